@@ -0,0 +1,157 @@
+package encodedTime
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// The binary encoding of the types is the one of time.Time, so it keeps the full precision and the zone offset.
+// It is used by encoding/gob, for instance for values in gorilla sessions or gob encoded caches.
+// The Null variants prefix it with a byte that is 1 for valid values.
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (t Millisecs) MarshalBinary() ([]byte, error) { return time.Time(t).MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (t *Millisecs) UnmarshalBinary(data []byte) error { return (*time.Time)(t).UnmarshalBinary(data) }
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (t Unix) MarshalBinary() ([]byte, error) { return time.Time(t).MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (t *Unix) UnmarshalBinary(data []byte) error { return (*time.Time)(t).UnmarshalBinary(data) }
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (t Seconds) MarshalBinary() ([]byte, error) { return time.Time(t).MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (t *Seconds) UnmarshalBinary(data []byte) error { return (*time.Time)(t).UnmarshalBinary(data) }
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (t Micros) MarshalBinary() ([]byte, error) { return time.Time(t).MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (t *Micros) UnmarshalBinary(data []byte) error { return (*time.Time)(t).UnmarshalBinary(data) }
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (t Nanos) MarshalBinary() ([]byte, error) { return time.Time(t).MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (t *Nanos) UnmarshalBinary(data []byte) error { return (*time.Time)(t).UnmarshalBinary(data) }
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (t Flexible) MarshalBinary() ([]byte, error) { return time.Time(t).MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (t *Flexible) UnmarshalBinary(data []byte) error { return (*time.Time)(t).UnmarshalBinary(data) }
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (t PreciseMillisecs) MarshalBinary() ([]byte, error) { return time.Time(t).MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (t *PreciseMillisecs) UnmarshalBinary(data []byte) error {
+	return (*time.Time)(t).UnmarshalBinary(data)
+}
+
+func marshalNullBinary(t time.Time, valid bool) ([]byte, error) {
+	if !valid {
+		return []byte{0}, nil
+	}
+	b, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{1}, b...), nil
+}
+
+func unmarshalNullBinary(data []byte) (time.Time, bool, error) {
+	if len(data) == 0 {
+		return time.Time{}, false, errors.New("encodedTime: no data for null time")
+	}
+	if data[0] == 0 {
+		return time.Time{}, false, nil
+	}
+	var t time.Time
+	err := t.UnmarshalBinary(data[1:])
+	return t, err == nil, err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (n NullMillisecs) MarshalBinary() ([]byte, error) { return marshalNullBinary(n.t, n.valid) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (n *NullMillisecs) UnmarshalBinary(data []byte) (err error) {
+	n.t, n.valid, err = unmarshalNullBinary(data)
+	return err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (n NullUnix) MarshalBinary() ([]byte, error) { return marshalNullBinary(n.t, n.valid) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (n *NullUnix) UnmarshalBinary(data []byte) (err error) {
+	n.t, n.valid, err = unmarshalNullBinary(data)
+	return err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (n NullSeconds) MarshalBinary() ([]byte, error) { return marshalNullBinary(n.t, n.valid) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (n *NullSeconds) UnmarshalBinary(data []byte) (err error) {
+	n.t, n.valid, err = unmarshalNullBinary(data)
+	return err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (n NullMicros) MarshalBinary() ([]byte, error) { return marshalNullBinary(n.t, n.valid) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (n *NullMicros) UnmarshalBinary(data []byte) (err error) {
+	n.t, n.valid, err = unmarshalNullBinary(data)
+	return err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (n NullNanos) MarshalBinary() ([]byte, error) { return marshalNullBinary(n.t, n.valid) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (n *NullNanos) UnmarshalBinary(data []byte) (err error) {
+	n.t, n.valid, err = unmarshalNullBinary(data)
+	return err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (n NullFlexible) MarshalBinary() ([]byte, error) { return marshalNullBinary(n.t, n.valid) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (n *NullFlexible) UnmarshalBinary(data []byte) (err error) {
+	n.t, n.valid, err = unmarshalNullBinary(data)
+	return err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (n NullPreciseMillisecs) MarshalBinary() ([]byte, error) { return marshalNullBinary(n.t, n.valid) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (n *NullPreciseMillisecs) UnmarshalBinary(data []byte) (err error) {
+	n.t, n.valid, err = unmarshalNullBinary(data)
+	return err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the nanoseconds as a big endian int64
+func (d DurationMillisecs) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(d))
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (d *DurationMillisecs) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return errors.New("encodedTime: invalid length of binary duration")
+	}
+	*d = DurationMillisecs(binary.BigEndian.Uint64(data))
+	return nil
+}
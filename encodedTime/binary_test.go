@@ -0,0 +1,73 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+)
+
+func TestGob(t *testing.T) {
+	type cached struct {
+		Created Millisecs
+		Seen    Flexible
+		Deleted NullUnix
+		Updated NullSeconds
+		TTL     DurationMillisecs
+	}
+
+	loc := time.FixedZone("test", 3600)
+	in := cached{
+		Created: Millisecs(time.Unix(12345, 678).In(loc)),
+		Seen:    Flexible(time.Unix(1449808143, 0)),
+		Updated: NewNullSeconds(time.Unix(1, 5e8)),
+		TTL:     DurationMillisecs(90 * time.Second),
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+
+	var out cached
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := time.Time(out.Created); !got.Equal(time.Time(in.Created)) {
+		t.Errorf("created: %s", got)
+	} else if _, offset := got.Zone(); offset != 3600 {
+		t.Errorf("created: %s", got)
+	}
+	if !time.Time(out.Seen).Equal(time.Time(in.Seen)) {
+		t.Errorf("seen: %s", time.Time(out.Seen))
+	}
+	if out.Deleted.Valid() {
+		t.Error("deleted should be invalid")
+	}
+	if !out.Updated.Valid() || !out.Updated.Time().Equal(in.Updated.Time()) {
+		t.Errorf("updated: %v %s", out.Updated.Valid(), out.Updated.Time())
+	}
+	if out.TTL != in.TTL {
+		t.Errorf("ttl: %s", time.Duration(out.TTL))
+	}
+}
+
+func TestGobInterface(t *testing.T) {
+	// like the values of a gorilla session
+	gob.Register(Millisecs{})
+
+	in := map[interface{}]interface{}{"created": Millisecs(time.Unix(12345, 0))}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out map[interface{}]interface{}
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := out["created"].(Millisecs); !ok || !time.Time(got).Equal(time.Unix(12345, 0)) {
+		t.Errorf("got %#v", out["created"])
+	}
+}
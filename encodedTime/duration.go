@@ -0,0 +1,62 @@
+package encodedTime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// DurationMillisecs is a duration encoded as a number of milliseconds, for fields like timeouts and retention periods.
+// Fractions of milliseconds are accepted and kept (up to nanoseconds).
+type DurationMillisecs time.Duration
+
+func (d *DurationMillisecs) UnmarshalJSON(in []byte) error {
+	ms, nsecs, err := parseDecimal(string(in), 6)
+	if err != nil {
+		return err
+	}
+
+	*d = DurationMillisecs(time.Duration(ms)*time.Millisecond + time.Duration(nsecs))
+	return nil
+}
+
+// MarshalJSON returns the milliseconds as an integer or, if there are fractions of them, as a decimal of up to six places
+func (d DurationMillisecs) MarshalJSON() ([]byte, error) {
+	dur := time.Duration(d)
+	ms, nsecs := int64(dur/time.Millisecond), int64(dur%time.Millisecond)
+	if nsecs == 0 {
+		return []byte(strconv.FormatInt(ms, 10)), nil
+	}
+
+	sign := ""
+	if nsecs < 0 {
+		sign, ms, nsecs = "-", -ms, -nsecs
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%06d", nsecs), "0")
+	return []byte(fmt.Sprintf("%s%d.%s", sign, ms, frac)), nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d DurationMillisecs) MarshalText() ([]byte, error) { return d.MarshalJSON() }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *DurationMillisecs) UnmarshalText(in []byte) error { return d.UnmarshalJSON(in) }
+
+func (d DurationMillisecs) EncodeMsgpack(e *msgpack.Encoder) error {
+	text, err := d.MarshalText()
+	if err != nil {
+		return err
+	}
+	return encodeText(e, text)
+}
+
+func (d *DurationMillisecs) DecodeMsgpack(dec *msgpack.Decoder) error {
+	text, err := decodeText(dec)
+	if err != nil || text == nil {
+		return err
+	}
+	return d.UnmarshalText(text)
+}
@@ -0,0 +1,43 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDurationMillisecs(t *testing.T) {
+	tcs := []struct {
+		json string
+		dur  time.Duration
+	}{
+		{`0`, 0},
+		{`1500`, 1500 * time.Millisecond},
+		{`0.25`, 250 * time.Microsecond},
+		{`-2.5`, -2500 * time.Microsecond},
+		{`86400000`, 24 * time.Hour},
+	}
+
+	for _, tc := range tcs {
+		var d DurationMillisecs
+		if err := json.Unmarshal([]byte(tc.json), &d); err != nil {
+			t.Fatalf("%s: %s", tc.json, err)
+		}
+		if time.Duration(d) != tc.dur {
+			t.Errorf("%s: got %s, want %s", tc.json, time.Duration(d), tc.dur)
+		}
+
+		out, err := json.Marshal(DurationMillisecs(tc.dur))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tc.json {
+			t.Errorf("%s: marshaled to %s", tc.dur, out)
+		}
+	}
+
+	var v struct{ Timeout DurationMillisecs }
+	if err := json.Unmarshal([]byte(`{"Timeout":"5s"}`), &v); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
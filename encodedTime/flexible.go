@@ -0,0 +1,97 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Format is the canonical form Flexible times are marshaled to
+type Format int
+
+// the formats for FlexibleFormat
+const (
+	FormatRFC3339 Format = iota
+	FormatMillisecs
+	FormatSeconds
+)
+
+// FlexibleFormat sets how all Flexible times are marshaled (RFC3339 with nanoseconds by default)
+var FlexibleFormat = FormatRFC3339
+
+// numbers with at least this magnitude are taken as milliseconds, smaller ones as seconds.
+// 1e11 seconds are in the year 5138 while 1e11 milliseconds are in 1973.
+const flexMillisThreshold = 1e11
+
+// Flexible is a time that is decoded from epoch milliseconds, epoch seconds or an RFC3339 string, for payloads that mix them.
+// Numbers are told apart by their magnitude, so millisecond timestamps before March 1973 are read as seconds.
+// They can also be passed as strings. null leaves the time unchanged.
+type Flexible time.Time
+
+func (t *Flexible) UnmarshalJSON(in []byte) error {
+	if bytes.Equal(in, jsonNull) {
+		return nil
+	}
+
+	s := string(in)
+	if len(in) > 0 && in[0] == '"' {
+		if err := json.Unmarshal(in, &s); err != nil {
+			return err
+		}
+	}
+	return t.UnmarshalText([]byte(s))
+}
+
+// UnmarshalText is like UnmarshalJSON for unquoted text
+func (t *Flexible) UnmarshalText(in []byte) error {
+	s := string(in)
+	if tt, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		if err := checkRange(tt); err != nil {
+			return err
+		}
+		*t = Flexible(tt)
+		return nil
+	}
+
+	whole, frac, err := parseDecimal(s, 9)
+	if err != nil {
+		return fmt.Errorf("encodedTime: %q is neither an epoch timestamp nor RFC3339", s)
+	}
+
+	if whole >= flexMillisThreshold || whole <= -flexMillisThreshold {
+		// the fraction is of a millisecond
+		tt := time.UnixMilli(whole).Add(time.Duration(frac / 1e6))
+		if err := checkRange(tt); err != nil {
+			return err
+		}
+		*t = Flexible(tt)
+		return nil
+	}
+	tt := time.Unix(whole, frac)
+	if err := checkRange(tt); err != nil {
+		return err
+	}
+	*t = Flexible(tt)
+	return nil
+}
+
+func (t Flexible) MarshalJSON() ([]byte, error) {
+	switch FlexibleFormat {
+	case FormatMillisecs:
+		return []byte(strconv.FormatInt(time.Time(t).UnixMilli(), 10)), nil
+	case FormatSeconds:
+		return Seconds(t).MarshalJSON()
+	default:
+		return time.Time(t).MarshalJSON()
+	}
+}
+
+// MarshalText is like MarshalJSON without the quotes of RFC3339
+func (t Flexible) MarshalText() ([]byte, error) {
+	if FlexibleFormat == FormatRFC3339 {
+		return time.Time(t).MarshalText()
+	}
+	return t.MarshalJSON()
+}
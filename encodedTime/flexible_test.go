@@ -0,0 +1,59 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFlexibleUnmarshal(t *testing.T) {
+	want := time.Unix(1449808143, 436000000)
+
+	for _, in := range []string{
+		`1449808143436`,
+		`"1449808143436"`,
+		`1449808143.436`,
+		`"2015-12-11T04:29:03.436Z"`,
+		`"2015-12-11T05:29:03.436+01:00"`,
+	} {
+		var v struct{ Date Flexible }
+		if err := json.Unmarshal([]byte(`{"Date":`+in+`}`), &v); err != nil {
+			t.Fatalf("%s: %s", in, err)
+		}
+		if got := time.Time(v.Date); !got.Equal(want) {
+			t.Errorf("%s: got %s, want %s", in, got, want)
+		}
+	}
+
+	v := struct{ Date Flexible }{Flexible(want)}
+	if err := json.Unmarshal([]byte(`{"Date":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(v.Date).Equal(want) {
+		t.Error("null should leave the time unchanged")
+	}
+
+	if err := json.Unmarshal([]byte(`{"Date":"yesterday"}`), &v); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestFlexibleMarshal(t *testing.T) {
+	defer func() { FlexibleFormat = FormatRFC3339 }()
+
+	v := Flexible(time.Unix(1449808143, 436000000).UTC())
+	for f, want := range map[Format]string{
+		FormatRFC3339:   `"2015-12-11T04:29:03.436Z"`,
+		FormatMillisecs: `1449808143436`,
+		FormatSeconds:   `1449808143.436`,
+	} {
+		FlexibleFormat = f
+		out, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != want {
+			t.Errorf("format %d: got %s, want %s", f, out, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package encodedTime
+
+import (
+	"strconv"
+	"time"
+)
+
+// Micros is a time encoded as a number of microseconds since unix-0, like the timestamps of Zipkin spans.
+// Like Millisecs it accepts numbers with a fractional part, which is kept up to nanoseconds.
+type Micros time.Time
+
+// NewMicros returns a Micros instance with micros since unix-0
+func NewMicros(micros int64) Micros {
+	return Micros(time.UnixMicro(micros))
+}
+
+func (t *Micros) UnmarshalJSON(in []byte) error {
+	micros, nsecs, err := parseDecimal(string(in), 3)
+	if err != nil {
+		return err
+	}
+
+	tt := time.UnixMicro(micros).Add(time.Duration(nsecs))
+	if err := checkRange(tt); err != nil {
+		return err
+	}
+	*t = Micros(tt)
+	return nil
+}
+
+func (t Micros) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(time.Time(t).UnixMicro(), 10)), nil
+}
+
+// Nanos is a time encoded as a number of nanoseconds since unix-0, like the timestamps of OpenTelemetry exporters.
+// A fractional part is accepted but dropped.
+// Only times between the years 1678 and 2262 can be marshaled.
+type Nanos time.Time
+
+// NewNanos returns a Nanos instance with nanos since unix-0
+func NewNanos(nanos int64) Nanos {
+	return Nanos(time.Unix(0, nanos))
+}
+
+func (t *Nanos) UnmarshalJSON(in []byte) error {
+	nanos, _, err := parseDecimal(string(in), 0)
+	if err != nil {
+		return err
+	}
+
+	tt := time.Unix(0, nanos)
+	if err := checkRange(tt); err != nil {
+		return err
+	}
+	*t = Nanos(tt)
+	return nil
+}
+
+func (t Nanos) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(time.Time(t).UnixNano(), 10)), nil
+}
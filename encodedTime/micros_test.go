@@ -0,0 +1,51 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMicros(t *testing.T) {
+	var v struct{ Timestamp Micros }
+	if err := json.Unmarshal([]byte(`{"Timestamp":1449808143436123.5}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Unix(1449808143, 436123500)
+	if got := time.Time(v.Timestamp); !got.Equal(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"Timestamp":1449808143436123}` {
+		t.Fatalf("got %s", out)
+	}
+}
+
+func TestNanos(t *testing.T) {
+	var v struct{ Timestamp Nanos }
+	if err := json.Unmarshal([]byte(`{"Timestamp":1449808143436123789}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Unix(1449808143, 436123789)
+	if got := time.Time(v.Timestamp); !got.Equal(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	out, err := json.Marshal(NewNanos(-42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `-42` {
+		t.Fatalf("got %s", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Timestamp":"soon"}`), &v); err == nil {
+		t.Fatal("expected an error for a string")
+	}
+}
@@ -29,7 +29,11 @@ func (t *Millisecs) UnmarshalJSON(in []byte) (err error) {
 		return err
 	}
 
-	*t = Millisecs(time.Unix(secs/int64(i), 0))
+	tt := time.Unix(secs/int64(i), 0)
+	if err := checkRange(tt); err != nil {
+		return err
+	}
+	*t = Millisecs(tt)
 	return nil
 }
 
@@ -0,0 +1,335 @@
+package encodedTime
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// The msgpack encoding of the types uses the same numbers as their JSON encoding, as msgpack integers
+// (or floats if there are fractions), so that it matches what other languages send. Flexible times in RFC3339 are strings.
+// nil leaves the plain types unchanged and makes the Null variants invalid.
+
+// encodeText writes the text encoding of a type as a msgpack number or string
+func encodeText(e *msgpack.Encoder, text []byte) error {
+	if n, err := strconv.ParseInt(string(text), 10, 64); err == nil {
+		return e.EncodeInt(n)
+	}
+	if f, err := strconv.ParseFloat(string(text), 64); err == nil {
+		return e.EncodeFloat64(f)
+	}
+	// RFC3339
+	return e.EncodeString(string(text))
+}
+
+// decodeText reads a msgpack number or string as text for UnmarshalText and returns nil for nil
+func decodeText(d *msgpack.Decoder) ([]byte, error) {
+	c, err := d.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c == msgpcode.Nil:
+		return nil, d.DecodeNil()
+
+	case c == msgpcode.Float || c == msgpcode.Double:
+		f, err := d.DecodeFloat64()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.FormatFloat(f, 'f', -1, 64)), nil
+
+	case msgpcode.IsString(c):
+		s, err := d.DecodeString()
+		return []byte(s), err
+
+	default:
+		n, err := d.DecodeInt64()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.FormatInt(n, 10)), nil
+	}
+}
+
+func (t Millisecs) EncodeMsgpack(e *msgpack.Encoder) error {
+	text, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return encodeText(e, text)
+}
+
+func (t *Millisecs) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil || text == nil {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Unix) EncodeMsgpack(e *msgpack.Encoder) error {
+	text, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return encodeText(e, text)
+}
+
+func (t *Unix) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil || text == nil {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Seconds) EncodeMsgpack(e *msgpack.Encoder) error {
+	text, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return encodeText(e, text)
+}
+
+func (t *Seconds) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil || text == nil {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Micros) EncodeMsgpack(e *msgpack.Encoder) error {
+	text, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return encodeText(e, text)
+}
+
+func (t *Micros) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil || text == nil {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Nanos) EncodeMsgpack(e *msgpack.Encoder) error {
+	text, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return encodeText(e, text)
+}
+
+func (t *Nanos) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil || text == nil {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Flexible) EncodeMsgpack(e *msgpack.Encoder) error {
+	text, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return encodeText(e, text)
+}
+
+func (t *Flexible) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil || text == nil {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (n NullMillisecs) EncodeMsgpack(e *msgpack.Encoder) error {
+	if !n.valid {
+		return e.EncodeNil()
+	}
+	return Millisecs(n.t).EncodeMsgpack(e)
+}
+
+func (n *NullMillisecs) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*n = NullMillisecs{}
+		return nil
+	}
+	var v Millisecs
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullMillisecs(time.Time(v))
+	return nil
+}
+
+func (n NullUnix) EncodeMsgpack(e *msgpack.Encoder) error {
+	if !n.valid {
+		return e.EncodeNil()
+	}
+	return Unix(n.t).EncodeMsgpack(e)
+}
+
+func (n *NullUnix) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*n = NullUnix{}
+		return nil
+	}
+	var v Unix
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullUnix(time.Time(v))
+	return nil
+}
+
+func (n NullSeconds) EncodeMsgpack(e *msgpack.Encoder) error {
+	if !n.valid {
+		return e.EncodeNil()
+	}
+	return Seconds(n.t).EncodeMsgpack(e)
+}
+
+func (n *NullSeconds) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*n = NullSeconds{}
+		return nil
+	}
+	var v Seconds
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullSeconds(time.Time(v))
+	return nil
+}
+
+func (n NullMicros) EncodeMsgpack(e *msgpack.Encoder) error {
+	if !n.valid {
+		return e.EncodeNil()
+	}
+	return Micros(n.t).EncodeMsgpack(e)
+}
+
+func (n *NullMicros) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*n = NullMicros{}
+		return nil
+	}
+	var v Micros
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullMicros(time.Time(v))
+	return nil
+}
+
+func (n NullNanos) EncodeMsgpack(e *msgpack.Encoder) error {
+	if !n.valid {
+		return e.EncodeNil()
+	}
+	return Nanos(n.t).EncodeMsgpack(e)
+}
+
+func (n *NullNanos) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*n = NullNanos{}
+		return nil
+	}
+	var v Nanos
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullNanos(time.Time(v))
+	return nil
+}
+
+func (n NullFlexible) EncodeMsgpack(e *msgpack.Encoder) error {
+	if !n.valid {
+		return e.EncodeNil()
+	}
+	return Flexible(n.t).EncodeMsgpack(e)
+}
+
+func (n *NullFlexible) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*n = NullFlexible{}
+		return nil
+	}
+	var v Flexible
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullFlexible(time.Time(v))
+	return nil
+}
+func (t PreciseMillisecs) EncodeMsgpack(e *msgpack.Encoder) error {
+	text, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return encodeText(e, text)
+}
+
+func (t *PreciseMillisecs) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil || text == nil {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (n NullPreciseMillisecs) EncodeMsgpack(e *msgpack.Encoder) error {
+	if !n.valid {
+		return e.EncodeNil()
+	}
+	return PreciseMillisecs(n.t).EncodeMsgpack(e)
+}
+
+func (n *NullPreciseMillisecs) DecodeMsgpack(d *msgpack.Decoder) error {
+	text, err := decodeText(d)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*n = NullPreciseMillisecs{}
+		return nil
+	}
+	var v PreciseMillisecs
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullPreciseMillisecs(time.Time(v))
+	return nil
+}
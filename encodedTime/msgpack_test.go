@@ -0,0 +1,74 @@
+package encodedTime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpack(t *testing.T) {
+	type payload struct {
+		Created Millisecs
+		Updated Seconds
+		Seen    Flexible
+		Deleted NullMillisecs
+	}
+
+	in := payload{
+		Created: Millisecs(time.Unix(12345, 0)),
+		Updated: Seconds(time.Unix(-1, -5e8)),
+		Seen:    Flexible(time.Unix(1449808143, 0).UTC()),
+	}
+	b, err := msgpack.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the numbers are plain msgpack values
+	var raw struct {
+		Created int64
+		Updated float64
+		Seen    string
+		Deleted *int64
+	}
+	if err := msgpack.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.Created != 12345000 || raw.Updated != -1.5 || raw.Seen != "2015-12-11T04:29:03Z" || raw.Deleted != nil {
+		t.Errorf("got %+v", raw)
+	}
+
+	var out payload
+	if err := msgpack.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(out.Updated).Equal(time.Unix(-1, -5e8)) || !time.Time(out.Seen).Equal(time.Unix(1449808143, 0)) || out.Deleted.Valid() {
+		t.Errorf("got %+v", out)
+	}
+	// Millisecs keeps its JSON semantics, which are precise to seconds
+	if !time.Time(out.Created).Equal(time.Unix(12345, 0)) {
+		t.Errorf("created: %s", time.Time(out.Created))
+	}
+}
+
+func TestMsgpackFromOtherTypes(t *testing.T) {
+	b, err := msgpack.Marshal(map[string]interface{}{"Created": 1449808143436, "Deleted": 1449808143436.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		Created Millisecs
+		Deleted NullMicros
+	}
+	if err := msgpack.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(out.Created).Equal(time.Unix(1449808143, 0)) {
+		t.Errorf("created: %s", time.Time(out.Created))
+	}
+	if !out.Deleted.Valid() || !out.Deleted.Time().Equal(time.UnixMicro(1449808143436).Add(500)) {
+		t.Errorf("deleted: %v %s", out.Deleted.Valid(), out.Deleted.Time())
+	}
+}
@@ -0,0 +1,292 @@
+package encodedTime
+
+import (
+	"bytes"
+	"time"
+)
+
+// The Null variants of the types can also be null.
+// JSON null and missing fields leave them invalid, which is marshaled as null again.
+// Their zero value is invalid and IsZero reports that, so they can be left out with the omitzero tag option (Go 1.24 and later).
+
+var jsonNull = []byte("null")
+
+// NullMillisecs is a Millisecs which can be null
+type NullMillisecs struct {
+	t     time.Time
+	valid bool
+}
+
+// NewNullMillisecs returns a valid NullMillisecs for t
+func NewNullMillisecs(t time.Time) NullMillisecs {
+	return NullMillisecs{t: t, valid: true}
+}
+
+// Valid returns false if the value was null or missing
+func (n NullMillisecs) Valid() bool { return n.valid }
+
+// Time returns the time or the zero time if it isn't valid
+func (n NullMillisecs) Time() time.Time { return n.t }
+
+// IsZero reports whether the value is invalid
+func (n NullMillisecs) IsZero() bool { return !n.valid }
+
+func (n *NullMillisecs) UnmarshalJSON(in []byte) error {
+	if bytes.Equal(in, jsonNull) {
+		*n = NullMillisecs{}
+		return nil
+	}
+	var v Millisecs
+	if err := v.UnmarshalJSON(in); err != nil {
+		return err
+	}
+	*n = NewNullMillisecs(time.Time(v))
+	return nil
+}
+
+func (n NullMillisecs) MarshalJSON() ([]byte, error) {
+	if !n.valid {
+		return jsonNull, nil
+	}
+	return Millisecs(n.t).MarshalJSON()
+}
+
+// NullUnix is a Unix which can be null
+type NullUnix struct {
+	t     time.Time
+	valid bool
+}
+
+// NewNullUnix returns a valid NullUnix for t
+func NewNullUnix(t time.Time) NullUnix {
+	return NullUnix{t: t, valid: true}
+}
+
+// Valid returns false if the value was null or missing
+func (n NullUnix) Valid() bool { return n.valid }
+
+// Time returns the time or the zero time if it isn't valid
+func (n NullUnix) Time() time.Time { return n.t }
+
+// IsZero reports whether the value is invalid
+func (n NullUnix) IsZero() bool { return !n.valid }
+
+func (n *NullUnix) UnmarshalJSON(in []byte) error {
+	if bytes.Equal(in, jsonNull) {
+		*n = NullUnix{}
+		return nil
+	}
+	var v Unix
+	if err := v.UnmarshalJSON(in); err != nil {
+		return err
+	}
+	*n = NewNullUnix(time.Time(v))
+	return nil
+}
+
+func (n NullUnix) MarshalJSON() ([]byte, error) {
+	if !n.valid {
+		return jsonNull, nil
+	}
+	return Unix(n.t).MarshalJSON()
+}
+
+// NullSeconds is a Seconds which can be null
+type NullSeconds struct {
+	t     time.Time
+	valid bool
+}
+
+// NewNullSeconds returns a valid NullSeconds for t
+func NewNullSeconds(t time.Time) NullSeconds {
+	return NullSeconds{t: t, valid: true}
+}
+
+// Valid returns false if the value was null or missing
+func (n NullSeconds) Valid() bool { return n.valid }
+
+// Time returns the time or the zero time if it isn't valid
+func (n NullSeconds) Time() time.Time { return n.t }
+
+// IsZero reports whether the value is invalid
+func (n NullSeconds) IsZero() bool { return !n.valid }
+
+func (n *NullSeconds) UnmarshalJSON(in []byte) error {
+	if bytes.Equal(in, jsonNull) {
+		*n = NullSeconds{}
+		return nil
+	}
+	var v Seconds
+	if err := v.UnmarshalJSON(in); err != nil {
+		return err
+	}
+	*n = NewNullSeconds(time.Time(v))
+	return nil
+}
+
+func (n NullSeconds) MarshalJSON() ([]byte, error) {
+	if !n.valid {
+		return jsonNull, nil
+	}
+	return Seconds(n.t).MarshalJSON()
+}
+
+// NullMicros is a Micros which can be null
+type NullMicros struct {
+	t     time.Time
+	valid bool
+}
+
+// NewNullMicros returns a valid NullMicros for t
+func NewNullMicros(t time.Time) NullMicros {
+	return NullMicros{t: t, valid: true}
+}
+
+// Valid returns false if the value was null or missing
+func (n NullMicros) Valid() bool { return n.valid }
+
+// Time returns the time or the zero time if it isn't valid
+func (n NullMicros) Time() time.Time { return n.t }
+
+// IsZero reports whether the value is invalid
+func (n NullMicros) IsZero() bool { return !n.valid }
+
+func (n *NullMicros) UnmarshalJSON(in []byte) error {
+	if bytes.Equal(in, jsonNull) {
+		*n = NullMicros{}
+		return nil
+	}
+	var v Micros
+	if err := v.UnmarshalJSON(in); err != nil {
+		return err
+	}
+	*n = NewNullMicros(time.Time(v))
+	return nil
+}
+
+func (n NullMicros) MarshalJSON() ([]byte, error) {
+	if !n.valid {
+		return jsonNull, nil
+	}
+	return Micros(n.t).MarshalJSON()
+}
+
+// NullNanos is a Nanos which can be null
+type NullNanos struct {
+	t     time.Time
+	valid bool
+}
+
+// NewNullNanos returns a valid NullNanos for t
+func NewNullNanos(t time.Time) NullNanos {
+	return NullNanos{t: t, valid: true}
+}
+
+// Valid returns false if the value was null or missing
+func (n NullNanos) Valid() bool { return n.valid }
+
+// Time returns the time or the zero time if it isn't valid
+func (n NullNanos) Time() time.Time { return n.t }
+
+// IsZero reports whether the value is invalid
+func (n NullNanos) IsZero() bool { return !n.valid }
+
+func (n *NullNanos) UnmarshalJSON(in []byte) error {
+	if bytes.Equal(in, jsonNull) {
+		*n = NullNanos{}
+		return nil
+	}
+	var v Nanos
+	if err := v.UnmarshalJSON(in); err != nil {
+		return err
+	}
+	*n = NewNullNanos(time.Time(v))
+	return nil
+}
+
+func (n NullNanos) MarshalJSON() ([]byte, error) {
+	if !n.valid {
+		return jsonNull, nil
+	}
+	return Nanos(n.t).MarshalJSON()
+}
+
+// NullFlexible is a Flexible which can be null
+type NullFlexible struct {
+	t     time.Time
+	valid bool
+}
+
+// NewNullFlexible returns a valid NullFlexible for t
+func NewNullFlexible(t time.Time) NullFlexible {
+	return NullFlexible{t: t, valid: true}
+}
+
+// Valid returns false if the value was null or missing
+func (n NullFlexible) Valid() bool { return n.valid }
+
+// Time returns the time or the zero time if it isn't valid
+func (n NullFlexible) Time() time.Time { return n.t }
+
+// IsZero reports whether the value is invalid
+func (n NullFlexible) IsZero() bool { return !n.valid }
+
+func (n *NullFlexible) UnmarshalJSON(in []byte) error {
+	if bytes.Equal(in, jsonNull) {
+		*n = NullFlexible{}
+		return nil
+	}
+	var v Flexible
+	if err := v.UnmarshalJSON(in); err != nil {
+		return err
+	}
+	*n = NewNullFlexible(time.Time(v))
+	return nil
+}
+
+func (n NullFlexible) MarshalJSON() ([]byte, error) {
+	if !n.valid {
+		return jsonNull, nil
+	}
+	return Flexible(n.t).MarshalJSON()
+}
+
+// NullPreciseMillisecs is a PreciseMillisecs which can be null
+type NullPreciseMillisecs struct {
+	t     time.Time
+	valid bool
+}
+
+// NewNullPreciseMillisecs returns a valid NullPreciseMillisecs for t
+func NewNullPreciseMillisecs(t time.Time) NullPreciseMillisecs {
+	return NullPreciseMillisecs{t: t, valid: true}
+}
+
+// Valid returns false if the value was null or missing
+func (n NullPreciseMillisecs) Valid() bool { return n.valid }
+
+// Time returns the time or the zero time if it isn't valid
+func (n NullPreciseMillisecs) Time() time.Time { return n.t }
+
+// IsZero reports whether the value is invalid
+func (n NullPreciseMillisecs) IsZero() bool { return !n.valid }
+
+func (n *NullPreciseMillisecs) UnmarshalJSON(in []byte) error {
+	if bytes.Equal(in, jsonNull) {
+		*n = NullPreciseMillisecs{}
+		return nil
+	}
+	var v PreciseMillisecs
+	if err := v.UnmarshalJSON(in); err != nil {
+		return err
+	}
+	*n = NewNullPreciseMillisecs(time.Time(v))
+	return nil
+}
+
+func (n NullPreciseMillisecs) MarshalJSON() ([]byte, error) {
+	if !n.valid {
+		return jsonNull, nil
+	}
+	return PreciseMillisecs(n.t).MarshalJSON()
+}
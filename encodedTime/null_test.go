@@ -0,0 +1,57 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNullMillisecs(t *testing.T) {
+	var v struct {
+		A NullMillisecs
+		B NullMillisecs
+		C NullMillisecs
+	}
+	if err := json.Unmarshal([]byte(`{"A":12345000,"B":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !v.A.Valid() || !v.A.Time().Equal(time.Unix(12345, 0)) {
+		t.Errorf("A: got %v %s", v.A.Valid(), v.A.Time())
+	}
+	if v.B.Valid() || v.C.Valid() {
+		t.Error("null and missing should be invalid")
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"A":12345000,"B":null,"C":null}` {
+		t.Errorf("got %s", out)
+	}
+
+	// a valid zero time is not null
+	out, err = json.Marshal(NewNullUnix(time.Unix(0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `0` {
+		t.Errorf("got %s", out)
+	}
+}
+
+func TestNullVariantsReset(t *testing.T) {
+	n := NewNullSeconds(time.Unix(1, 0))
+	if err := json.Unmarshal([]byte(`null`), &n); err != nil {
+		t.Fatal(err)
+	}
+	if n.Valid() || !n.IsZero() {
+		t.Error("null should reset to invalid")
+	}
+
+	var f NullFlexible
+	if err := json.Unmarshal([]byte(`"bogus"`), &f); err == nil {
+		t.Error("expected the error of the underlying type")
+	}
+}
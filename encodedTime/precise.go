@@ -0,0 +1,47 @@
+package encodedTime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PreciseMillisecs is like Millisecs but keeps the fraction of the milliseconds up to microseconds,
+// instead of truncating to seconds, so that timestamps keep their order.
+// A number like 1553708494043.0059 is read as milliseconds, not with the digit shifting of Millisecs.
+type PreciseMillisecs time.Time
+
+// NewPreciseMillisecs returns a PreciseMillisecs instance with millis since unix-0
+func NewPreciseMillisecs(millis int64) PreciseMillisecs {
+	return PreciseMillisecs(time.UnixMilli(millis))
+}
+
+func (t *PreciseMillisecs) UnmarshalJSON(in []byte) error {
+	ms, micros, err := parseDecimal(string(in), 3)
+	if err != nil {
+		return err
+	}
+
+	tt := time.UnixMilli(ms).Add(time.Duration(micros) * time.Microsecond)
+	if err := checkRange(tt); err != nil {
+		return err
+	}
+	*t = PreciseMillisecs(tt)
+	return nil
+}
+
+// MarshalJSON returns the milliseconds as an integer or, if there are microseconds, as a decimal of up to three places
+func (t PreciseMillisecs) MarshalJSON() ([]byte, error) {
+	micros := time.Time(t).UnixMicro()
+	ms, frac := micros/1000, micros%1000
+	if frac == 0 {
+		return []byte(strconv.FormatInt(ms, 10)), nil
+	}
+
+	sign := ""
+	if frac < 0 {
+		sign, ms, frac = "-", -ms, -frac
+	}
+	return []byte(fmt.Sprintf("%s%d.%s", sign, ms, strings.TrimRight(fmt.Sprintf("%03d", frac), "0"))), nil
+}
@@ -0,0 +1,56 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPreciseMillisecs(t *testing.T) {
+	var v struct{ Timestamp PreciseMillisecs }
+	if err := json.Unmarshal([]byte(`{"Timestamp":1553708494043.0059}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Unix(1553708494, 43005000)
+	if got := time.Time(v.Timestamp); !got.Equal(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"Timestamp":1553708494043.005}` {
+		t.Fatalf("got %s", out)
+	}
+
+	for _, tc := range []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Unix(12345, 0), `12345000`},
+		{time.Unix(0, -1500000), `-1.5`},
+	} {
+		out, err := json.Marshal(PreciseMillisecs(tc.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tc.want {
+			t.Errorf("got %s, want %s", out, tc.want)
+		}
+	}
+}
+
+func TestPreciseMillisecsOrder(t *testing.T) {
+	var a, b PreciseMillisecs
+	if err := json.Unmarshal([]byte(`1553708494043.001`), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`1553708494043.002`), &b); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(a).Before(time.Time(b)) {
+		t.Error("should keep the order of sub-millisecond timestamps")
+	}
+}
@@ -0,0 +1,99 @@
+package encodedTime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Seconds is a time encoded as unix seconds.
+// Unlike Unix it also accepts numbers with fractional seconds (like 1553708494.043) and keeps them when marshaling.
+type Seconds time.Time
+
+// NewSeconds returns a Seconds instance with secs since unix-0
+func NewSeconds(secs int64) Seconds {
+	return Seconds(time.Unix(secs, 0))
+}
+
+// UnmarshalJSON parses the integer and fractional part separately, so that no precision is lost to floats (up to nanoseconds)
+func (t *Seconds) UnmarshalJSON(in []byte) error {
+	s := string(in)
+	if strings.ContainsAny(s, "eE") {
+		// exponents are rare enough to accept the float rounding
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		secs := int64(f)
+		tt := time.Unix(secs, int64((f-float64(secs))*1e9))
+		if err := checkRange(tt); err != nil {
+			return err
+		}
+		*t = Seconds(tt)
+		return nil
+	}
+
+	secs, nsecs, err := parseDecimal(s, 9)
+	if err != nil {
+		return err
+	}
+
+	tt := time.Unix(secs, nsecs)
+	if err := checkRange(tt); err != nil {
+		return err
+	}
+	*t = Seconds(tt)
+	return nil
+}
+
+// parseDecimal returns the integer part of s and its fraction as an integer with the passed number of places.
+// Further places are truncated, the fraction has the same sign as the integer part.
+func parseDecimal(s string, places int) (whole, frac int64, err error) {
+	ws, fs := s, ""
+	if i := strings.IndexByte(s, '.'); i != -1 {
+		ws, fs = s[:i], s[i+1:]
+	}
+
+	whole, err = strconv.ParseInt(ws, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if fs == "" || places == 0 {
+		return whole, 0, nil
+	}
+	if len(fs) > places {
+		fs = fs[:places]
+	}
+	fs += strings.Repeat("0", places-len(fs))
+	f, err := strconv.ParseUint(fs, 10, 63)
+	if err != nil {
+		return 0, 0, fmt.Errorf("encodedTime: invalid fraction in %q", s)
+	}
+	frac = int64(f)
+	if strings.HasPrefix(ws, "-") {
+		frac = -frac
+	}
+	return whole, frac, nil
+}
+
+// MarshalJSON returns the seconds as an integer or, if the time has fractional seconds, as a decimal of up to nine places
+func (t Seconds) MarshalJSON() ([]byte, error) {
+	tt := time.Time(t)
+	secs, nsecs := tt.Unix(), tt.Nanosecond()
+	if nsecs == 0 {
+		return []byte(strconv.FormatInt(secs, 10)), nil
+	}
+
+	sign := ""
+	if secs < 0 {
+		// time.Unix normalizes to a positive nanosecond part
+		secs, nsecs = secs+1, 1e9-nsecs
+		if secs == 0 {
+			sign = "-"
+		}
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%09d", nsecs), "0")
+	return []byte(fmt.Sprintf("%s%d.%s", sign, secs, frac)), nil
+}
@@ -0,0 +1,65 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSecondsUnmarshal(t *testing.T) {
+	tcs := []struct {
+		in   string
+		want time.Time
+	}{
+		{`12345`, time.Unix(12345, 0)},
+		{`1553708494.043`, time.Unix(1553708494, 43000000)},
+		{`1553708494.0430059`, time.Unix(1553708494, 43005900)},
+		{`1.5e9`, time.Unix(1500000000, 0)},
+		{`-1.5`, time.Unix(-1, -500000000)},
+	}
+
+	for _, tc := range tcs {
+		var v struct{ Date Seconds }
+		if err := json.Unmarshal([]byte(`{"Date":`+tc.in+`}`), &v); err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		if got := time.Time(v.Date); !got.Equal(tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.in, got, tc.want)
+		}
+	}
+
+	var v struct{ Date Seconds }
+	if err := json.Unmarshal([]byte(`{"Date":"12345"}`), &v); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
+
+func TestSecondsMarshal(t *testing.T) {
+	tcs := []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Unix(12345, 0), `12345`},
+		{time.Unix(1553708494, 43000000), `1553708494.043`},
+		{time.Unix(-1, -500000000), `-1.5`},
+		{time.Unix(0, -250000000), `-0.25`},
+	}
+
+	for _, tc := range tcs {
+		out, err := json.Marshal(Seconds(tc.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tc.want {
+			t.Errorf("got %s, want %s", out, tc.want)
+		}
+
+		var back Seconds
+		if err := json.Unmarshal(out, &back); err != nil {
+			t.Fatal(err)
+		}
+		if !time.Time(back).Equal(tc.in) {
+			t.Errorf("%s: roundtrip got %s", tc.want, time.Time(back))
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package encodedTime
+
+import "time"
+
+// The text encoding of the epoch types is the same number as their JSON encoding,
+// so that they can be used as map keys, in URL queries and XML attributes.
+// The Null variants encode invalid values as empty text.
+
+// MarshalText implements encoding.TextMarshaler
+func (t Millisecs) MarshalText() ([]byte, error) { return t.MarshalJSON() }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Millisecs) UnmarshalText(in []byte) error { return t.UnmarshalJSON(in) }
+
+// MarshalText implements encoding.TextMarshaler
+func (t Unix) MarshalText() ([]byte, error) { return t.MarshalJSON() }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Unix) UnmarshalText(in []byte) error { return t.UnmarshalJSON(in) }
+
+// MarshalText implements encoding.TextMarshaler
+func (t Seconds) MarshalText() ([]byte, error) { return t.MarshalJSON() }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Seconds) UnmarshalText(in []byte) error { return t.UnmarshalJSON(in) }
+
+// MarshalText implements encoding.TextMarshaler
+func (t Micros) MarshalText() ([]byte, error) { return t.MarshalJSON() }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Micros) UnmarshalText(in []byte) error { return t.UnmarshalJSON(in) }
+
+// MarshalText implements encoding.TextMarshaler
+func (t Nanos) MarshalText() ([]byte, error) { return t.MarshalJSON() }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Nanos) UnmarshalText(in []byte) error { return t.UnmarshalJSON(in) }
+
+// MarshalText implements encoding.TextMarshaler
+func (n NullMillisecs) MarshalText() ([]byte, error) {
+	if !n.valid {
+		return []byte{}, nil
+	}
+	return Millisecs(n.t).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (n *NullMillisecs) UnmarshalText(in []byte) error {
+	if len(in) == 0 {
+		*n = NullMillisecs{}
+		return nil
+	}
+	var v Millisecs
+	if err := v.UnmarshalText(in); err != nil {
+		return err
+	}
+	*n = NewNullMillisecs(time.Time(v))
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (n NullUnix) MarshalText() ([]byte, error) {
+	if !n.valid {
+		return []byte{}, nil
+	}
+	return Unix(n.t).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (n *NullUnix) UnmarshalText(in []byte) error {
+	if len(in) == 0 {
+		*n = NullUnix{}
+		return nil
+	}
+	var v Unix
+	if err := v.UnmarshalText(in); err != nil {
+		return err
+	}
+	*n = NewNullUnix(time.Time(v))
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (n NullSeconds) MarshalText() ([]byte, error) {
+	if !n.valid {
+		return []byte{}, nil
+	}
+	return Seconds(n.t).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (n *NullSeconds) UnmarshalText(in []byte) error {
+	if len(in) == 0 {
+		*n = NullSeconds{}
+		return nil
+	}
+	var v Seconds
+	if err := v.UnmarshalText(in); err != nil {
+		return err
+	}
+	*n = NewNullSeconds(time.Time(v))
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (n NullMicros) MarshalText() ([]byte, error) {
+	if !n.valid {
+		return []byte{}, nil
+	}
+	return Micros(n.t).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (n *NullMicros) UnmarshalText(in []byte) error {
+	if len(in) == 0 {
+		*n = NullMicros{}
+		return nil
+	}
+	var v Micros
+	if err := v.UnmarshalText(in); err != nil {
+		return err
+	}
+	*n = NewNullMicros(time.Time(v))
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (n NullNanos) MarshalText() ([]byte, error) {
+	if !n.valid {
+		return []byte{}, nil
+	}
+	return Nanos(n.t).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (n *NullNanos) UnmarshalText(in []byte) error {
+	if len(in) == 0 {
+		*n = NullNanos{}
+		return nil
+	}
+	var v Nanos
+	if err := v.UnmarshalText(in); err != nil {
+		return err
+	}
+	*n = NewNullNanos(time.Time(v))
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (n NullFlexible) MarshalText() ([]byte, error) {
+	if !n.valid {
+		return []byte{}, nil
+	}
+	return Flexible(n.t).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (n *NullFlexible) UnmarshalText(in []byte) error {
+	if len(in) == 0 {
+		*n = NullFlexible{}
+		return nil
+	}
+	var v Flexible
+	if err := v.UnmarshalText(in); err != nil {
+		return err
+	}
+	*n = NewNullFlexible(time.Time(v))
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t PreciseMillisecs) MarshalText() ([]byte, error) { return t.MarshalJSON() }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *PreciseMillisecs) UnmarshalText(in []byte) error { return t.UnmarshalJSON(in) }
+
+// MarshalText implements encoding.TextMarshaler
+func (n NullPreciseMillisecs) MarshalText() ([]byte, error) {
+	if !n.valid {
+		return []byte{}, nil
+	}
+	return PreciseMillisecs(n.t).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (n *NullPreciseMillisecs) UnmarshalText(in []byte) error {
+	if len(in) == 0 {
+		*n = NullPreciseMillisecs{}
+		return nil
+	}
+	var v PreciseMillisecs
+	if err := v.UnmarshalText(in); err != nil {
+		return err
+	}
+	*n = NewNullPreciseMillisecs(time.Time(v))
+	return nil
+}
@@ -0,0 +1,65 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestTextMapKeys(t *testing.T) {
+	in := map[Millisecs]string{Millisecs(time.Unix(12345, 0)): "a"}
+
+	out, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"12345000":"a"}` {
+		t.Fatalf("got %s", out)
+	}
+
+	var back map[Millisecs]string
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back[Millisecs(time.Unix(12345, 0))] != "a" {
+		t.Errorf("got %v", back)
+	}
+}
+
+func TestTextXMLAttrs(t *testing.T) {
+	type item struct {
+		Created Unix          `xml:"created,attr"`
+		Updated NullSeconds   `xml:"updated,attr"`
+		Seen    Flexible      `xml:"seen,attr"`
+		Deleted NullMillisecs `xml:"deleted,attr"`
+	}
+
+	var v item
+	err := xml.Unmarshal([]byte(`<item created="12345" updated="1.5" seen="2015-12-11T04:29:03Z" deleted=""/>`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(v.Created).Equal(time.Unix(12345, 0)) {
+		t.Errorf("created: %s", time.Time(v.Created))
+	}
+	if !v.Updated.Valid() || !v.Updated.Time().Equal(time.Unix(1, 5e8)) {
+		t.Errorf("updated: %v %s", v.Updated.Valid(), v.Updated.Time())
+	}
+	if !time.Time(v.Seen).Equal(time.Unix(1449808143, 0)) {
+		t.Errorf("seen: %s", time.Time(v.Seen))
+	}
+	if v.Deleted.Valid() {
+		t.Error("deleted should be invalid")
+	}
+
+	v.Seen = Flexible(time.Unix(1449808143, 0).UTC())
+	out, err := xml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<item created="12345" updated="1.5" seen="2015-12-11T04:29:03Z" deleted=""></item>`
+	if string(out) != want {
+		t.Errorf("got %s", out)
+	}
+}
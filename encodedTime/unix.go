@@ -20,7 +20,11 @@ func (t *Unix) UnmarshalJSON(in []byte) (err error) {
 		return err
 	}
 
-	*t = Unix(time.Unix(secs, 0))
+	tt := time.Unix(secs, 0)
+	if err := checkRange(tt); err != nil {
+		return err
+	}
+	*t = Unix(tt)
 	return nil
 }
 
@@ -0,0 +1,42 @@
+package encodedTime
+
+import (
+	"fmt"
+	"time"
+)
+
+// Range is the span of times that are accepted when decoding, see ValidRange
+type Range struct {
+	NotBefore time.Time     // zero for no lower bound
+	MaxAhead  time.Duration // how far after the current time, zero for no upper bound
+}
+
+// ValidRange is checked by all the time types when they are decoded (from JSON, text or msgpack).
+// The zero Range, which is the default, accepts every time.
+//
+//	encodedTime.ValidRange = encodedTime.Range{NotBefore: time.Unix(0, 0), MaxAhead: 10 * 365 * 24 * time.Hour}
+var ValidRange Range
+
+// RangeError is returned for decoded times outside of ValidRange
+type RangeError struct {
+	Time  time.Time
+	Range Range
+}
+
+func (re RangeError) Error() string {
+	if !re.Range.NotBefore.IsZero() && re.Time.Before(re.Range.NotBefore) {
+		return fmt.Sprintf("encodedTime: %s is before %s", re.Time.Format(time.RFC3339), re.Range.NotBefore.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("encodedTime: %s is more than %s in the future", re.Time.Format(time.RFC3339), re.Range.MaxAhead)
+}
+
+func checkRange(t time.Time) error {
+	r := ValidRange
+	if !r.NotBefore.IsZero() && t.Before(r.NotBefore) {
+		return RangeError{Time: t, Range: r}
+	}
+	if r.MaxAhead > 0 && t.After(time.Now().Add(r.MaxAhead)) {
+		return RangeError{Time: t, Range: r}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestValidRange(t *testing.T) {
+	defer func() { ValidRange = Range{} }()
+
+	var v struct {
+		A Millisecs
+		B NullSeconds
+		C Flexible
+	}
+
+	// accepted by default
+	if err := json.Unmarshal([]byte(`{"A":-1000,"C":99999999999999}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	ValidRange = Range{NotBefore: time.Unix(0, 0), MaxAhead: 10 * 365 * 24 * time.Hour}
+
+	for _, in := range []string{`{"A":-1000}`, `{"B":-1.5}`, `{"C":"1969-12-31T23:59:59Z"}`, `{"C":99999999999999}`} {
+		err := json.Unmarshal([]byte(in), &v)
+		var re RangeError
+		if !errors.As(err, &re) {
+			t.Errorf("%s: expected a RangeError, got %v", in, err)
+		}
+	}
+
+	now := time.Now().Unix()
+	if err := json.Unmarshal([]byte(`{"B":`+strconv.FormatInt(now, 10)+`}`), &v); err != nil {
+		t.Error(err)
+	}
+	if v.B.Time().Unix() != now {
+		t.Errorf("got %s", v.B.Time())
+	}
+}
@@ -0,0 +1,330 @@
+package encodedTime
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The YAML encoding of the types uses the same numbers as their JSON encoding (and strings for RFC3339 Flexible times).
+// null leaves the plain types unchanged and makes the Null variants invalid.
+
+// yamlValue turns the text encoding of a type into a number for YAML, if it is one
+func yamlValue(text []byte) interface{} {
+	if n, err := strconv.ParseInt(string(text), 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(string(text), 64); err == nil {
+		return f
+	}
+	return string(text)
+}
+
+// yamlText returns the scalar of the node and false for null
+func yamlText(value *yaml.Node) ([]byte, bool, error) {
+	if value.Kind != yaml.ScalarNode {
+		return nil, false, fmt.Errorf("encodedTime: line %d: expected a number or string", value.Line)
+	}
+	if value.ShortTag() == "!!null" {
+		return nil, false, nil
+	}
+	return []byte(value.Value), true, nil
+}
+
+func (t Millisecs) MarshalYAML() (interface{}, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(text), nil
+}
+
+func (t *Millisecs) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil || !ok {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Unix) MarshalYAML() (interface{}, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(text), nil
+}
+
+func (t *Unix) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil || !ok {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Seconds) MarshalYAML() (interface{}, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(text), nil
+}
+
+func (t *Seconds) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil || !ok {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Micros) MarshalYAML() (interface{}, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(text), nil
+}
+
+func (t *Micros) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil || !ok {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Nanos) MarshalYAML() (interface{}, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(text), nil
+}
+
+func (t *Nanos) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil || !ok {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t Flexible) MarshalYAML() (interface{}, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(text), nil
+}
+
+func (t *Flexible) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil || !ok {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (t PreciseMillisecs) MarshalYAML() (interface{}, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(text), nil
+}
+
+func (t *PreciseMillisecs) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil || !ok {
+		return err
+	}
+	return t.UnmarshalText(text)
+}
+
+func (d DurationMillisecs) MarshalYAML() (interface{}, error) {
+	text, err := d.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(text), nil
+}
+
+func (d *DurationMillisecs) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil || !ok {
+		return err
+	}
+	return d.UnmarshalText(text)
+}
+
+func (n NullMillisecs) MarshalYAML() (interface{}, error) {
+	if !n.valid {
+		return nil, nil
+	}
+	return Millisecs(n.t).MarshalYAML()
+}
+
+func (n *NullMillisecs) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*n = NullMillisecs{}
+		return nil
+	}
+	var v Millisecs
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullMillisecs(time.Time(v))
+	return nil
+}
+
+func (n NullUnix) MarshalYAML() (interface{}, error) {
+	if !n.valid {
+		return nil, nil
+	}
+	return Unix(n.t).MarshalYAML()
+}
+
+func (n *NullUnix) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*n = NullUnix{}
+		return nil
+	}
+	var v Unix
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullUnix(time.Time(v))
+	return nil
+}
+
+func (n NullSeconds) MarshalYAML() (interface{}, error) {
+	if !n.valid {
+		return nil, nil
+	}
+	return Seconds(n.t).MarshalYAML()
+}
+
+func (n *NullSeconds) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*n = NullSeconds{}
+		return nil
+	}
+	var v Seconds
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullSeconds(time.Time(v))
+	return nil
+}
+
+func (n NullMicros) MarshalYAML() (interface{}, error) {
+	if !n.valid {
+		return nil, nil
+	}
+	return Micros(n.t).MarshalYAML()
+}
+
+func (n *NullMicros) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*n = NullMicros{}
+		return nil
+	}
+	var v Micros
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullMicros(time.Time(v))
+	return nil
+}
+
+func (n NullNanos) MarshalYAML() (interface{}, error) {
+	if !n.valid {
+		return nil, nil
+	}
+	return Nanos(n.t).MarshalYAML()
+}
+
+func (n *NullNanos) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*n = NullNanos{}
+		return nil
+	}
+	var v Nanos
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullNanos(time.Time(v))
+	return nil
+}
+
+func (n NullFlexible) MarshalYAML() (interface{}, error) {
+	if !n.valid {
+		return nil, nil
+	}
+	return Flexible(n.t).MarshalYAML()
+}
+
+func (n *NullFlexible) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*n = NullFlexible{}
+		return nil
+	}
+	var v Flexible
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullFlexible(time.Time(v))
+	return nil
+}
+
+func (n NullPreciseMillisecs) MarshalYAML() (interface{}, error) {
+	if !n.valid {
+		return nil, nil
+	}
+	return PreciseMillisecs(n.t).MarshalYAML()
+}
+
+func (n *NullPreciseMillisecs) UnmarshalYAML(value *yaml.Node) error {
+	text, ok, err := yamlText(value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*n = NullPreciseMillisecs{}
+		return nil
+	}
+	var v PreciseMillisecs
+	if err := v.UnmarshalText(text); err != nil {
+		return err
+	}
+	*n = NewNullPreciseMillisecs(time.Time(v))
+	return nil
+}
@@ -0,0 +1,54 @@
+package encodedTime
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAML(t *testing.T) {
+	type config struct {
+		Start   Millisecs         `yaml:"start"`
+		Seen    Flexible          `yaml:"seen"`
+		Until   NullSeconds       `yaml:"until"`
+		Expires NullMillisecs     `yaml:"expires"`
+		Timeout DurationMillisecs `yaml:"timeout"`
+	}
+
+	in := "start: 12345000\nseen: 2015-12-11T04:29:03Z\nuntil: 1.5\nexpires: null\ntimeout: 2500\n"
+	var c config
+	if err := yaml.Unmarshal([]byte(in), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !time.Time(c.Start).Equal(time.Unix(12345, 0)) {
+		t.Errorf("start: %s", time.Time(c.Start))
+	}
+	if !time.Time(c.Seen).Equal(time.Unix(1449808143, 0)) {
+		t.Errorf("seen: %s", time.Time(c.Seen))
+	}
+	if !c.Until.Valid() || !c.Until.Time().Equal(time.Unix(1, 5e8)) {
+		t.Errorf("until: %v %s", c.Until.Valid(), c.Until.Time())
+	}
+	if c.Expires.Valid() {
+		t.Error("expires should be invalid")
+	}
+	if time.Duration(c.Timeout) != 2500*time.Millisecond {
+		t.Errorf("timeout: %s", time.Duration(c.Timeout))
+	}
+
+	c.Seen = Flexible(time.Unix(1449808143, 0).UTC())
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "start: 12345000\nseen: \"2015-12-11T04:29:03Z\"\nuntil: 1.5\nexpires: null\ntimeout: 2500\n"
+	if string(out) != want {
+		t.Errorf("got:\n%s", out)
+	}
+
+	if err := yaml.Unmarshal([]byte("start: [1, 2]\n"), &c); err == nil {
+		t.Error("expected an error for a list")
+	}
+}
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 
 	"go.mindeco.de/http/tester"
@@ -13,6 +15,7 @@ import (
 var (
 	testMux          *http.ServeMux
 	testClient       *tester.Tester
+	testHandler      *Handler
 	testAuthProvider mockProvider
 	testStore        sessions.Store
 	testOptions      []Option
@@ -37,17 +40,40 @@ func setup(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	testHandler = ah
 	testMux.HandleFunc("/login", ah.Authorize)
 	testMux.HandleFunc("/logout", ah.Logout)
 	testMux.Handle("/profile", ah.Authenticate(http.HandlerFunc(restricted)))
+	testMux.Handle("/admin", ah.BasicAuth(http.HandlerFunc(showUser)))
+	testMux.HandleFunc("/logout-token", func(w http.ResponseWriter, r *http.Request) {
+		tok, err := ah.LogoutToken(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, tok)
+	})
 
 }
 
+func urlTo(path string) *url.URL {
+	return &url.URL{Path: path}
+}
+
 func restricted(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	return
 }
 
+func showUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "no user in context", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(w, user)
+}
+
 func teardown() {
 	testMux = nil
 }
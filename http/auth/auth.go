@@ -1,13 +1,21 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/gob"
 	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
+
+	"go.mindeco.de/log/level"
+	"go.mindeco.de/logging/adapter"
 )
 
 // custom sessionKey type to prevent collision
@@ -24,13 +32,19 @@ const (
 
 	userKey sessionKey = iota
 	userTimeout
+	userCreated
+	userLogoutToken
+	userLogin     // the name the user logged in with
+	userConfirmed // the last time the password was entered
+	guestKey      // the ID of an anonymous session, see GuestSessions
+	impersonatedKey
+	userBinding   // the fingerprint of the client, see SetClientBinding
+	userVersion   // the version of the data in userKey, see SetPayloadVersion
+	userSessionID // the ID in the SessionRegistry
 )
 
-// errors to be checked against returned
-var (
-	ErrBadLogin      = errors.New("Bad Login")
-	ErrNotAuthorized = errors.New("Not Authorized")
-)
+// LogoutTokenField is the name of the form field Logout expects the token of LogoutToken in
+const LogoutTokenField = "logout-token"
 
 // Auther allows for custom authentication backends
 type Auther interface {
@@ -39,13 +53,29 @@ type Auther interface {
 	Check(user, pass string) (interface{}, error)
 }
 
+// AutherCtx is like Auther but gets passed the context of the request.
+// Backends that talk to databases or remote identity providers should use it to honor cancellation and deadlines.
+type AutherCtx interface {
+	Check(ctx context.Context, user, pass string) (interface{}, error)
+}
+
+// withoutCtx turns an Auther into an AutherCtx by ignoring the context
+type withoutCtx struct{ Auther }
+
+func (a withoutCtx) Check(_ context.Context, user, pass string) (interface{}, error) {
+	return a.Auther.Check(user, pass)
+}
+
 // Handler exposes helper functions to login/authorize, logout and authenticate http requests.
 type Handler struct {
-	auther Auther
+	auther AutherCtx
 	store  sessions.Store
 
+	currentCodec securecookie.Codec // set by SetCookieKeys for RefreshSessions
+
 	errorHandler         ErrorHandler
 	notAuthorizedHandler http.Handler
+	successHandler       SuccessHandler
 
 	redirLanding string // the url to redirect to after login
 	redirLogout  string // the url to redirect to after logout
@@ -55,10 +85,53 @@ type Handler struct {
 
 	// the name of the cookie
 	sessionName string
+	scope       ScopeFunc // if set, overrides the name (and domain) per request
+
+	revocations RevocationStore
+	userID      UserIDFunc
+
+	basicRealm string
+
+	legacyLogout bool // allow logout with any method and without token
+
+	reauthHandler http.Handler // called by RequireRecentAuth if the password wasn't confirmed recently
+
+	guestMerger GuestMergeFunc
+
+	impersonationAudit ImpersonationAuditFunc
+
+	binding ClientBinding
+
+	tarpit *Tarpit
+
+	proxyAuth *proxyAuth
+
+	verified VerificationStore // if set, only verified accounts are authenticated
+
+	payloadVersion uint
+	migrations     map[uint]PayloadMigration
+
+	registry SessionRegistry
+
+	instrument Instrumenter
+
+	log adapter.Logger
 }
 
 // NewHandler returns a configured Handler value, using the passed Auther and options.
 func NewHandler(a Auther, options ...Option) (*Handler, error) {
+	if a == nil {
+		return nil, errors.New("auth: Auther can't be nil")
+	}
+	return NewHandlerWithContext(withoutCtx{a}, options...)
+}
+
+// NewHandlerWithContext is like NewHandler but for an AutherCtx, which gets passed the request context.
+func NewHandlerWithContext(a AutherCtx, options ...Option) (*Handler, error) {
+	if a == nil {
+		return nil, errors.New("auth: AutherCtx can't be nil")
+	}
+
 	var ah Handler
 	ah.auther = a
 
@@ -89,12 +162,38 @@ func NewHandler(a Auther, options ...Option) (*Handler, error) {
 		ah.sessionName = defaultSessionName
 	}
 
+	if ah.basicRealm == "" {
+		ah.basicRealm = "Restricted"
+	}
+
+	if ah.log == nil {
+		ah.log = adapter.Nop()
+	}
+
+	if ah.userID == nil {
+		ah.userID = func(userData interface{}) string {
+			return fmt.Sprint(userData)
+		}
+	}
+
 	if ah.errorHandler == nil {
 		ah.errorHandler = func(w http.ResponseWriter, r *http.Request, err error, code int) {
 			http.Error(w, err.Error(), code)
 		}
 	}
 
+	if ah.successHandler == nil {
+		ah.successHandler = func(w http.ResponseWriter, r *http.Request, _ interface{}) {
+			http.Redirect(w, r, ah.redirLanding, http.StatusSeeOther)
+		}
+	}
+
+	if ah.reauthHandler == nil {
+		ah.reauthHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ah.errorHandler(w, r, ErrReauthRequired, http.StatusForbidden)
+		})
+	}
+
 	if ah.notAuthorizedHandler == nil {
 		ah.notAuthorizedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ah.errorHandler(w, r, ErrNotAuthorized, http.StatusUnauthorized)
@@ -106,6 +205,7 @@ func NewHandler(a Auther, options ...Option) (*Handler, error) {
 
 // Authorize is a http.HandlerFunc to authorize a login POST request (with form fields user and pass)
 // and passes them to the configured Auther to check them before the return value is saved in the configured session store.
+// Afterwards the SuccessHandler is called, which redirects to the landing location by default.
 func (ah Handler) Authorize(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		code := http.StatusBadRequest
@@ -126,34 +226,109 @@ func (ah Handler) Authorize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := ah.auther.Check(user, pass)
+	if ah.tarpit != nil {
+		ah.tarpit.wait(r, user)
+	}
+
+	id, err := ah.check(r.Context(), "login", user, pass)
 	if err != nil {
-		var code = http.StatusInternalServerError
-		if err == ErrBadLogin {
-			code = http.StatusBadRequest
+		if ah.tarpit != nil && errors.Is(err, ErrBadLogin) {
+			ah.tarpit.fail(r, user)
 		}
-		ah.errorHandler(w, r, err, code)
+		ah.errorHandler(w, r, err, StatusOf(err))
 		return
 	}
 
-	if err := ah.SaveUserSession(r, w, id); err != nil {
+	if ah.tarpit != nil {
+		ah.tarpit.succeed(user)
+	}
+
+	if err := ah.saveSession(r, w, id, user); err != nil {
 		ah.errorHandler(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
-	http.Redirect(w, r, ah.redirLanding, http.StatusSeeOther)
-	return
+	ah.successHandler(w, r, id)
+}
+
+// check passes the credentials to the Auther, inside the Instrumenter if there is one
+func (ah Handler) check(ctx context.Context, kind, user, pass string) (interface{}, error) {
+	var (
+		id  interface{}
+		err error
+	)
+	if ah.instrument == nil {
+		id, err = ah.auther.Check(ctx, user, pass)
+	} else {
+		ctx, done := ah.instrument(ctx, kind)
+		id, err = ah.auther.Check(ctx, user, pass)
+		done(err)
+	}
+	if err != nil {
+		level.Warn(ah.log).Log("event", "check failed", "check", kind, "user", user, "err", err)
+	}
+	return id, err
 }
 
 // SaveUserSession a way to manually Authorize a session and create a cookie for a user.
 func (ah Handler) SaveUserSession(r *http.Request, w http.ResponseWriter, userData interface{}) error {
-	session, err := ah.store.Get(r, ah.sessionName)
+	return ah.saveSession(r, w, userData, "")
+}
+
+// saveSession stores the session data and, if the password was checked, the login name for ConfirmPassword
+func (ah Handler) saveSession(r *http.Request, w http.ResponseWriter, userData interface{}, login string) error {
+	session, err := ah.getSession(r)
 	if err != nil {
 		return err
 	}
 
+	logoutToken, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	if guest, ok := session.Values[guestKey].(string); ok {
+		if ah.guestMerger != nil {
+			if err := ah.guestMerger(r, guest, userData); err != nil {
+				return err
+			}
+		}
+		delete(session.Values, guestKey)
+	}
+
+	delete(session.Values, impersonatedKey)
+
+	if ah.binding.enabled() {
+		session.Values[userBinding] = ah.binding.fingerprint(r)
+	}
+
+	now := time.Now()
+	if ah.registry != nil {
+		if old, ok := session.Values[userSessionID].(string); ok {
+			if err := ah.registry.Remove(old); err != nil {
+				return err
+			}
+		}
+		id, err := ah.registerSession(r, userData, now)
+		if err != nil {
+			return err
+		}
+		session.Values[userSessionID] = id
+	}
+	session.Values[userLogoutToken] = logoutToken
 	session.Values[userKey] = userData
-	session.Values[userTimeout] = time.Now().Add(ah.lifetime)
+	if ah.payloadVersion > 0 {
+		session.Values[userVersion] = ah.payloadVersion
+	}
+	session.Values[userTimeout] = now.Add(ah.lifetime)
+	session.Values[userCreated] = now
+	if login != "" {
+		session.Values[userLogin] = login
+		session.Values[userConfirmed] = now
+	} else {
+		delete(session.Values, userLogin)
+		delete(session.Values, userConfirmed)
+	}
 	if err := session.Save(r, w); err != nil {
 		return err
 	}
@@ -161,60 +336,177 @@ func (ah Handler) SaveUserSession(r *http.Request, w http.ResponseWriter, userDa
 	return nil
 }
 
-// Authenticate calls the next unless AuthenticateRequest returns an error
+// Authenticate calls the next unless AuthenticateRequest returns an error.
+// The session data is passed on in the request context (see FromContext).
+// Sessions with outdated data are saved again after the migrations of SetPayloadVersion ran.
 func (ah Handler) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if _, err := ah.AuthenticateRequest(r); err != nil {
+		if err := ah.upgradeSession(r, w); err != nil {
+			ah.errorHandler(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		user, err := ah.AuthenticateRequest(r)
+		if err != nil {
 			ah.notAuthorizedHandler.ServeHTTP(w, r)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), user)))
 	})
 }
 
 // AuthenticateRequest uses the passed request to load and return the session data that was stored previously.
-// If it is invalid or there is no session, it will return ErrNotAuthorized.
+// If it is invalid or there is no session, it will return ErrNotAuthorized
+// (or ErrSessionExpired, which matches ErrNotAuthorized with errors.Is).
+// If the session is impersonating another user, that users data is returned (see Impersonate).
+// With SetProxyAuth, the identity from the headers of a trusted proxy takes precedence over the session.
 func (ah Handler) AuthenticateRequest(r *http.Request) (interface{}, error) {
-	session, err := ah.store.Get(r, ah.sessionName)
+	if ah.proxyAuth != nil {
+		user, ok, err := ah.proxyAuth.identity(r)
+		if ok {
+			return user, err
+		}
+	}
+
+	user, session, err := ah.authenticateSession(r)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := ah.checkVerified(user); err != nil {
+		return nil, err
+	}
+
+	if target, ok := session.Values[impersonatedKey]; ok {
+		return target, nil
+	}
+
+	return user, nil
+}
+
+// authenticateSession returns the data of the user that logged in, ignoring impersonation
+func (ah Handler) authenticateSession(r *http.Request) (interface{}, *sessions.Session, error) {
+	session, err := ah.getSession(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if session.IsNew {
-		return nil, ErrNotAuthorized
+		return nil, nil, ErrNotAuthorized
 	}
 
 	user, ok := session.Values[userKey]
 	if !ok {
-		return nil, ErrNotAuthorized
+		return nil, nil, ErrNotAuthorized
 	}
 
 	t, ok := session.Values[userTimeout]
 	if !ok {
-		return nil, ErrNotAuthorized
+		return nil, nil, ErrNotAuthorized
 	}
 
 	tout, ok := t.(time.Time)
 	if !ok {
-		return nil, ErrNotAuthorized
+		return nil, nil, ErrNotAuthorized
 	}
 
 	if time.Now().After(tout) {
-		return nil, ErrNotAuthorized
+		return nil, nil, ErrSessionExpired
 	}
 
-	return user, nil
+	if _, err := ah.migratePayload(session); err != nil {
+		return nil, nil, err
+	}
+	user = session.Values[userKey]
+
+	if ah.binding.enabled() {
+		fp, ok := session.Values[userBinding].(string)
+		if !ok || fp != ah.binding.fingerprint(r) {
+			return nil, nil, ErrClientMismatch
+		}
+	}
+
+	if ah.revocations != nil {
+		created, ok := session.Values[userCreated].(time.Time)
+		if !ok {
+			return nil, nil, ErrNotAuthorized
+		}
+
+		revoked, err := ah.isRevoked(user, created)
+		if err != nil {
+			return nil, nil, err
+		}
+		if revoked {
+			return nil, nil, ErrNotAuthorized
+		}
+	}
+
+	if ah.registry != nil {
+		id, ok := session.Values[userSessionID].(string)
+		if !ok {
+			return nil, nil, ErrNotAuthorized
+		}
+
+		known, err := ah.registry.Seen(id, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		if !known {
+			return nil, nil, ErrNotAuthorized
+		}
+	}
+
+	return user, session, nil
+}
+
+// LogoutToken returns the token that needs to be passed to Logout as the LogoutTokenField form value.
+// It is created with the session and should be embedded in the logout form.
+func (ah Handler) LogoutToken(r *http.Request) (string, error) {
+	session, err := ah.getSession(r)
+	if err != nil {
+		return "", err
+	}
+
+	tok, ok := session.Values[userLogoutToken].(string)
+	if !ok {
+		return "", ErrNotAuthorized
+	}
+	return tok, nil
 }
 
 // Logout destroys the session data and updates the cookie with an invalidated one.
+// It only accepts POST requests which carry the token from LogoutToken,
+// so that sessions can't be ended by third party sites (like <img src=/logout>).
+// SetLegacyLogout restores the old behavior of accepting any request.
 func (ah Handler) Logout(w http.ResponseWriter, r *http.Request) {
-	session, err := ah.store.Get(r, ah.sessionName)
+	session, err := ah.getSession(r)
 	if err != nil {
 		ah.errorHandler(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
+	if !ah.legacyLogout {
+		if r.Method != "POST" {
+			ah.errorHandler(w, r, fmt.Errorf("method should be POST"), http.StatusMethodNotAllowed)
+			return
+		}
+
+		want, ok := session.Values[userLogoutToken].(string)
+		got := r.PostFormValue(LogoutTokenField)
+		if !ok || subtle.ConstantTimeCompare([]byte(want), []byte(got)) != 1 {
+			ah.errorHandler(w, r, ErrBadLogoutToken, http.StatusForbidden)
+			return
+		}
+	}
+
+	if id, ok := session.Values[userSessionID].(string); ah.registry != nil && ok {
+		if err := ah.registry.Remove(id); err != nil {
+			ah.errorHandler(w, r, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	session.Values[userTimeout] = time.Now().Add(-ah.lifetime)
 	session.Options.MaxAge = -1
 	if err := session.Save(r, w); err != nil {
@@ -223,5 +515,12 @@ func (ah Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	http.Redirect(w, r, ah.redirLogout, http.StatusSeeOther)
-	return
+}
+
+func randomToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("auth: failed to read random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
 }
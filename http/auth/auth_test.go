@@ -13,7 +13,7 @@ func TestUnauthorized(t *testing.T) {
 	defer teardown()
 	a := assert.New(t)
 
-	resp := testClient.GetBody("/profile")
+	resp := testClient.GetBody(urlTo("/profile"))
 	a.Equal(http.StatusUnauthorized, resp.Code)
 	a.NotEqual(0, resp.Body.Len())
 }
@@ -24,7 +24,7 @@ func TestLogin_emptyVals(t *testing.T) {
 	a := assert.New(t)
 
 	vals := url.Values{}
-	resp := testClient.PostForm("/login", vals)
+	resp := testClient.PostForm(urlTo("/login"), vals)
 	a.Equal(http.StatusBadRequest, resp.Code)
 }
 
@@ -42,7 +42,7 @@ func TestLogin_badLogin(t *testing.T) {
 		called = true
 		return nil, ErrBadLogin
 	}
-	resp := testClient.PostForm("/login", vals)
+	resp := testClient.PostForm(urlTo("/login"), vals)
 	a.Equal(http.StatusBadRequest, resp.Code)
 	a.True(called)
 	a.Contains(resp.Body.String(), ErrBadLogin.Error())
@@ -65,7 +65,7 @@ func TestLogin_workingLogin(t *testing.T) {
 		}
 		return 23, nil
 	}
-	resp := testClient.PostForm("/login", vals)
+	resp := testClient.PostForm(urlTo("/login"), vals)
 	a.Equal(http.StatusSeeOther, resp.Code)
 	a.Equal("/landingRedir", resp.Header().Get("Location"))
 	a.True(called)
@@ -90,14 +90,14 @@ func TestLogin_workingLoginAndRestrictedAcc(t *testing.T) {
 		}
 		return 23, nil
 	}
-	resp := testClient.PostForm("/login", vals)
+	resp := testClient.PostForm(urlTo("/login"), vals)
 	a.Equal(http.StatusSeeOther, resp.Code)
 	a.True(called)
 	newCookie := resp.Header().Get("Set-Cookie")
 	a.Contains(newCookie, defaultSessionName)
 
 	testClient.SetHeaders(http.Header{"Cookie": []string{newCookie}})
-	resp2 := testClient.GetBody("/profile")
+	resp2 := testClient.GetBody(urlTo("/profile"))
 	a.Equal(http.StatusOK, resp2.Code)
 }
 
@@ -118,14 +118,15 @@ func TestLogin_workingLoginAndLogout(t *testing.T) {
 		}
 		return 23, nil
 	}
-	resp := testClient.PostForm("/login", vals)
+	resp := testClient.PostForm(urlTo("/login"), vals)
 	a.Equal(http.StatusSeeOther, resp.Code)
 	a.True(called)
 	newCookie := resp.Header().Get("Set-Cookie")
 	a.Contains(newCookie, defaultSessionName)
 
 	testClient.SetHeaders(http.Header{"Cookie": []string{newCookie}})
-	resp2 := testClient.GetBody("/logout")
+	token := testClient.GetBody(urlTo("/logout-token")).Body.String()
+	resp2 := testClient.PostForm(urlTo("/logout"), url.Values{LogoutTokenField: {token}})
 	logoutCookie := resp2.Header().Get("Set-Cookie")
 	a.Equal("/landingRedir", resp2.Header().Get("Location"))
 	a.NotEqual("", logoutCookie)
@@ -133,7 +134,33 @@ func TestLogin_workingLoginAndLogout(t *testing.T) {
 
 	testClient.ClearHeaders()
 	testClient.SetHeaders(http.Header{"Cookie": []string{logoutCookie}})
-	resp3 := testClient.GetBody("/profile")
+	resp3 := testClient.GetBody(urlTo("/profile"))
 	a.Equal(http.StatusUnauthorized, resp3.Code)
 	a.Equal("Not Authorized\n", resp3.Body.String(), "Body %q", resp3.Body.String())
 }
+
+func TestLogout_protected(t *testing.T) {
+	setup(t)
+	defer teardown()
+	a := assert.New(t)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return 23, nil
+	}
+	resp := testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	newCookie := resp.Header().Get("Set-Cookie")
+	testClient.SetHeaders(http.Header{"Cookie": []string{newCookie}})
+
+	resp = testClient.GetBody(urlTo("/logout"))
+	a.Equal(http.StatusMethodNotAllowed, resp.Code)
+
+	resp = testClient.PostForm(urlTo("/logout"), nil)
+	a.Equal(http.StatusForbidden, resp.Code)
+
+	resp = testClient.PostForm(urlTo("/logout"), url.Values{LogoutTokenField: {"guessed"}})
+	a.Equal(http.StatusForbidden, resp.Code)
+
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusOK, resp.Code, "session should still be valid")
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// BasicAuth authenticates requests with the Authorization: Basic header against the configured Auther.
+// It doesn't create a session, the credentials are checked on every request.
+// This is useful for endpoints that are used by scripts, like metrics or admin APIs.
+// The data returned by the Auther is passed on in the request context (see FromContext).
+func (ah Handler) BasicAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user == "" || pass == "" {
+			ah.basicChallenge(w, r, ErrNotAuthorized)
+			return
+		}
+
+		userData, err := ah.check(r.Context(), "basic", user, pass)
+		if err != nil {
+			if errors.Is(err, ErrBadLogin) {
+				ah.basicChallenge(w, r, err)
+				return
+			}
+			ah.errorHandler(w, r, err, StatusOf(err))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), userData)))
+	})
+}
+
+func (ah Handler) basicChallenge(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", ah.basicRealm))
+	ah.errorHandler(w, r, err, http.StatusUnauthorized)
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasicAuth(t *testing.T) {
+	setup(t)
+	defer teardown()
+	a := assert.New(t)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		if !(u == "testUser" && p == "testPassw") {
+			return nil, ErrBadLogin
+		}
+		return 23, nil
+	}
+
+	resp := testClient.GetBody(urlTo("/admin"))
+	a.Equal(http.StatusUnauthorized, resp.Code)
+	a.Equal(`Basic realm="Restricted", charset="UTF-8"`, resp.Header().Get("WWW-Authenticate"))
+
+	req, err := http.NewRequest("GET", "/admin", nil)
+	a.NoError(err)
+
+	req.SetBasicAuth("testUser", "wrong")
+	testClient.SetHeaders(http.Header{"Authorization": req.Header["Authorization"]})
+	resp = testClient.GetBody(urlTo("/admin"))
+	a.Equal(http.StatusUnauthorized, resp.Code)
+	testClient.ClearHeaders()
+
+	req.SetBasicAuth("testUser", "testPassw")
+	testClient.SetHeaders(http.Header{"Authorization": req.Header["Authorization"]})
+	resp = testClient.GetBody(urlTo("/admin"))
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal("23", resp.Body.String())
+	a.Equal("", resp.Header().Get("Set-Cookie"), "should not create a session")
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"net/http"
+
+	"go.mindeco.de/http/clientip"
+)
+
+// ClientBinding configures which attributes of a client a session is bound to, see SetClientBinding.
+// The prefix lengths control how strict the check is, smaller values allow clients to move around more (like mobile networks).
+type ClientBinding struct {
+	IPv4Prefix int  // bits of the IPv4 address that need to match, 0 disables the check for IPv4 (24 is a good start)
+	IPv6Prefix int  // bits of the IPv6 address that need to match, 0 disables the check for IPv6 (48 is a good start)
+	UserAgent  bool // require the exact same User-Agent header
+}
+
+func (cb ClientBinding) enabled() bool {
+	return cb.IPv4Prefix > 0 || cb.IPv6Prefix > 0 || cb.UserAgent
+}
+
+// fingerprint returns a hash of the configured client attributes of the request
+func (cb ClientBinding) fingerprint(r *http.Request) string {
+	h := sha256.New()
+
+	if ip := net.ParseIP(clientip.FromRequest(r)); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			if cb.IPv4Prefix > 0 {
+				h.Write(v4.Mask(net.CIDRMask(cb.IPv4Prefix, 32)))
+			}
+		} else if cb.IPv6Prefix > 0 {
+			h.Write(ip.Mask(net.CIDRMask(cb.IPv6Prefix, 128)))
+		}
+	}
+	h.Write([]byte{0})
+
+	if cb.UserAgent {
+		h.Write([]byte(r.UserAgent()))
+	}
+
+	return base64.RawStdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientBinding(t *testing.T) {
+	a := assert.New(t)
+
+	auther := mockProvider{checkMock: func(u, p string) (interface{}, error) {
+		return 23, nil
+	}}
+	keys := KeyPair{Hash: securecookie.GenerateRandomKey(32)}
+	ah, err := NewHandler(auther,
+		SetCookieKeys(nil, keys),
+		SetClientBinding(ClientBinding{IPv4Prefix: 24, IPv6Prefix: 48, UserAgent: true}),
+	)
+	a.NoError(err)
+
+	vals := url.Values{"user": {"testUser"}, "pass": {"testPassw"}}
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(vals.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "test/1.0")
+	req.RemoteAddr = "192.0.2.10:1234"
+	rw := httptest.NewRecorder()
+	ah.Authorize(rw, req)
+	a.Equal(http.StatusSeeOther, rw.Code)
+	cookie := rw.Header().Get("Set-Cookie")
+
+	check := func(remote, ua string) error {
+		req := httptest.NewRequest("GET", "/profile", nil)
+		req.Header.Set("Cookie", cookie)
+		req.Header.Set("User-Agent", ua)
+		req.RemoteAddr = remote
+		_, err := ah.AuthenticateRequest(req)
+		return err
+	}
+
+	a.NoError(check("192.0.2.10:1234", "test/1.0"))
+	a.NoError(check("192.0.2.99:4321", "test/1.0"), "same prefix should be fine")
+
+	err = check("198.51.100.10:1234", "test/1.0")
+	a.Equal(ErrClientMismatch, err)
+	a.True(errors.Is(err, ErrNotAuthorized))
+
+	a.Equal(ErrClientMismatch, check("192.0.2.10:1234", "other/2.0"))
+
+	_, err = NewHandler(auther, SetCookieKeys(nil, keys), SetClientBinding(ClientBinding{IPv4Prefix: 33}))
+	a.Error(err)
+}
@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx which carries the session data of an authenticated user
+func NewContext(ctx context.Context, userData interface{}) context.Context {
+	return context.WithValue(ctx, ctxKey{}, userData)
+}
+
+// FromContext returns the session data that was stored by Authenticate or BasicAuth
+func FromContext(ctx context.Context) (interface{}, bool) {
+	v := ctx.Value(ctxKey{})
+	return v, v != nil
+}
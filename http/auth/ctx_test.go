@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxAuther struct {
+	seen context.Context
+}
+
+func (ca *ctxAuther) Check(ctx context.Context, user, pass string) (interface{}, error) {
+	ca.seen = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !(user == "testUser" && pass == "testPassw") {
+		return nil, ErrBadLogin
+	}
+	return 23, nil
+}
+
+func TestAutherCtx(t *testing.T) {
+	a := assert.New(t)
+
+	var ca ctxAuther
+	store := sessions.NewCookieStore(securecookie.GenerateRandomKey(32))
+	ah, err := NewHandlerWithContext(&ca, SetStore(store))
+	a.NoError(err)
+
+	vals := url.Values{"user": {"testUser"}, "pass": {"testPassw"}}
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "marker")
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(vals.Encode())).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rw := httptest.NewRecorder()
+	ah.Authorize(rw, req)
+	a.Equal(http.StatusSeeOther, rw.Code)
+	a.Equal("marker", ca.seen.Value(key{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req = httptest.NewRequest("POST", "/login", strings.NewReader(vals.Encode())).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rw = httptest.NewRecorder()
+	ah.Authorize(rw, req)
+	a.Equal(http.StatusInternalServerError, rw.Code)
+	a.Contains(rw.Body.String(), context.Canceled.Error())
+}
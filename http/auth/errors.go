@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorCode identifies the kind of an Error, for instance in API responses
+type ErrorCode string
+
+// the codes of the errors defined by this package
+const (
+	CodeBadLogin             ErrorCode = "bad-login"
+	CodeNotAuthorized        ErrorCode = "not-authorized"
+	CodeSessionExpired       ErrorCode = "session-expired"
+	CodeLocked               ErrorCode = "locked"
+	CodeSecondFactorRequired ErrorCode = "second-factor-required"
+	CodeReauthRequired       ErrorCode = "reauth-required"
+	CodeBadLogoutToken       ErrorCode = "bad-logout-token"
+	CodeInvalidToken         ErrorCode = "invalid-token"
+	CodeNoSuchUser           ErrorCode = "no-such-user"
+	CodeClientMismatch       ErrorCode = "client-mismatch"
+	CodeUnverified           ErrorCode = "unverified"
+)
+
+// Error is the type of the errors returned by this package.
+// Authers can return them (or wrap them) to control the response of Authorize.
+// Use errors.Is to compare against the Err* values and errors.As to get to the Code and Status.
+type Error struct {
+	Code   ErrorCode
+	Status int // the HTTP status code for responses to this error
+
+	msg    string
+	parent *Error // a more general error this one also matches with errors.Is
+}
+
+func (e *Error) Error() string { return e.msg }
+
+// Is matches errors with the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Unwrap returns the more general error, like ErrNotAuthorized for ErrSessionExpired
+func (e *Error) Unwrap() error {
+	if e.parent == nil {
+		return nil
+	}
+	return e.parent
+}
+
+// errors to be checked against returned
+var (
+	ErrBadLogin      = &Error{Code: CodeBadLogin, Status: http.StatusBadRequest, msg: "Bad Login"}
+	ErrNotAuthorized = &Error{Code: CodeNotAuthorized, Status: http.StatusUnauthorized, msg: "Not Authorized"}
+
+	ErrSessionExpired       = &Error{Code: CodeSessionExpired, Status: http.StatusUnauthorized, msg: "Session Expired", parent: ErrNotAuthorized}
+	ErrLocked               = &Error{Code: CodeLocked, Status: http.StatusForbidden, msg: "Account Locked"}
+	ErrSecondFactorRequired = &Error{Code: CodeSecondFactorRequired, Status: http.StatusUnauthorized, msg: "Second Factor Required"}
+
+	// ErrClientMismatch is returned if the session is presented by a different client than it was created for (see SetClientBinding)
+	ErrClientMismatch = &Error{Code: CodeClientMismatch, Status: http.StatusUnauthorized, msg: "auth: session used by a different client", parent: ErrNotAuthorized}
+
+	ErrReauthRequired = &Error{Code: CodeReauthRequired, Status: http.StatusForbidden, msg: "auth: please confirm your password"}
+	ErrBadLogoutToken = &Error{Code: CodeBadLogoutToken, Status: http.StatusForbidden, msg: "auth: invalid logout token"}
+	ErrInvalidToken   = &Error{Code: CodeInvalidToken, Status: http.StatusBadRequest, msg: "auth: invalid or expired token"}
+	ErrNoSuchUser     = &Error{Code: CodeNoSuchUser, Status: http.StatusBadRequest, msg: "auth: no such user"}
+
+	// ErrUnverified is returned for accounts that didn't verify their email address yet (see SetVerifiedOnly)
+	ErrUnverified = &Error{Code: CodeUnverified, Status: http.StatusForbidden, msg: "auth: email address not verified"}
+
+	ErrNoRevocationStore = errors.New("auth: no revocation store configured")
+)
+
+// StatusOf returns the HTTP status of err if it is (or wraps) an Error and 500 otherwise
+func StatusOf(err error) int {
+	var ae *Error
+	if errors.As(err, &ae) && ae.Status != 0 {
+		return ae.Status
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(errors.Is(ErrSessionExpired, ErrNotAuthorized))
+	a.False(errors.Is(ErrNotAuthorized, ErrSessionExpired))
+	a.False(errors.Is(ErrBadLogin, ErrNotAuthorized))
+
+	wrapped := fmt.Errorf("ldap said no: %w", ErrLocked)
+	a.True(errors.Is(wrapped, ErrLocked))
+
+	var ae *Error
+	a.True(errors.As(wrapped, &ae))
+	a.Equal(CodeLocked, ae.Code)
+
+	a.Equal(http.StatusForbidden, StatusOf(wrapped))
+	a.Equal(http.StatusBadRequest, StatusOf(ErrBadLogin))
+	a.Equal(http.StatusInternalServerError, StatusOf(errors.New("database outage")))
+}
+
+func TestLogin_lockedAccount(t *testing.T) {
+	setup(t)
+	defer teardown()
+	a := assert.New(t)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return nil, fmt.Errorf("too many attempts: %w", ErrLocked)
+	}
+
+	resp := testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusForbidden, resp.Code)
+	a.Contains(resp.Body.String(), ErrLocked.Error())
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+)
+
+// GuestMergeFunc is called by Authorize if the client had a guest session.
+// It can be used to transfer data that the application stored for the guest ID (like a shopping cart) to the user.
+// Values stored with SessionSet don't need this, they are kept in the session anyway.
+// If it returns an error the login fails.
+type GuestMergeFunc func(r *http.Request, guestID string, userData interface{}) error
+
+type guestCtxKey struct{}
+
+// GuestSessions makes sure every client has a session, even if it isn't logged in.
+// Clients without one get a random guest ID, which is available through GuestID.
+// Once the client logs in, the guest ID is passed to the GuestMergeFunc (see SetGuestMerger) and removed from the session.
+func (ah Handler) GuestSessions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ah.AuthenticateRequest(r); err == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session, err := ah.getSession(r)
+		if err != nil {
+			// most likely signed with a key that isn't valid anymore, start over
+			session, err = ah.newSession(r)
+			if session == nil {
+				ah.errorHandler(w, r, err, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		guest, ok := session.Values[guestKey].(string)
+		if !ok {
+			guest, err = randomToken()
+			if err != nil {
+				ah.errorHandler(w, r, err, http.StatusInternalServerError)
+				return
+			}
+			session.Values[guestKey] = guest
+			if err := session.Save(r, w); err != nil {
+				ah.errorHandler(w, r, err, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), guestCtxKey{}, guest)))
+	})
+}
+
+// GuestID returns the ID of the guest session that was created by GuestSessions.
+// It returns false for clients which are logged in or didn't pass through GuestSessions.
+func (ah Handler) GuestID(r *http.Request) (string, bool) {
+	if guest, ok := r.Context().Value(guestCtxKey{}).(string); ok {
+		return guest, true
+	}
+
+	session, err := ah.getSession(r)
+	if err != nil {
+		return "", false
+	}
+	guest, ok := session.Values[guestKey].(string)
+	return guest, ok
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuestSessions(t *testing.T) {
+	merged := make(map[string]interface{})
+	testOptions = []Option{
+		SetGuestMerger(func(r *http.Request, guestID string, userData interface{}) error {
+			merged[guestID] = userData
+			return nil
+		}),
+	}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	testMux.Handle("/shop", testHandler.GuestSessions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		guest, _ := testHandler.GuestID(r)
+		if r.Method == "POST" {
+			if err := testHandler.SessionSet(r, w, "cart", "apples"); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		fmt.Fprint(w, guest)
+	})))
+	testMux.HandleFunc("/cart", func(w http.ResponseWriter, r *http.Request) {
+		v, _, _ := testHandler.SessionGet(r, "cart")
+		fmt.Fprint(w, v)
+	})
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return 23, nil
+	}
+
+	resp := testClient.GetBody(urlTo("/shop"))
+	a.Equal(http.StatusOK, resp.Code)
+	guest := resp.Body.String()
+	a.NotEqual("", guest)
+	cookie := resp.Header().Get("Set-Cookie")
+	a.NotEqual("", cookie)
+
+	testClient.SetHeaders(http.Header{"Cookie": []string{cookie}})
+	resp = testClient.PostForm(urlTo("/shop"), nil)
+	a.Equal(guest, resp.Body.String(), "guest ID should be stable")
+	testClient.ClearHeaders()
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal(23, merged[guest])
+	testClient.ClearHeaders()
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.GetBody(urlTo("/cart"))
+	a.Equal("apples", resp.Body.String())
+
+	resp = testClient.GetBody(urlTo("/shop"))
+	a.Equal("", resp.Body.String(), "logged in users are not guests")
+}
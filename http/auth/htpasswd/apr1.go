@@ -0,0 +1,95 @@
+package htpasswd
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"strings"
+
+	"go.mindeco.de/http/auth/password"
+)
+
+const apr1Magic = "$apr1$"
+
+// verifyAPR1 checks pass against an Apache MD5-crypt hash ($apr1$salt$hash)
+func verifyAPR1(encoded, pass string) error {
+	rest := strings.TrimPrefix(encoded, apr1Magic)
+	i := strings.Index(rest, "$")
+	if i < 0 {
+		return password.ErrUnknownFormat
+	}
+
+	want := apr1([]byte(pass), []byte(rest[:i]))
+	if subtle.ConstantTimeCompare([]byte(want), []byte(encoded)) != 1 {
+		return password.ErrMismatch
+	}
+	return nil
+}
+
+// apr1 is the MD5-crypt algorithm by Poul-Henning Kamp with the magic string of Apache
+func apr1(pass, salt []byte) string {
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	alt := md5.New()
+	alt.Write(pass)
+	alt.Write(salt)
+	alt.Write(pass)
+	altSum := alt.Sum(nil)
+
+	ctx := md5.New()
+	ctx.Write(pass)
+	ctx.Write([]byte(apr1Magic))
+	ctx.Write(salt)
+	for i := len(pass); i > 0; i -= 16 {
+		n := i
+		if n > 16 {
+			n = 16
+		}
+		ctx.Write(altSum[:n])
+	}
+	for i := len(pass); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			ctx.Write([]byte{0})
+		} else {
+			ctx.Write(pass[:1])
+		}
+	}
+	final := ctx.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		c := md5.New()
+		if i&1 != 0 {
+			c.Write(pass)
+		} else {
+			c.Write(final)
+		}
+		if i%3 != 0 {
+			c.Write(salt)
+		}
+		if i%7 != 0 {
+			c.Write(pass)
+		}
+		if i&1 != 0 {
+			c.Write(final)
+		} else {
+			c.Write(pass)
+		}
+		final = c.Sum(nil)
+	}
+
+	const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	var out strings.Builder
+	to64 := func(v uint32, n int) {
+		for ; n > 0; n-- {
+			out.WriteByte(itoa64[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, g := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		to64(uint32(final[g[0]])<<16|uint32(final[g[1]])<<8|uint32(final[g[2]]), 4)
+	}
+	to64(uint32(final[11]), 2)
+
+	return apr1Magic + string(salt) + "$" + out.String()
+}
@@ -0,0 +1,176 @@
+// Package htpasswd implements an auth.Auther which reads users from an Apache htpasswd file.
+//
+// Supported are bcrypt ($2y$, htpasswd -B) and MD5-crypt ($apr1$, htpasswd -m) entries.
+// The user name is used as session data.
+package htpasswd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mindeco.de/http/auth"
+	"go.mindeco.de/http/auth/password"
+)
+
+// File holds the parsed entries of an htpasswd file
+type File struct {
+	path string
+
+	reloadEvery time.Duration
+
+	mu        sync.RWMutex
+	users     map[string]string
+	dummy     string // the hash of one of the users, verified against for unknown ones
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+var _ auth.Auther = (*File)(nil)
+
+// Option is a function that changes a File during Open
+type Option func(*File) error
+
+// AutoReload makes Check look at the modification time of the file at most every interval
+// and reload it if it changed. Failed reloads keep the previous entries.
+func AutoReload(interval time.Duration) Option {
+	return func(f *File) error {
+		if interval <= 0 {
+			return fmt.Errorf("htpasswd: reload interval needs to be positive")
+		}
+		f.reloadEvery = interval
+		return nil
+	}
+}
+
+// Open reads and parses the file at path
+func Open(path string, opts ...Option) (*File, error) {
+	f := &File{path: path}
+	for _, o := range opts {
+		if err := o(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, f.Reload()
+}
+
+// Reload reads the file again
+func (f *File) Reload() error {
+	fh, err := os.Open(f.path)
+	if err != nil {
+		return fmt.Errorf("htpasswd: failed to open file: %w", err)
+	}
+	defer fh.Close()
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return fmt.Errorf("htpasswd: failed to stat file: %w", err)
+	}
+
+	users, err := Parse(fh)
+	if err != nil {
+		return err
+	}
+
+	// a real entry has the algorithm and cost of the file, so unknown users take as long as known ones
+	var first string
+	for name := range users {
+		if first == "" || name < first {
+			first = name
+		}
+	}
+	dummy := users[first]
+
+	f.mu.Lock()
+	f.users = users
+	f.dummy = dummy
+	f.modTime = fi.ModTime()
+	f.lastCheck = time.Now()
+	f.mu.Unlock()
+	return nil
+}
+
+// Parse reads user:hash lines. Empty lines and lines starting with # are ignored.
+func Parse(r io.Reader) (map[string]string, error) {
+	users := make(map[string]string)
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+		txt := strings.TrimSpace(s.Text())
+		if txt == "" || strings.HasPrefix(txt, "#") {
+			continue
+		}
+		i := strings.Index(txt, ":")
+		if i < 1 {
+			return nil, fmt.Errorf("htpasswd: invalid entry on line %d", line)
+		}
+		users[txt[:i]] = txt[i+1:]
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("htpasswd: failed to read file: %w", err)
+	}
+	return users, nil
+}
+
+func (f *File) maybeReload() {
+	if f.reloadEvery == 0 {
+		return
+	}
+
+	f.mu.RLock()
+	due := time.Since(f.lastCheck) > f.reloadEvery
+	modTime := f.modTime
+	f.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	fi, err := os.Stat(f.path)
+	if err == nil && !fi.ModTime().Equal(modTime) {
+		if f.Reload() == nil {
+			return
+		}
+	}
+
+	f.mu.Lock()
+	f.lastCheck = time.Now()
+	f.mu.Unlock()
+}
+
+// Check implements auth.Auther
+func (f *File) Check(user, pass string) (interface{}, error) {
+	f.maybeReload()
+
+	f.mu.RLock()
+	h, has := f.users[user]
+	dummy := f.dummy
+	f.mu.RUnlock()
+	if !has {
+		// so that the response time doesn't tell which users exist
+		if dummy != "" {
+			verify(dummy, pass)
+		}
+		return nil, auth.ErrBadLogin
+	}
+
+	if err := verify(h, pass); err != nil {
+		if err == password.ErrMismatch {
+			return nil, auth.ErrBadLogin
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func verify(h, pass string) error {
+	if strings.HasPrefix(h, apr1Magic) {
+		return verifyAPR1(h, pass)
+	}
+	return password.Verify(h, pass)
+}
@@ -0,0 +1,68 @@
+package htpasswd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mindeco.de/http/auth"
+	"go.mindeco.de/http/auth/password"
+)
+
+func TestAPR1(t *testing.T) {
+	// openssl passwd -apr1 -salt r31maBcd testPassw
+	const h = "$apr1$r31maBcd$F0KONj8BhYRF3A5x2yLGA0"
+	a := assert.New(t)
+	a.NoError(verifyAPR1(h, "testPassw"))
+	a.Equal(password.ErrMismatch, verifyAPR1(h, "wrong"))
+}
+
+func TestFile(t *testing.T) {
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "htpasswd")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	bcryptHash, err := password.HashBcrypt("bobPassw", 4)
+	a.NoError(err)
+
+	fname := filepath.Join(dir, ".htpasswd")
+	content := "# comment\nalice:$apr1$r31maBcd$F0KONj8BhYRF3A5x2yLGA0\n\nbob:" + bcryptHash + "\n"
+	a.NoError(ioutil.WriteFile(fname, []byte(content), 0600))
+
+	f, err := Open(fname, AutoReload(time.Nanosecond))
+	a.NoError(err)
+
+	v, err := f.Check("alice", "testPassw")
+	a.NoError(err)
+	a.Equal("alice", v)
+
+	v, err = f.Check("bob", "bobPassw")
+	a.NoError(err)
+	a.Equal("bob", v)
+
+	_, err = f.Check("bob", "testPassw")
+	a.Equal(auth.ErrBadLogin, err)
+
+	// unknown users are verified against a real entry, even if the password matches it
+	a.Equal("$apr1$r31maBcd$F0KONj8BhYRF3A5x2yLGA0", f.dummy)
+	_, err = f.Check("carol", "testPassw")
+	a.Equal(auth.ErrBadLogin, err)
+
+	// remove alice
+	a.NoError(ioutil.WriteFile(fname, []byte("bob:"+bcryptHash+"\n"), 0600))
+	future := time.Now().Add(time.Minute)
+	a.NoError(os.Chtimes(fname, future, future))
+
+	_, err = f.Check("alice", "testPassw")
+	a.Equal(auth.ErrBadLogin, err)
+	a.Equal(bcryptHash, f.dummy)
+
+	_, err = Parse(strings.NewReader("no-colon-here\n"))
+	a.Error(err)
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNotImpersonating is returned by StopImpersonating if the session isn't impersonating anyone
+var ErrNotImpersonating = errors.New("auth: session is not impersonating")
+
+// ImpersonationAuditFunc is called when real starts (started=true) or stops impersonating target
+type ImpersonationAuditFunc func(r *http.Request, real, target interface{}, started bool)
+
+// Impersonate layers the target user over the one that is logged in with the session of the request.
+// Afterwards AuthenticateRequest (and thus Authenticate and FromContext) return target,
+// while RealUser still returns the data of the user that logged in.
+// Checking that the current user is allowed to do this is up to the application.
+// Impersonating while already impersonating replaces the target.
+func (ah Handler) Impersonate(r *http.Request, w http.ResponseWriter, target interface{}) error {
+	real, session, err := ah.authenticateSession(r)
+	if err != nil {
+		return err
+	}
+
+	session.Values[impersonatedKey] = target
+	if err := session.Save(r, w); err != nil {
+		return err
+	}
+
+	if ah.impersonationAudit != nil {
+		ah.impersonationAudit(r, real, target, true)
+	}
+	return nil
+}
+
+// StopImpersonating removes the impersonated user from the session, which makes the real user active again.
+func (ah Handler) StopImpersonating(r *http.Request, w http.ResponseWriter) error {
+	real, session, err := ah.authenticateSession(r)
+	if err != nil {
+		return err
+	}
+
+	target, ok := session.Values[impersonatedKey]
+	if !ok {
+		return ErrNotImpersonating
+	}
+
+	delete(session.Values, impersonatedKey)
+	if err := session.Save(r, w); err != nil {
+		return err
+	}
+
+	if ah.impersonationAudit != nil {
+		ah.impersonationAudit(r, real, target, false)
+	}
+	return nil
+}
+
+// RealUser returns the data of the user that logged in with the session of the request.
+// The second return value is true if the session currently impersonates someone else.
+func (ah Handler) RealUser(r *http.Request) (interface{}, bool, error) {
+	real, session, err := ah.authenticateSession(r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, impersonating := session.Values[impersonatedKey]
+	return real, impersonating, nil
+}
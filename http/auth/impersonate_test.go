@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonate(t *testing.T) {
+	type auditEvent struct {
+		real, target interface{}
+		started      bool
+	}
+	var audit []auditEvent
+	testOptions = []Option{
+		SetImpersonationAudit(func(r *http.Request, real, target interface{}, started bool) {
+			audit = append(audit, auditEvent{real, target, started})
+		}),
+	}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	testMux.HandleFunc("/impersonate", func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if r.URL.Query().Get("stop") != "" {
+			err = testHandler.StopImpersonating(r, w)
+		} else {
+			err = testHandler.Impersonate(r, w, 42)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	})
+	testMux.Handle("/whoami", testHandler.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := FromContext(r.Context())
+		real, impersonating, err := testHandler.RealUser(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, user, real, impersonating)
+	})))
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return 23, nil
+	}
+
+	resp := testClient.GetBody(urlTo("/impersonate"))
+	a.Equal(http.StatusBadRequest, resp.Code, "needs a login")
+
+	resp = testClient.PostForm(urlTo("/login"), url.Values{"user": {"admin"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.GetBody(urlTo("/whoami"))
+	a.Equal("23 23 false", resp.Body.String())
+
+	resp = testClient.GetBody(urlTo("/impersonate"))
+	a.Equal(http.StatusOK, resp.Code)
+	testClient.ClearHeaders()
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.GetBody(urlTo("/whoami"))
+	a.Equal("42 23 true", resp.Body.String())
+
+	resp = testClient.GetBody(&url.URL{Path: "/impersonate", RawQuery: "stop=1"})
+	a.Equal(http.StatusOK, resp.Code)
+	testClient.ClearHeaders()
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.GetBody(urlTo("/whoami"))
+	a.Equal("23 23 false", resp.Body.String())
+
+	resp = testClient.GetBody(&url.URL{Path: "/impersonate", RawQuery: "stop=1"})
+	a.Equal(http.StatusBadRequest, resp.Code)
+
+	a.Equal([]auditEvent{{23, 42, true}, {23, 42, false}}, audit)
+}
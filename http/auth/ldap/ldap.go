@@ -0,0 +1,190 @@
+// Package ldap implements an auth.AutherCtx which checks credentials against an LDAP server or Active Directory.
+//
+// It uses the search-then-bind approach: a service account searches for the entry of the user
+// and the password is checked by binding as that entry.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"go.mindeco.de/http/auth"
+)
+
+func init() {
+	gob.Register(User{})
+}
+
+// Config holds the connection and search parameters
+type Config struct {
+	// URL of the server, like ldap://ldap.example.com:389 or ldaps://ldap.example.com:636
+	URL string
+
+	// StartTLS upgrades ldap:// connections before binding
+	StartTLS bool
+
+	// TLSConfig is used for ldaps:// and StartTLS, can be nil
+	TLSConfig *tls.Config
+
+	// BindDN and BindPassword are the credentials of the service account that does the search.
+	// If BindDN is empty an anonymous search is done.
+	BindDN       string
+	BindPassword string
+
+	// BaseDN is where the search for users starts, like ou=people,dc=example,dc=com
+	BaseDN string
+
+	// UserFilter is the search filter with a %s for the (escaped) user name.
+	// Defaults to (&(objectClass=person)(uid=%s)), Active Directory needs something like (&(objectClass=user)(sAMAccountName=%s)).
+	UserFilter string
+
+	// Attributes are copied from the entry of the user into the session data
+	Attributes []string
+
+	// Timeout for dialing and each request, defaults to 10 seconds
+	Timeout time.Duration
+}
+
+// User is the session data returned by Check
+type User struct {
+	Name       string
+	DN         string
+	Attributes map[string][]string
+}
+
+// conn is the subset of (*ldap.Conn) that is used here
+type conn interface {
+	StartTLS(*tls.Config) error
+	Bind(username, password string) error
+	Search(*goldap.SearchRequest) (*goldap.SearchResult, error)
+	Close()
+}
+
+// Auther checks credentials against the configured server.
+// Every check uses its own connection.
+type Auther struct {
+	cfg Config
+
+	dial func(Config) (conn, error)
+}
+
+var _ auth.AutherCtx = (*Auther)(nil)
+
+// New checks the config and returns an Auther for it
+func New(cfg Config) (*Auther, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("ldap: URL can't be empty")
+	}
+	if cfg.BaseDN == "" {
+		return nil, errors.New("ldap: BaseDN can't be empty")
+	}
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(&(objectClass=person)(uid=%s))"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &Auther{cfg: cfg, dial: dial}, nil
+}
+
+func dial(cfg Config) (conn, error) {
+	c, err := goldap.DialURL(cfg.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: cfg.Timeout}),
+		goldap.DialWithTLSConfig(cfg.TLSConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.SetTimeout(cfg.Timeout)
+	return c, nil
+}
+
+// Check implements auth.AutherCtx.
+// It returns auth.ErrBadLogin if the user doesn't exist, isn't unique or the password is wrong.
+func (la *Auther) Check(ctx context.Context, user, pass string) (interface{}, error) {
+	if user == "" || pass == "" {
+		// an empty password would be an unauthenticated bind, which most servers accept
+		return nil, auth.ErrBadLogin
+	}
+
+	c, err := la.dial(la.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: failed to connect: %w", err)
+	}
+	defer c.Close()
+
+	// abort pending requests if the request is canceled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+
+	if la.cfg.StartTLS {
+		if err := c.StartTLS(la.cfg.TLSConfig); err != nil {
+			return nil, fmt.Errorf("ldap: StartTLS failed: %w", err)
+		}
+	}
+
+	if la.cfg.BindDN != "" {
+		if err := c.Bind(la.cfg.BindDN, la.cfg.BindPassword); err != nil {
+			return nil, la.ctxErr(ctx, fmt.Errorf("ldap: service bind failed: %w", err))
+		}
+	}
+
+	req := goldap.NewSearchRequest(la.cfg.BaseDN,
+		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
+		2, int(la.cfg.Timeout/time.Second), false,
+		fmt.Sprintf(la.cfg.UserFilter, goldap.EscapeFilter(user)),
+		append([]string{"dn"}, la.cfg.Attributes...),
+		nil,
+	)
+	res, err := c.Search(req)
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+			return nil, auth.ErrBadLogin
+		}
+		return nil, la.ctxErr(ctx, fmt.Errorf("ldap: search failed: %w", err))
+	}
+	if len(res.Entries) != 1 {
+		return nil, auth.ErrBadLogin
+	}
+	entry := res.Entries[0]
+
+	if err := c.Bind(entry.DN, pass); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, auth.ErrBadLogin
+		}
+		return nil, la.ctxErr(ctx, fmt.Errorf("ldap: user bind failed: %w", err))
+	}
+
+	u := User{
+		Name:       user,
+		DN:         entry.DN,
+		Attributes: make(map[string][]string, len(la.cfg.Attributes)),
+	}
+	for _, a := range la.cfg.Attributes {
+		if vals := entry.GetAttributeValues(a); len(vals) > 0 {
+			u.Attributes[a] = vals
+		}
+	}
+	return u, nil
+}
+
+// ctxErr prefers the error of the context, which is the reason for the failure if the connection was closed by it
+func (la *Auther) ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
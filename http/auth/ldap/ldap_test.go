@@ -0,0 +1,92 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"go.mindeco.de/http/auth"
+)
+
+type mockConn struct {
+	startTLS bool
+	binds    []string
+	filter   string
+
+	entries   []*goldap.Entry
+	passwords map[string]string
+}
+
+func (mc *mockConn) StartTLS(*tls.Config) error {
+	mc.startTLS = true
+	return nil
+}
+
+func (mc *mockConn) Bind(dn, pass string) error {
+	mc.binds = append(mc.binds, dn)
+	if mc.passwords[dn] != pass {
+		return goldap.NewError(goldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	return nil
+}
+
+func (mc *mockConn) Search(req *goldap.SearchRequest) (*goldap.SearchResult, error) {
+	mc.filter = req.Filter
+	return &goldap.SearchResult{Entries: mc.entries}, nil
+}
+
+func (mc *mockConn) Close() {}
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+
+	la, err := New(Config{
+		URL:          "ldap://ldap.example.com",
+		StartTLS:     true,
+		BindDN:       "cn=service,dc=example,dc=com",
+		BindPassword: "service-pw",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		Attributes:   []string{"mail"},
+	})
+	a.NoError(err)
+
+	mc := &mockConn{
+		entries: []*goldap.Entry{
+			goldap.NewEntry("uid=alice,ou=people,dc=example,dc=com", map[string][]string{
+				"mail": {"alice@example.com"},
+			}),
+		},
+		passwords: map[string]string{
+			"cn=service,dc=example,dc=com":          "service-pw",
+			"uid=alice,ou=people,dc=example,dc=com": "alice-pw",
+		},
+	}
+	la.dial = func(Config) (conn, error) { return mc, nil }
+
+	v, err := la.Check(context.Background(), "alice", "alice-pw")
+	a.NoError(err)
+	a.True(mc.startTLS)
+	a.Equal("(&(objectClass=person)(uid=alice))", mc.filter)
+	a.Equal([]string{"cn=service,dc=example,dc=com", "uid=alice,ou=people,dc=example,dc=com"}, mc.binds)
+	a.Equal(User{
+		Name:       "alice",
+		DN:         "uid=alice,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{"mail": {"alice@example.com"}},
+	}, v)
+
+	_, err = la.Check(context.Background(), "alice", "wrong")
+	a.Equal(auth.ErrBadLogin, err)
+
+	_, err = la.Check(context.Background(), "alice", "")
+	a.Equal(auth.ErrBadLogin, err, "no unauthenticated binds")
+
+	_, err = la.Check(context.Background(), "*)(uid=*", "alice-pw")
+	a.Equal(`(&(objectClass=person)(uid=\2a\29\28uid=\2a))`, mc.filter)
+
+	mc.entries = nil
+	_, err = la.Check(context.Background(), "bob", "bob-pw")
+	a.Equal(auth.ErrBadLogin, err)
+}
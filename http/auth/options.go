@@ -1,11 +1,14 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/sessions"
+
+	"go.mindeco.de/logging/adapter"
 )
 
 // Option is a function that changes a handler in a certain way during initialization
@@ -33,6 +36,18 @@ func SetSessionName(name string) Option {
 	}
 }
 
+// SetSessionScope derives the name and domain of the session cookie from each request, see PerHostScope.
+// It takes precedence over SetSessionName.
+func SetSessionScope(fn ScopeFunc) Option {
+	return func(h *Handler) error {
+		if fn == nil {
+			return errors.New("ScopeFunc can't be nil")
+		}
+		h.scope = fn
+		return nil
+	}
+}
+
 // SetLanding sets the url to where a client is redirect to after login
 func SetLanding(l string) Option {
 	return func(h *Handler) error {
@@ -83,3 +98,188 @@ func SetErrorHandler(errh ErrorHandler) Option {
 		return nil
 	}
 }
+
+// SetRevocationStore enables RevokeSessions and the check against it in AuthenticateRequest.
+// Session data is mapped to user IDs with the function of SetUserID.
+func SetRevocationStore(rs RevocationStore) Option {
+	return func(h *Handler) error {
+		if rs == nil {
+			return errors.New("RevocationStore can't be nil")
+		}
+		h.revocations = rs
+		return nil
+	}
+}
+
+// SetUserID sets how session data is mapped to the user IDs of the RevocationStore, VerificationStore and SessionRegistry.
+// By default fmt.Sprint is used.
+func SetUserID(fn UserIDFunc) Option {
+	return func(h *Handler) error {
+		if fn == nil {
+			return errors.New("UserIDFunc can't be nil")
+		}
+		h.userID = fn
+		return nil
+	}
+}
+
+// SetBasicAuthRealm sets the realm that is send with the WWW-Authenticate header by BasicAuth
+func SetBasicAuthRealm(realm string) Option {
+	return func(h *Handler) error {
+		if realm == "" {
+			return errors.New("basic auth realm can't be empty")
+		}
+		h.basicRealm = realm
+		return nil
+	}
+}
+
+// SuccessHandler is called by Authorize after the session was saved.
+// The userData is what the Auther returned.
+type SuccessHandler func(w http.ResponseWriter, r *http.Request, userData interface{})
+
+// SetSuccessHandler replaces the redirect to the landing location after a successful login.
+// This can be used to respond with JSON or redirect based on the role of the user.
+func SetSuccessHandler(sh SuccessHandler) Option {
+	return func(h *Handler) error {
+		if sh == nil {
+			return errors.New("SuccessHandler can't be nil")
+		}
+		h.successHandler = sh
+		return nil
+	}
+}
+
+// SetLegacyLogout makes Logout accept requests with any method and without the LogoutToken.
+// This makes it possible for other sites to end sessions and should only be used for compatibility.
+func SetLegacyLogout() Option {
+	return func(h *Handler) error {
+		h.legacyLogout = true
+		return nil
+	}
+}
+
+// SetReauthHandler sets the handler RequireRecentAuth uses if the password needs to be confirmed.
+// Most likely it should render or redirect to a form which posts to ConfirmPassword.
+func SetReauthHandler(rh http.Handler) Option {
+	return func(h *Handler) error {
+		if rh == nil {
+			return errors.New("reauth handler can't be nil")
+		}
+		h.reauthHandler = rh
+		return nil
+	}
+}
+
+// SetGuestMerger sets the function that is called when a guest session is upgraded by a login.
+// See GuestSessions.
+func SetGuestMerger(fn GuestMergeFunc) Option {
+	return func(h *Handler) error {
+		if fn == nil {
+			return errors.New("GuestMergeFunc can't be nil")
+		}
+		h.guestMerger = fn
+		return nil
+	}
+}
+
+// SetImpersonationAudit sets a function that is called whenever an impersonation starts or stops
+func SetImpersonationAudit(fn ImpersonationAuditFunc) Option {
+	return func(h *Handler) error {
+		if fn == nil {
+			return errors.New("ImpersonationAuditFunc can't be nil")
+		}
+		h.impersonationAudit = fn
+		return nil
+	}
+}
+
+// SetClientBinding records a fingerprint of the client (IP prefix and/or User-Agent) at login
+// and rejects the session if it is presented by a client that doesn't match it.
+// This helps to contain stolen cookies. Sessions that were created before this was enabled are rejected as well.
+// Behind a reverse proxy, resolve the client address with middleware.ClientIP first.
+func SetClientBinding(cb ClientBinding) Option {
+	return func(h *Handler) error {
+		if cb.IPv4Prefix < 0 || cb.IPv4Prefix > 32 || cb.IPv6Prefix < 0 || cb.IPv6Prefix > 128 {
+			return errors.New("invalid client binding prefix length")
+		}
+		h.binding = cb
+		return nil
+	}
+}
+
+// SetTarpit delays Authorize requests after failed ones, see Tarpit
+func SetTarpit(tp *Tarpit) Option {
+	return func(h *Handler) error {
+		if tp == nil {
+			return errors.New("Tarpit can't be nil")
+		}
+		h.tarpit = tp
+		return nil
+	}
+}
+
+// SetVerifiedOnly makes AuthenticateRequest (and thus Authenticate) refuse accounts which aren't marked as verified in vs with ErrUnverified.
+// Session data is mapped to user IDs with the function of SetUserID.
+func SetVerifiedOnly(vs VerificationStore) Option {
+	return func(h *Handler) error {
+		if vs == nil {
+			return errors.New("VerificationStore can't be nil")
+		}
+		h.verified = vs
+		return nil
+	}
+}
+
+// SetSessionRegistry records every session in reg, which enables ListSessions and RevokeSession.
+// Sessions which are removed from the registry are not authorized anymore.
+// Session data is mapped to user IDs with the function of SetUserID.
+func SetSessionRegistry(reg SessionRegistry) Option {
+	return func(h *Handler) error {
+		if reg == nil {
+			return errors.New("SessionRegistry can't be nil")
+		}
+		h.registry = reg
+		return nil
+	}
+}
+
+// Instrumenter is called around the checks of the Auther, with the kind of check ("login", "basic" or "confirm").
+// It returns the context for the Auther and a function that is called with the result, for tracing or metrics.
+type Instrumenter func(ctx context.Context, check string) (context.Context, func(err error))
+
+// SetInstrumenter sets the Instrumenter, see middleware.TraceAuth for one that creates spans and metrics.Metrics.Auth for one that counts the checks.
+// If it is used more than once, all of them are called, each with the context returned by the one before.
+func SetInstrumenter(fn Instrumenter) Option {
+	return func(h *Handler) error {
+		if fn == nil {
+			return errors.New("Instrumenter can't be nil")
+		}
+		prev := h.instrument
+		if prev == nil {
+			h.instrument = fn
+			return nil
+		}
+		h.instrument = func(ctx context.Context, check string) (context.Context, func(error)) {
+			ctx, donePrev := prev(ctx, check)
+			ctx, done := fn(ctx, check)
+			return ctx, func(err error) {
+				done(err)
+				donePrev(err)
+			}
+		}
+		return nil
+	}
+}
+
+// SetLogger sets where failed checks of credentials are logged, nothing is logged by default.
+// A slog.Logger can be passed with adapter.FromSlog.
+func SetLogger(l adapter.Logger) Option {
+	return func(h *Handler) error {
+		if l == nil {
+			return errors.New("auth: nil logger passed")
+		}
+		h.log = l
+		return nil
+	}
+}
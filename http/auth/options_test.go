@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gorilla/securecookie"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,7 +32,7 @@ func TestOption_landing(t *testing.T) {
 		}
 		return 23, nil
 	}
-	resp := testClient.PostForm("/login", vals)
+	resp := testClient.PostForm(urlTo("/login"), vals)
 	a.Equal(http.StatusSeeOther, resp.Code)
 	a.Equal(want, resp.Header().Get("Location"))
 	a.True(called)
@@ -60,18 +61,62 @@ func TestOption_logout(t *testing.T) {
 		}
 		return 23, nil
 	}
-	resp := testClient.PostForm("/login", vals)
+	resp := testClient.PostForm(urlTo("/login"), vals)
 	a.Equal(http.StatusSeeOther, resp.Code)
 	a.Equal("/landingRedir", resp.Header().Get("Location"))
 	a.True(called)
 	newCookie := resp.Header().Get("Set-Cookie")
 	a.Contains(newCookie, defaultSessionName)
 
-	resp = testClient.PostForm("/logout", nil)
+	testClient.SetHeaders(http.Header{"Cookie": []string{newCookie}})
+	defer testClient.ClearHeaders()
+	token := testClient.GetBody(urlTo("/logout-token")).Body.String()
+
+	resp = testClient.PostForm(urlTo("/logout"), url.Values{LogoutTokenField: {token}})
 	a.Equal(http.StatusSeeOther, resp.Code)
 	a.Equal(want, resp.Header().Get("Location"))
 }
 
+func TestOption_legacyLogout(t *testing.T) {
+	testOptions = []Option{
+		SetLegacyLogout(),
+	}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	resp := testClient.GetBody(urlTo("/logout"))
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal("/landingRedir", resp.Header().Get("Location"))
+}
+
+func TestOption_userID(t *testing.T) {
+	reg := NewMemSessionRegistry()
+	testOptions = []Option{
+		SetSessionRegistry(reg),
+		SetUserID(func(userData interface{}) string { return fmt.Sprint("user:", userData) }),
+		SetRevocationStore(NewMemRevocationStore()),
+	}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return 23, nil
+	}
+	resp := testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+
+	list, err := testHandler.ListSessions("user:23")
+	a.NoError(err)
+	a.Len(list, 1, "the order of the options doesn't matter")
+
+	_, err = NewHandler(mockProvider{}, SetCookieKeys(nil, KeyPair{Hash: securecookie.GenerateRandomKey(32)}), SetUserID(nil))
+	a.Error(err)
+}
+
 func TestOption_errhandler(t *testing.T) {
 
 	var errh = func(rw http.ResponseWriter, req *http.Request, err error, code int) {
@@ -91,9 +136,32 @@ func TestOption_errhandler(t *testing.T) {
 	}
 
 	vals := url.Values{"user": {"testUser"}, "pass": {"testPassw"}}
-	resp := testClient.PostForm("/login", vals)
+	resp := testClient.PostForm(urlTo("/login"), vals)
 
 	a.Equal(http.StatusInternalServerError, resp.Code)
 	body := resp.Body.String()
 	a.True(strings.HasPrefix(body, "custom error: 500"))
 }
+
+func TestOption_successHandler(t *testing.T) {
+	testOptions = []Option{
+		SetSuccessHandler(func(w http.ResponseWriter, r *http.Request, userData interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"user":%d}`, userData)
+		}),
+	}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return 23, nil
+	}
+
+	vals := url.Values{"user": {"testUser"}, "pass": {"testPassw"}}
+	resp := testClient.PostForm(urlTo("/login"), vals)
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal(`{"user":23}`, resp.Body.String())
+	a.Contains(resp.Header().Get("Set-Cookie"), defaultSessionName)
+}
@@ -0,0 +1,100 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mindeco.de/http/auth"
+)
+
+// ErrNoSuchUser should be returned by a Store if it doesn't know the requested user.
+// It is the same value as auth.ErrNoSuchUser, so either can be returned and checked.
+var ErrNoSuchUser = auth.ErrNoSuchUser
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// verifyDummy is called for unknown users so that the response time doesn't leak which users exist.
+// The hash is only computed on first use, not when the package is loaded.
+func verifyDummy(pass string) {
+	dummyOnce.Do(func() {
+		h, err := Hash("not a real password")
+		if err != nil {
+			panic(fmt.Errorf("password: failed to create the dummy hash: %w", err))
+		}
+		dummyHash = h
+	})
+	Verify(dummyHash, pass)
+}
+
+// MapAuther is a static user name to password hash map which implements auth.Auther.
+// The user name is used as the session data.
+type MapAuther map[string]string
+
+// Check implements auth.Auther
+func (ma MapAuther) Check(user, pass string) (interface{}, error) {
+	h, has := ma[user]
+	if !has {
+		verifyDummy(pass)
+		return nil, auth.ErrBadLogin
+	}
+
+	if err := Verify(h, pass); err != nil {
+		if err == ErrMismatch {
+			return nil, auth.ErrBadLogin
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Store is used by StoreAuther to look up the password hash of a user.
+// It also returns the data that should be saved in the session of that user.
+type Store interface {
+	LookupHash(user string) (hash string, userData interface{}, err error)
+}
+
+// Rehasher can optionally be implemented by a Store to upgrade password hashes (see NeedsRehash)
+type Rehasher interface {
+	UpdateHash(user, hash string) error
+}
+
+// StoreAuther implements auth.Auther using a Store for the user lookup
+type StoreAuther struct {
+	Store Store
+}
+
+// Check implements auth.Auther
+func (sa StoreAuther) Check(user, pass string) (interface{}, error) {
+	h, userData, err := sa.Store.LookupHash(user)
+	if err != nil {
+		if errors.Is(err, ErrNoSuchUser) {
+			verifyDummy(pass)
+			return nil, auth.ErrBadLogin
+		}
+		return nil, fmt.Errorf("password: store lookup failed: %w", err)
+	}
+
+	if err := Verify(h, pass); err != nil {
+		if err == ErrMismatch {
+			return nil, auth.ErrBadLogin
+		}
+		return nil, err
+	}
+
+	if rh, ok := sa.Store.(Rehasher); ok && NeedsRehash(h) {
+		newHash, err := Hash(pass)
+		if err != nil {
+			return nil, err
+		}
+		if err := rh.UpdateHash(user, newHash); err != nil {
+			return nil, fmt.Errorf("password: failed to update hash: %w", err)
+		}
+	}
+
+	return userData, nil
+}
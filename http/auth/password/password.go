@@ -0,0 +1,186 @@
+// Package password implements hashing and verification of passwords for the auth package.
+//
+// New hashes are created with argon2id and encoded in the PHC string format.
+// bcrypt hashes are accepted by Verify so that existing user databases keep working,
+// NeedsRehash can be used to migrate those (or argon2id hashes with outdated parameters) after a successful login.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// errors to be checked against returned
+var (
+	ErrMismatch      = errors.New("password: hash and password mismatch")
+	ErrUnknownFormat = errors.New("password: unknown hash format")
+)
+
+// Params are the tuning parameters for argon2id
+type Params struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follow the recommendations of RFC 9106 for memory constrained environments
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+const argon2idPrefix = "$argon2id$"
+
+// limits for the parameters of stored hashes, so that a broken or planted hash can't crash Verify, make it use all memory or hang it
+const (
+	minSaltLength  = 16
+	minKeyLength   = 16
+	maxMemory      = 1024 * 1024 // KiB, 1 GiB
+	maxIterations  = 32
+	maxParallelism = 16
+)
+
+func paramsInRange(p Params) bool {
+	return p.Iterations >= 1 && p.Iterations <= maxIterations &&
+		p.Parallelism >= 1 && p.Parallelism <= maxParallelism &&
+		p.Memory <= maxMemory
+}
+
+// Hash returns an argon2id hash of password, using DefaultParams
+func Hash(password string) (string, error) {
+	return HashWithParams(password, DefaultParams)
+}
+
+// HashWithParams returns an argon2id hash of password, using the passed parameters
+func HashWithParams(password string, p Params) (string, error) {
+	if p.SaltLength < minSaltLength || p.KeyLength < minKeyLength || !paramsInRange(p) {
+		return "", errors.New("password: parameters out of the range Verify accepts")
+	}
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("password: failed to read salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	b64 := base64.RawStdEncoding
+	encoded := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		b64.EncodeToString(salt),
+		b64.EncodeToString(key),
+	)
+	return encoded, nil
+}
+
+// HashBcrypt returns a bcrypt hash of password.
+// It is only here for systems that can't handle argon2id, Hash should be preferred.
+func HashBcrypt(password string, cost int) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", fmt.Errorf("password: bcrypt failed: %w", err)
+	}
+	return string(h), nil
+}
+
+// Verify checks that password matches the encoded hash (argon2id or bcrypt).
+// It returns ErrMismatch if it doesn't and compares in constant time.
+func Verify(encoded, password string) error {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return ErrMismatch
+		}
+		return err
+	}
+
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash returns true if the encoded hash wasn't created by Hash with the current DefaultParams.
+// Callers should hash the password again after a successful Verify and store the new value.
+func NeedsRehash(encoded string) bool {
+	p, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p != DefaultParams
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// decodeArgon2id parses $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	var p Params
+	if !strings.HasPrefix(encoded, argon2idPrefix) {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, fmt.Errorf("password: invalid version field: %w", err)
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("password: unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("password: invalid parameter field: %w", err)
+	}
+	if !paramsInRange(p) {
+		return p, nil, nil, fmt.Errorf("%w: parameters out of range (m=%d,t=%d,p=%d)", ErrUnknownFormat, p.Memory, p.Iterations, p.Parallelism)
+	}
+
+	b64 := base64.RawStdEncoding
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("password: invalid salt: %w", err)
+	}
+	if len(salt) < minSaltLength {
+		return p, nil, nil, fmt.Errorf("%w: salt too short", ErrUnknownFormat)
+	}
+	p.SaltLength = uint32(len(salt))
+
+	key, err := b64.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("password: invalid key: %w", err)
+	}
+	if len(key) < minKeyLength {
+		return p, nil, nil, fmt.Errorf("%w: key too short", ErrUnknownFormat)
+	}
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,142 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mindeco.de/http/auth"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	a := assert.New(t)
+
+	h, err := Hash("secret")
+	a.NoError(err)
+	a.Contains(h, "$argon2id$v=19$m=65536,t=3,p=4$")
+
+	a.NoError(Verify(h, "secret"))
+	a.Equal(ErrMismatch, Verify(h, "wrong"))
+	a.False(NeedsRehash(h))
+
+	other, err := Hash("secret")
+	a.NoError(err)
+	a.NotEqual(h, other, "salt should differ")
+}
+
+func TestBcryptNeedsRehash(t *testing.T) {
+	a := assert.New(t)
+
+	h, err := HashBcrypt("secret", 4)
+	a.NoError(err)
+
+	a.NoError(Verify(h, "secret"))
+	a.Equal(ErrMismatch, Verify(h, "wrong"))
+	a.True(NeedsRehash(h))
+
+	weak, err := HashWithParams("secret", Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 16})
+	a.NoError(err)
+	a.NoError(Verify(weak, "secret"))
+	a.True(NeedsRehash(weak))
+
+	a.Equal(ErrUnknownFormat, Verify("plaintext", "plaintext"))
+}
+
+func TestVerifyRejectsBadParams(t *testing.T) {
+	const salt = "c2FsdHNhbHRzYWx0c2FsdA"          // 16 bytes
+	const key = "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5" // 24 bytes
+	for _, h := range []string{
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$",
+		"$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$" + key,
+		"$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=4294967295,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=255$" + salt + "$" + key,
+	} {
+		err := Verify(h, "secret")
+		assert.ErrorIs(t, err, ErrUnknownFormat, h)
+		assert.True(t, NeedsRehash(h), h)
+	}
+
+	_, err := HashWithParams("secret", Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 4, KeyLength: 32})
+	assert.Error(t, err)
+	_, err = HashWithParams("secret", Params{Memory: 64, Iterations: maxIterations + 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	assert.Error(t, err)
+	_, err = HashWithParams("secret", Params{Memory: 64, Iterations: 1, Parallelism: maxParallelism + 1, SaltLength: 16, KeyLength: 32})
+	assert.Error(t, err)
+}
+
+func TestMapAuther(t *testing.T) {
+	a := assert.New(t)
+
+	h, err := Hash("testPassw")
+	a.NoError(err)
+	ma := MapAuther{"testUser": h}
+
+	data, err := ma.Check("testUser", "testPassw")
+	a.NoError(err)
+	a.Equal("testUser", data)
+
+	_, err = ma.Check("testUser", "nope")
+	a.Equal(auth.ErrBadLogin, err)
+
+	_, err = ma.Check("unknown", "testPassw")
+	a.Equal(auth.ErrBadLogin, err)
+}
+
+type mockStore struct {
+	hashes  map[string]string
+	updated map[string]string
+}
+
+func (ms mockStore) LookupHash(user string) (string, interface{}, error) {
+	h, has := ms.hashes[user]
+	if !has {
+		return "", nil, ErrNoSuchUser
+	}
+	return h, len(user), nil
+}
+
+func (ms mockStore) UpdateHash(user, hash string) error {
+	ms.updated[user] = hash
+	return nil
+}
+
+func TestStoreAutherRehash(t *testing.T) {
+	a := assert.New(t)
+
+	old, err := HashBcrypt("testPassw", 4)
+	a.NoError(err)
+
+	ms := mockStore{
+		hashes:  map[string]string{"testUser": old},
+		updated: make(map[string]string),
+	}
+	sa := StoreAuther{Store: ms}
+
+	data, err := sa.Check("testUser", "testPassw")
+	a.NoError(err)
+	a.Equal(8, data)
+
+	newHash, has := ms.updated["testUser"]
+	a.True(has, "should have been rehashed")
+	a.NoError(Verify(newHash, "testPassw"))
+
+	_, err = sa.Check("unknown", "testPassw")
+	a.Equal(auth.ErrBadLogin, err)
+
+	// one sentinel for both packages, also when a store wraps it
+	a.True(errors.Is(ErrNoSuchUser, auth.ErrNoSuchUser))
+	wrapping := StoreAuther{Store: wrappingStore{}}
+	_, err = wrapping.Check("unknown", "testPassw")
+	a.Equal(auth.ErrBadLogin, err)
+}
+
+type wrappingStore struct{}
+
+func (wrappingStore) LookupHash(user string) (string, interface{}, error) {
+	return "", nil, fmt.Errorf("lookup %q: %w", user, auth.ErrNoSuchUser)
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+// PayloadMigration converts the session data of the Auther from one version to the next
+type PayloadMigration func(old interface{}) (interface{}, error)
+
+// SetPayloadVersion tags the session data with version and runs the migrations when an older session is loaded.
+// migrations[v] converts data of version v to v+1, sessions saved before this option was used have version 0.
+// Sessions for which a step is missing are not authorized.
+//
+// gob needs the old types to decode them, keep them around (with gob.RegisterName and the old name if the type was renamed)
+// until the last session of that version expired.
+func SetPayloadVersion(version uint, migrations map[uint]PayloadMigration) Option {
+	return func(h *Handler) error {
+		if version == 0 {
+			return errors.New("payload version needs to be positive")
+		}
+		for v, fn := range migrations {
+			if v >= version {
+				return fmt.Errorf("migration from version %d is not older than the payload version %d", v, version)
+			}
+			if fn == nil {
+				return fmt.Errorf("migration from version %d is nil", v)
+			}
+		}
+		h.payloadVersion = version
+		h.migrations = migrations
+		return nil
+	}
+}
+
+// migratePayload brings the session data to the current version and reports if it changed anything
+func (ah Handler) migratePayload(session *sessions.Session) (bool, error) {
+	if ah.payloadVersion == 0 {
+		return false, nil
+	}
+
+	user, ok := session.Values[userKey]
+	if !ok {
+		return false, nil
+	}
+
+	v, _ := session.Values[userVersion].(uint)
+	if v == ah.payloadVersion {
+		return false, nil
+	}
+	if v > ah.payloadVersion {
+		// saved by a newer deploy, nothing we can do with it
+		return false, ErrNotAuthorized
+	}
+
+	for ; v < ah.payloadVersion; v++ {
+		migrate, has := ah.migrations[v]
+		if !has {
+			return false, ErrNotAuthorized
+		}
+
+		var err error
+		user, err = migrate(user)
+		if err != nil {
+			return false, fmt.Errorf("auth: session payload migration from version %d failed: %w", v, err)
+		}
+	}
+
+	session.Values[userKey] = user
+	session.Values[userVersion] = ah.payloadVersion
+	return true, nil
+}
+
+// upgradeSession saves the session of the request if its data had to be migrated
+func (ah Handler) upgradeSession(r *http.Request, w http.ResponseWriter) error {
+	session, err := ah.getSession(r)
+	if err != nil || session.IsNew {
+		// dealt with by authenticateSession
+		return nil
+	}
+
+	migrated, err := ah.migratePayload(session)
+	if err != nil || !migrated {
+		return nil
+	}
+
+	return session.Save(r, w)
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadMigration(t *testing.T) {
+	setup(t)
+	defer teardown()
+	a := assert.New(t)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return u, nil
+	}
+
+	// logged in before versioning was introduced
+	resp := testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	oldCookie := resp.Header().Get("Set-Cookie")
+
+	// the next deploy prefixes user names
+	v1, err := NewHandler(&testAuthProvider, SetStore(testStore),
+		SetPayloadVersion(1, map[uint]PayloadMigration{
+			0: func(old interface{}) (interface{}, error) {
+				return fmt.Sprintf("user:%s", old), nil
+			},
+		}))
+	a.NoError(err)
+	testMux.Handle("/v1/whoami", v1.Authenticate(http.HandlerFunc(showUser)))
+
+	testClient.SetHeaders(http.Header{"Cookie": []string{oldCookie}})
+	resp = testClient.GetBody(urlTo("/v1/whoami"))
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal("user:testUser", resp.Body.String())
+	upgraded := resp.Header().Get("Set-Cookie")
+	a.NotEqual("", upgraded, "migrated session should be saved")
+	testClient.ClearHeaders()
+
+	testClient.SetHeaders(http.Header{"Cookie": []string{upgraded}})
+	resp = testClient.GetBody(urlTo("/v1/whoami"))
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal("user:testUser", resp.Body.String(), "should not migrate twice")
+	a.Equal("", resp.Header().Get("Set-Cookie"))
+	testClient.ClearHeaders()
+
+	// a later version without a path from version 0
+	v3, err := NewHandler(&testAuthProvider, SetStore(testStore),
+		SetPayloadVersion(3, map[uint]PayloadMigration{
+			2: func(old interface{}) (interface{}, error) { return old, nil },
+		}))
+	a.NoError(err)
+	testMux.Handle("/v3/whoami", v3.Authenticate(http.HandlerFunc(showUser)))
+
+	testClient.SetHeaders(http.Header{"Cookie": []string{oldCookie}})
+	resp = testClient.GetBody(urlTo("/v3/whoami"))
+	a.Equal(http.StatusUnauthorized, resp.Code)
+	testClient.ClearHeaders()
+
+	_, err = NewHandler(&testAuthProvider, SetStore(testStore),
+		SetPayloadVersion(1, map[uint]PayloadMigration{1: func(old interface{}) (interface{}, error) { return old, nil }}))
+	a.Error(err)
+}
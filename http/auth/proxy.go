@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"go.mindeco.de/http/clientip"
+)
+
+// ProxyAuth configures authentication by a header set from a reverse proxy, like oauth2-proxy or Authelia.
+// The header is only trusted if the request comes directly from one of the Proxies.
+type ProxyAuth struct {
+	// Proxies decides which peers are trusted reverse proxies, use the same resolver as for middleware.ClientIP
+	Proxies *clientip.Resolver
+
+	// Header carries the identity, like X-Auth-Request-Email for oauth2-proxy or Remote-User for Authelia.
+	// It has to be one the proxy always sets (or strips), otherwise clients can pass their own through it.
+	Header string
+
+	// Map can turn the identity from the header into session data, like looking up a user ID.
+	// By default the identity itself is used. Returning ErrNotAuthorized rejects the request.
+	Map func(r *http.Request, identity string) (interface{}, error)
+}
+
+type proxyAuth struct {
+	proxies *clientip.Resolver
+	header  string
+	mapFn   func(r *http.Request, identity string) (interface{}, error)
+}
+
+// SetProxyAuth makes AuthenticateRequest accept identities from trusted reverse proxies, see ProxyAuth.
+// Requests without the header fall back to the session.
+func SetProxyAuth(pa ProxyAuth) Option {
+	return func(h *Handler) error {
+		if pa.Proxies == nil {
+			return errors.New("proxy auth needs trusted proxies")
+		}
+		if pa.Header == "" {
+			return errors.New("proxy auth needs the identity header")
+		}
+
+		p := proxyAuth{
+			proxies: pa.Proxies,
+			header:  pa.Header,
+			mapFn:   pa.Map,
+		}
+		if p.mapFn == nil {
+			p.mapFn = func(_ *http.Request, identity string) (interface{}, error) {
+				return identity, nil
+			}
+		}
+
+		h.proxyAuth = &p
+		return nil
+	}
+}
+
+// identity returns the session data for the proxy header of the request and false if there is none
+func (p proxyAuth) identity(r *http.Request) (interface{}, bool, error) {
+	if !p.proxies.Trusted(r) {
+		return nil, false, nil
+	}
+
+	id := r.Header.Get(p.header)
+	if id == "" {
+		return nil, false, nil
+	}
+	v, err := p.mapFn(r, id)
+	return v, true, err
+}
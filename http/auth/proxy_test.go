@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mindeco.de/http/clientip"
+)
+
+func TestProxyAuth(t *testing.T) {
+	a := assert.New(t)
+
+	proxies, err := clientip.NewResolver([]string{"10.0.0.0/8", "::1/128"})
+	require.NoError(t, err)
+
+	ah, err := NewHandler(mockProvider{},
+		SetCookieKeys(nil, KeyPair{Hash: securecookie.GenerateRandomKey(32)}),
+		SetProxyAuth(ProxyAuth{
+			Proxies: proxies,
+			Header:  "X-Auth-Request-Email",
+			Map: func(_ *http.Request, id string) (interface{}, error) {
+				if id == "mallory@example.com" {
+					return nil, ErrNotAuthorized
+				}
+				return "user:" + id, nil
+			},
+		}),
+	)
+	a.NoError(err)
+
+	check := func(remote, email string) (interface{}, error) {
+		req := httptest.NewRequest("GET", "/profile", nil)
+		req.RemoteAddr = remote
+		if email != "" {
+			req.Header.Set("X-Auth-Request-Email", email)
+		}
+		return ah.AuthenticateRequest(req)
+	}
+
+	user, err := check("10.1.2.3:4567", "alice@example.com")
+	a.NoError(err)
+	a.Equal("user:alice@example.com", user)
+
+	user, err = check("[::1]:4567", "alice@example.com")
+	a.NoError(err)
+	a.Equal("user:alice@example.com", user)
+
+	_, err = check("192.0.2.1:4567", "alice@example.com")
+	a.Equal(ErrNotAuthorized, err, "untrusted peers can't set the header")
+
+	_, err = check("10.1.2.3:4567", "")
+	a.Equal(ErrNotAuthorized, err, "falls back to the session")
+
+	_, err = check("10.1.2.3:4567", "mallory@example.com")
+	a.Equal(ErrNotAuthorized, err)
+
+	// the proxy only manages its own header, others come from the client
+	req := httptest.NewRequest("GET", "/profile", nil)
+	req.RemoteAddr = "10.1.2.3:4567"
+	req.Header.Set("X-Auth-Request-Email", "alice@example.com")
+	req.Header.Set("X-Remote-User", "admin")
+	user, err = ah.AuthenticateRequest(req)
+	a.NoError(err)
+	a.Equal("user:alice@example.com", user)
+
+	req.Header.Del("X-Auth-Request-Email")
+	_, err = ah.AuthenticateRequest(req)
+	a.Equal(ErrNotAuthorized, err, "other headers are ignored")
+
+	_, err = NewHandler(mockProvider{}, SetCookieKeys(nil, KeyPair{Hash: securecookie.GenerateRandomKey(32)}),
+		SetProxyAuth(ProxyAuth{Header: "X-Auth-Request-Email"}))
+	a.Error(err)
+
+	_, err = NewHandler(mockProvider{}, SetCookieKeys(nil, KeyPair{Hash: securecookie.GenerateRandomKey(32)}),
+		SetProxyAuth(ProxyAuth{Proxies: proxies}))
+	a.Error(err)
+}
@@ -0,0 +1,173 @@
+// Package render renders the login page of the auth package with the Renderer of go.mindeco.de/http/render.
+//
+// It provides the handlers that need to be passed to auth.NewHandler:
+// failed logins are shown on the login form again (see ErrorHandler),
+// successful ones are sent back to where the user wanted to go (see SuccessHandler)
+// and unauthorized requests are redirected to the login form (see NotAuthorizedHandler).
+package render
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.mindeco.de/http/auth"
+	tplrender "go.mindeco.de/http/render"
+)
+
+// Data is passed to the login template
+type Data struct {
+	// Error is a message that can be shown to the user, empty if there was no error
+	Error string
+	// ErrorCode is set for errors of the auth package (like auth.CodeBadLogin)
+	ErrorCode auth.ErrorCode
+
+	// User is the name that was entered, so the form can be filled with it again
+	User string
+
+	// ReturnTo should be passed as the auth.ReturnToField form field
+	ReturnTo string
+
+	// Action is the path the form should be posted to
+	Action string
+}
+
+// LoginPage renders the login template
+type LoginPage struct {
+	r *tplrender.Renderer
+
+	template  string
+	loginPath string
+	landing   string
+}
+
+// Option is a function that changes a LoginPage during initialization
+type Option func(*LoginPage) error
+
+// Template sets the name of the login template (default login.tmpl).
+// It needs to be added to the Renderer with AddTemplates.
+func Template(name string) Option {
+	return func(lp *LoginPage) error {
+		if name == "" {
+			return errors.New("auth/render: template name can't be empty")
+		}
+		lp.template = name
+		return nil
+	}
+}
+
+// LoginPath sets the path where the form is served and posted to (default /login)
+func LoginPath(p string) Option {
+	return func(lp *LoginPage) error {
+		if !strings.HasPrefix(p, "/") {
+			return errors.New("auth/render: login path needs to be absolute")
+		}
+		lp.loginPath = p
+		return nil
+	}
+}
+
+// Landing sets where to redirect after a login without a return-to location (default /)
+func Landing(l string) Option {
+	return func(lp *LoginPage) error {
+		if l == "" {
+			return errors.New("auth/render: landing can't be empty")
+		}
+		lp.landing = l
+		return nil
+	}
+}
+
+// New returns a LoginPage which uses the passed Renderer
+func New(r *tplrender.Renderer, opts ...Option) (*LoginPage, error) {
+	if r == nil {
+		return nil, errors.New("auth/render: nil Renderer passed")
+	}
+
+	lp := &LoginPage{r: r}
+	for _, o := range opts {
+		if err := o(lp); err != nil {
+			return nil, err
+		}
+	}
+
+	if lp.template == "" {
+		lp.template = "login.tmpl"
+	}
+	if lp.loginPath == "" {
+		lp.loginPath = "/login"
+	}
+	if lp.landing == "" {
+		lp.landing = "/"
+	}
+	return lp, nil
+}
+
+// Form is a http.HandlerFunc which renders the empty login form.
+// It should be mounted for GET requests on the login path, the POST requests go to (auth.Handler).Authorize.
+func (lp LoginPage) Form(w http.ResponseWriter, req *http.Request) {
+	data := Data{
+		ReturnTo: localPath(req.URL.Query().Get(auth.ReturnToField)),
+		Action:   lp.loginPath,
+	}
+	lp.render(w, req, http.StatusOK, data)
+}
+
+// ErrorHandler can be passed to auth.SetErrorHandler.
+// Errors of login requests re-render the form with the error, all others use the error page of the Renderer.
+func (lp LoginPage) ErrorHandler(w http.ResponseWriter, req *http.Request, err error, code int) {
+	if req.Method != "POST" || req.URL.Path != lp.loginPath {
+		lp.r.Error(w, req, code, err)
+		return
+	}
+
+	data := Data{
+		User:     req.PostFormValue("user"),
+		ReturnTo: localPath(req.PostFormValue(auth.ReturnToField)),
+		Action:   lp.loginPath,
+		Error:    "Something went wrong, please try again later.",
+	}
+
+	var ae *auth.Error
+	if errors.As(err, &ae) {
+		data.Error = ae.Error()
+		data.ErrorCode = ae.Code
+	}
+
+	lp.render(w, req, code, data)
+}
+
+// SuccessHandler can be passed to auth.SetSuccessHandler.
+// It redirects to the local path in the return-to form field or the landing location.
+func (lp LoginPage) SuccessHandler(w http.ResponseWriter, req *http.Request, _ interface{}) {
+	to := localPath(req.PostFormValue(auth.ReturnToField))
+	if to == "" {
+		to = lp.landing
+	}
+	http.Redirect(w, req, to, http.StatusSeeOther)
+}
+
+// NotAuthorizedHandler can be passed to auth.SetNotAuthorizedHandler.
+// It redirects to the login form and remembers the requested page as return-to location.
+func (lp LoginPage) NotAuthorizedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		vals := url.Values{auth.ReturnToField: {req.URL.RequestURI()}}
+		http.Redirect(w, req, lp.loginPath+"?"+vals.Encode(), http.StatusSeeOther)
+	})
+}
+
+func (lp LoginPage) render(w http.ResponseWriter, req *http.Request, status int, data Data) {
+	w.Header().Set("Cache-Control", "no-store")
+	if err := lp.r.Render(w, req, lp.template, status, data); err != nil {
+		lp.r.Error(w, req, http.StatusInternalServerError, err)
+	}
+}
+
+// localPath only lets absolute paths on this host through (see auth.IsLocalPath), everything else becomes empty
+func localPath(to string) string {
+	if !auth.IsLocalPath(to) {
+		return ""
+	}
+	return to
+}
@@ -0,0 +1,120 @@
+package render
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+
+	"go.mindeco.de/http/auth"
+	tplrender "go.mindeco.de/http/render"
+	kitlog "go.mindeco.de/log"
+)
+
+func newTestPage(t *testing.T) *LoginPage {
+	r, err := tplrender.New(http.Dir("tests"),
+		tplrender.AddTemplates("login.tmpl", "/error.tmpl"),
+		tplrender.SetLogger(kitlog.NewNopLogger()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lp, err := New(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lp
+}
+
+func TestForm(t *testing.T) {
+	a := assert.New(t)
+	lp := newTestPage(t)
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/login?return-to=%2Fprofile", nil)
+	lp.Form(rw, req)
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("no-store", rw.Header().Get("Cache-Control"))
+
+	doc, err := goquery.NewDocumentFromReader(rw.Body)
+	a.NoError(err)
+	a.Equal("Login", doc.Find("title").Text())
+	a.Equal(0, doc.Find("#error").Length())
+	returnTo, _ := doc.Find("input[name=return-to]").Attr("value")
+	a.Equal("/profile", returnTo)
+
+	rw = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/login?return-to=https%3A%2F%2Fevil.example", nil)
+	lp.Form(rw, req)
+	doc, err = goquery.NewDocumentFromReader(rw.Body)
+	a.NoError(err)
+	returnTo, _ = doc.Find("input[name=return-to]").Attr("value")
+	a.Equal("", returnTo, "should drop non-local return-to")
+}
+
+func postLogin(vals url.Values) *http.Request {
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(vals.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestErrorHandler(t *testing.T) {
+	a := assert.New(t)
+	lp := newTestPage(t)
+
+	rw := httptest.NewRecorder()
+	req := postLogin(url.Values{"user": {"testUser"}, "pass": {"wrong"}, "return-to": {"/profile"}})
+	lp.ErrorHandler(rw, req, auth.ErrBadLogin, auth.StatusOf(auth.ErrBadLogin))
+	a.Equal(http.StatusBadRequest, rw.Code)
+
+	doc, err := goquery.NewDocumentFromReader(rw.Body)
+	a.NoError(err)
+	a.Equal("Bad Login", doc.Find("#error").Text())
+	code, _ := doc.Find("#error").Attr("data-code")
+	a.Equal(string(auth.CodeBadLogin), code)
+	user, _ := doc.Find("input[name=user]").Attr("value")
+	a.Equal("testUser", user)
+	returnTo, _ := doc.Find("input[name=return-to]").Attr("value")
+	a.Equal("/profile", returnTo)
+
+	// internal errors are not shown
+	rw = httptest.NewRecorder()
+	req = postLogin(url.Values{"user": {"testUser"}, "pass": {"test"}})
+	lp.ErrorHandler(rw, req, errors.New("database on fire"), http.StatusInternalServerError)
+	a.Equal(http.StatusInternalServerError, rw.Code)
+	a.NotContains(rw.Body.String(), "database on fire")
+
+	// other requests use the error page
+	rw = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/profile", nil)
+	lp.ErrorHandler(rw, req, auth.ErrNotAuthorized, http.StatusUnauthorized)
+	a.Equal(http.StatusUnauthorized, rw.Code)
+	doc, err = goquery.NewDocumentFromReader(rw.Body)
+	a.NoError(err)
+	a.Equal("Not Authorized", doc.Find("#errBody").Text())
+}
+
+func TestRedirects(t *testing.T) {
+	a := assert.New(t)
+	lp := newTestPage(t)
+
+	rw := httptest.NewRecorder()
+	lp.SuccessHandler(rw, postLogin(url.Values{"return-to": {"/profile?tab=2"}}), "testUser")
+	a.Equal(http.StatusSeeOther, rw.Code)
+	a.Equal("/profile?tab=2", rw.Header().Get("Location"))
+
+	rw = httptest.NewRecorder()
+	lp.SuccessHandler(rw, postLogin(url.Values{"return-to": {"//evil.example"}}), "testUser")
+	a.Equal("/", rw.Header().Get("Location"))
+
+	rw = httptest.NewRecorder()
+	lp.NotAuthorizedHandler().ServeHTTP(rw, httptest.NewRequest("GET", "/profile?tab=2", nil))
+	a.Equal(http.StatusSeeOther, rw.Code)
+	a.Equal("/login?return-to=%2Fprofile%3Ftab%3D2", rw.Header().Get("Location"))
+}
@@ -0,0 +1,387 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mindeco.de/http/auth/token"
+)
+
+// TokenStore persists single-use tokens, like the ones used for password resets.
+// Only a hash of the token is passed to it, so that the secret doesn't end up in a database.
+type TokenStore interface {
+	// Put saves the userID under the token id until expires
+	Put(id, userID string, expires time.Time) error
+
+	// Pop returns the user of the token and removes it from the store.
+	// It should return ErrInvalidToken for unknown or expired tokens.
+	Pop(id string) (userID string, err error)
+}
+
+// ResetUserStore is used by PasswordReset to find users and change their passwords
+type ResetUserStore interface {
+	// LookupUser returns the ID for the login name or email address or ErrNoSuchUser
+	LookupUser(login string) (userID string, err error)
+
+	// SetPassword is called with the new password once a valid reset token was consumed
+	SetPassword(userID, newPassword string) error
+}
+
+// ResetDeliverer sends the reset token to the user, most likely as a link in an email
+type ResetDeliverer interface {
+	DeliverResetToken(r *http.Request, userID, token string) error
+}
+
+// PasswordReset exposes handlers to request and consume password reset tokens.
+type PasswordReset struct {
+	ah *Handler
+
+	users   ResetUserStore
+	tokens  TokenStore
+	deliver ResetDeliverer
+
+	signer   *token.Signer
+	lifetime time.Duration
+
+	redirRequested string // the url to redirect to after a token was requested
+	redirDone      string // the url to redirect to after the password was changed
+}
+
+// ResetOption is a function that changes a PasswordReset during initialization
+type ResetOption func(pr *PasswordReset) error
+
+// SetResetLifetime sets how long reset tokens are valid (default 1 hour)
+func SetResetLifetime(d time.Duration) ResetOption {
+	return func(pr *PasswordReset) error {
+		if d <= 0 {
+			return errors.New("reset lifetime needs to be positive")
+		}
+		pr.lifetime = d
+		return nil
+	}
+}
+
+// SetResetRedirects sets where to redirect after a token was requested and after the password was changed.
+// Both default to the landing location of the Handler.
+func SetResetRedirects(requested, done string) ResetOption {
+	return func(pr *PasswordReset) error {
+		if requested == "" || done == "" {
+			return errors.New("reset redirects can't be empty")
+		}
+		pr.redirRequested = requested
+		pr.redirDone = done
+		return nil
+	}
+}
+
+// NewPasswordReset creates the handlers for the reset flow.
+// The key is used to sign the tokens (with a token.Signer) and needs to be at least 32 bytes long.
+// ah needs a RevocationStore (see SetRevocationStore), all sessions of the user are revoked once the password was changed.
+func NewPasswordReset(ah *Handler, key []byte, users ResetUserStore, tokens TokenStore, deliver ResetDeliverer, opts ...ResetOption) (*PasswordReset, error) {
+	if ah == nil {
+		return nil, errors.New("auth: reset needs a Handler")
+	}
+	if ah.revocations == nil {
+		return nil, errors.New("auth: reset needs a Handler with a RevocationStore, to end the sessions of the old password")
+	}
+	if len(key) < 32 {
+		return nil, errors.New("auth: reset key too short")
+	}
+	if users == nil || tokens == nil || deliver == nil {
+		return nil, errors.New("auth: reset needs a user store, token store and deliverer")
+	}
+
+	signer, err := token.NewSigner(key)
+	if err != nil {
+		return nil, fmt.Errorf("auth: reset key: %w", err)
+	}
+
+	pr := &PasswordReset{
+		ah:      ah,
+		signer:  signer,
+		users:   users,
+		tokens:  tokens,
+		deliver: deliver,
+	}
+
+	for _, o := range opts {
+		if err := o(pr); err != nil {
+			return nil, err
+		}
+	}
+
+	if pr.lifetime == 0 {
+		pr.lifetime = time.Hour
+	}
+
+	if pr.redirRequested == "" {
+		pr.redirRequested = ah.redirLanding
+	}
+
+	if pr.redirDone == "" {
+		pr.redirDone = ah.redirLanding
+	}
+
+	return pr, nil
+}
+
+// Request is a http.HandlerFunc for a POST request with the form field login.
+// It creates a token and hands it to the ResetDeliverer.
+// To not expose which users exist, it responds the same way for unknown users.
+func (pr PasswordReset) Request(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pr.ah.errorHandler(w, r, fmt.Errorf("method should be POST"), http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		pr.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	login := r.Form.Get("login")
+	if login == "" {
+		pr.ah.errorHandler(w, r, ErrBadLogin, http.StatusBadRequest)
+		return
+	}
+
+	userID, err := pr.users.LookupUser(login)
+	if err != nil {
+		if errors.Is(err, ErrNoSuchUser) {
+			http.Redirect(w, r, pr.redirRequested, http.StatusSeeOther)
+			return
+		}
+		pr.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	id, token, err := newSingleUseToken(pr.signer, purposeReset, pr.lifetime)
+	if err != nil {
+		pr.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := pr.tokens.Put(id, userID, time.Now().Add(pr.lifetime)); err != nil {
+		pr.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := pr.deliver.DeliverResetToken(r, userID, token); err != nil {
+		pr.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, pr.redirRequested, http.StatusSeeOther)
+}
+
+// Consume is a http.HandlerFunc for a POST request with the form fields token and pass.
+// It sets the new password if the token is valid and revokes existing sessions of the user.
+func (pr PasswordReset) Consume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pr.ah.errorHandler(w, r, fmt.Errorf("method should be POST"), http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		pr.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	token := r.Form.Get("token")
+	pass := r.Form.Get("pass")
+	if token == "" || pass == "" {
+		pr.ah.errorHandler(w, r, ErrInvalidToken, http.StatusBadRequest)
+		return
+	}
+
+	id, err := verifySingleUseToken(pr.signer, purposeReset, token)
+	if err != nil {
+		pr.ah.errorHandler(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	userID, err := pr.tokens.Pop(id)
+	if err != nil {
+		pr.ah.errorHandler(w, r, err, StatusOf(err))
+		return
+	}
+
+	if err := pr.users.SetPassword(userID, pass); err != nil {
+		pr.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := pr.ah.RevokeSessions(userID); err != nil {
+		pr.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, pr.redirDone, http.StatusSeeOther)
+}
+
+// the purposes of signed tokens, so that a token of one flow can't be used for another
+const (
+	purposeReset  = "reset"
+	purposeVerify = "verify"
+)
+
+// newSingleUseToken mints a token for purpose whose subject is a random nonce.
+// It returns the id for the TokenStore (hash of the nonce) and the token for the user.
+func newSingleUseToken(s *token.Signer, purpose string, lifetime time.Duration) (string, string, error) {
+	var nonce [32]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", "", fmt.Errorf("auth: failed to read random token: %w", err)
+	}
+	n := base64.RawURLEncoding.EncodeToString(nonce[:])
+
+	tok, err := s.Mint(purpose, n, lifetime)
+	if err != nil {
+		return "", "", err
+	}
+	return tokenID([]byte(n)), tok, nil
+}
+
+// verifySingleUseToken checks the signature, expiry and purpose of the token and returns its store id
+func verifySingleUseToken(s *token.Signer, purpose, tok string) (string, error) {
+	n, err := s.Verify(purpose, tok)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	return tokenID([]byte(n)), nil
+}
+
+func tokenID(secret []byte) string {
+	h := sha256.Sum256(secret)
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// Defaults for the limits of MemTokenStore
+const (
+	DefaultMaxTokensPerUser = 5
+	DefaultMaxTokens        = 10000
+)
+
+// MemTokenStore is an in-memory TokenStore.
+// Expired tokens are dropped when new ones are put.
+type MemTokenStore struct {
+	// MaxPerUser limits the outstanding tokens of a user, the oldest one is dropped when a new one is put.
+	// The Request handler doesn't need a login, so this stops anyone from piling up tokens for a user.
+	MaxPerUser int
+
+	// MaxTokens limits the outstanding tokens of all users, the oldest ones are dropped when there are more
+	MaxTokens int
+
+	mu      sync.Mutex
+	tokens  map[string]memToken
+	perUser map[string][]string // token IDs of each user, oldest first
+	queue   []string            // token IDs in the order they were put, which is roughly the order they expire in
+}
+
+type memToken struct {
+	userID  string
+	expires time.Time
+}
+
+// NewMemTokenStore returns an empty MemTokenStore
+func NewMemTokenStore() *MemTokenStore {
+	return &MemTokenStore{
+		tokens:  make(map[string]memToken),
+		perUser: make(map[string][]string),
+	}
+}
+
+// Put implements TokenStore
+func (ms *MemTokenStore) Put(id, userID string, expires time.Time) error {
+	perUser := ms.MaxPerUser
+	if perUser <= 0 {
+		perUser = DefaultMaxTokensPerUser
+	}
+	total := ms.MaxTokens
+	if total <= 0 {
+		total = DefaultMaxTokens
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.remove(id)
+	ms.prune(time.Now(), total)
+	for len(ms.perUser[userID]) >= perUser {
+		ms.remove(ms.perUser[userID][0])
+	}
+
+	ms.tokens[id] = memToken{userID: userID, expires: expires}
+	ms.perUser[userID] = append(ms.perUser[userID], id)
+	ms.queue = append(ms.queue, id)
+	return nil
+}
+
+// prune drops expired tokens and the oldest ones over the limit from the front of the queue,
+// so that there is room for one more
+func (ms *MemTokenStore) prune(now time.Time, limit int) {
+	for len(ms.queue) > 0 {
+		id := ms.queue[0]
+		if t, has := ms.tokens[id]; has {
+			if len(ms.tokens) < limit && !now.After(t.expires) {
+				break
+			}
+			ms.remove(id)
+		}
+		ms.queue = ms.queue[1:]
+	}
+
+	// popped tokens stay in the queue until they get to the front, don't let them pile up behind a live one
+	if len(ms.queue) > 2*len(ms.tokens)+16 {
+		live := ms.queue[:0]
+		for _, id := range ms.queue {
+			if _, has := ms.tokens[id]; has {
+				live = append(live, id)
+			}
+		}
+		ms.queue = live
+	}
+}
+
+// remove deletes the token from the map and the list of its user (but not from the queue)
+func (ms *MemTokenStore) remove(id string) {
+	t, has := ms.tokens[id]
+	if !has {
+		return
+	}
+	delete(ms.tokens, id)
+
+	ids := ms.perUser[t.userID]
+	for i, other := range ids {
+		if other == id {
+			ids = append(ids[:i:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(ms.perUser, t.userID)
+	} else {
+		ms.perUser[t.userID] = ids
+	}
+}
+
+// Pop implements TokenStore
+func (ms *MemTokenStore) Pop(id string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	t, has := ms.tokens[id]
+	if !has {
+		return "", ErrInvalidToken
+	}
+	ms.remove(id)
+
+	if time.Now().After(t.expires) {
+		return "", ErrInvalidToken
+	}
+	return t.userID, nil
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockResetUsers struct {
+	passwords map[string]string
+}
+
+func (m mockResetUsers) LookupUser(login string) (string, error) {
+	if _, has := m.passwords[login]; !has {
+		return "", ErrNoSuchUser
+	}
+	return login, nil
+}
+
+func (m mockResetUsers) SetPassword(userID, pass string) error {
+	m.passwords[userID] = pass
+	return nil
+}
+
+type mockDeliverer struct {
+	tokens map[string]string
+}
+
+func (m mockDeliverer) DeliverResetToken(_ *http.Request, userID, token string) error {
+	m.tokens[userID] = token
+	return nil
+}
+
+func TestPasswordReset(t *testing.T) {
+	testOptions = []Option{SetRevocationStore(NewMemRevocationStore())}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	users := mockResetUsers{passwords: map[string]string{"testUser": "testPassw"}}
+	deliver := mockDeliverer{tokens: make(map[string]string)}
+	key := make([]byte, 32)
+
+	_, err := NewPasswordReset(&Handler{}, key, users, NewMemTokenStore(), deliver)
+	a.Error(err, "needs a RevocationStore")
+
+	pr, err := NewPasswordReset(testHandler, key, users, NewMemTokenStore(), deliver, SetResetRedirects("/requested", "/done"))
+	a.NoError(err)
+	testMux.HandleFunc("/reset/request", pr.Request)
+	testMux.HandleFunc("/reset/consume", pr.Consume)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		if users.passwords[u] != p {
+			return nil, ErrBadLogin
+		}
+		return u, nil
+	}
+
+	resp := testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	oldCookie := resp.Header().Get("Set-Cookie")
+
+	// unknown users look the same from the outside
+	resp = testClient.PostForm(urlTo("/reset/request"), url.Values{"login": {"unknown"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal("/requested", resp.Header().Get("Location"))
+	a.Len(deliver.tokens, 0)
+
+	resp = testClient.PostForm(urlTo("/reset/request"), url.Values{"login": {"testUser"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal("/requested", resp.Header().Get("Location"))
+	token, has := deliver.tokens["testUser"]
+	a.True(has)
+
+	// tampered tokens are rejected
+	resp = testClient.PostForm(urlTo("/reset/consume"), url.Values{"token": {token + "x"}, "pass": {"newPassw"}})
+	a.Equal(http.StatusBadRequest, resp.Code)
+
+	resp = testClient.PostForm(urlTo("/reset/consume"), url.Values{"token": {token}, "pass": {"newPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal("/done", resp.Header().Get("Location"))
+	a.Equal("newPassw", users.passwords["testUser"])
+
+	// single use
+	resp = testClient.PostForm(urlTo("/reset/consume"), url.Values{"token": {token}, "pass": {"again"}})
+	a.Equal(http.StatusBadRequest, resp.Code)
+	a.Equal("newPassw", users.passwords["testUser"])
+
+	// the session from before the reset is gone
+	testClient.SetHeaders(http.Header{"Cookie": []string{oldCookie}})
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusUnauthorized, resp.Code)
+	testClient.ClearHeaders()
+
+	resp = testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"newPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusOK, resp.Code)
+}
+
+func TestMemTokenStoreLimits(t *testing.T) {
+	a := assert.New(t)
+	ms := NewMemTokenStore()
+	ms.MaxTokens = 10
+
+	soon := time.Now().Add(time.Hour)
+	a.NoError(ms.Put("first", "other", soon))
+	for i := 0; i < 100; i++ {
+		a.NoError(ms.Put(fmt.Sprint("flood", i), "victim", soon))
+	}
+	a.Len(ms.tokens, 1+DefaultMaxTokensPerUser, "a user only has a few tokens")
+	a.LessOrEqual(len(ms.queue), 2*len(ms.tokens)+16+1, "dropped tokens don't pile up behind live ones")
+
+	_, err := ms.Pop("flood0")
+	a.Equal(ErrInvalidToken, err, "the oldest ones were dropped")
+	user, err := ms.Pop("flood99")
+	a.NoError(err)
+	a.Equal("victim", user)
+
+	for i := 0; i < 20; i++ {
+		a.NoError(ms.Put(fmt.Sprint("user", i), fmt.Sprint("user", i), soon))
+	}
+	a.Len(ms.tokens, 10)
+	_, err = ms.Pop("first")
+	a.Equal(ErrInvalidToken, err)
+	_, err = ms.Pop("user9")
+	a.Equal(ErrInvalidToken, err)
+	_, err = ms.Pop("user10")
+	a.NoError(err)
+
+	// expired tokens go away with the next Put
+	ms = NewMemTokenStore()
+	a.NoError(ms.Put("old", "a", time.Now().Add(-time.Minute)))
+	a.NoError(ms.Put("new", "b", soon))
+	a.Len(ms.tokens, 1)
+	a.Len(ms.perUser, 1)
+	a.Len(ms.queue, 1)
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationStore keeps track of when all the sessions of a user were revoked.
+// Sessions that were created before that point in time are treated as not authorized.
+type RevocationStore interface {
+	RevokeAll(userID string, at time.Time) error
+
+	// RevokedAt returns the last time RevokeAll was called for the user and false if it never was.
+	RevokedAt(userID string) (time.Time, bool, error)
+}
+
+// UserIDFunc maps the session data returned by the Auther to a stable user ID
+type UserIDFunc func(userData interface{}) string
+
+// RevokeSessions invalidates all the sessions of the passed user which exist at this point.
+// It needs SetRevocationStore to be configured.
+func (ah Handler) RevokeSessions(userID string) error {
+	if ah.revocations == nil {
+		return ErrNoRevocationStore
+	}
+	return ah.revocations.RevokeAll(userID, time.Now())
+}
+
+func (ah Handler) isRevoked(userData interface{}, created time.Time) (bool, error) {
+	if ah.revocations == nil {
+		return false, nil
+	}
+
+	at, has, err := ah.revocations.RevokedAt(ah.userID(userData))
+	if err != nil {
+		return false, err
+	}
+	if !has {
+		return false, nil
+	}
+
+	return !created.After(at), nil
+}
+
+// MemRevocationStore is an in-memory RevocationStore.
+// It forgets everything on restart, which is only fine for single process deployments with short(er) session lifetimes.
+type MemRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// NewMemRevocationStore returns an empty MemRevocationStore
+func NewMemRevocationStore() *MemRevocationStore {
+	return &MemRevocationStore{revoked: make(map[string]time.Time)}
+}
+
+// RevokeAll implements RevocationStore
+func (ms *MemRevocationStore) RevokeAll(userID string, at time.Time) error {
+	ms.mu.Lock()
+	ms.revoked[userID] = at
+	ms.mu.Unlock()
+	return nil
+}
+
+// RevokedAt implements RevocationStore
+func (ms *MemRevocationStore) RevokedAt(userID string) (time.Time, bool, error) {
+	ms.mu.Lock()
+	at, has := ms.revoked[userID]
+	ms.mu.Unlock()
+	return at, has, nil
+}
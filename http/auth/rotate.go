@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// KeyPair holds the keys for a session cookie.
+// Hash authenticates the cookie and is required (32 or 64 bytes),
+// Block enables encryption and is optional (16, 24 or 32 bytes for AES-128, AES-192 or AES-256).
+type KeyPair struct {
+	Hash, Block []byte
+}
+
+// SetCookieKeys creates a sessions.CookieStore which writes sessions with the current keys
+// and still accepts sessions that were written with one of the old ones.
+// The passed options can be nil, which uses the defaults of gorilla/sessions.
+//
+// Rotating keys works like this: deploy the new pair as current and the previous one as old.
+// Sessions are re-encoded with the current keys whenever they are saved again
+// (or on every request that passes through RefreshSessions).
+// Once an old pair is removed, all sessions that are still encoded with it are invalid
+// and those users need to log in again. Changing the current pair without passing the previous one as old logs everyone out.
+func SetCookieKeys(opts *sessions.Options, current KeyPair, old ...KeyPair) Option {
+	return func(h *Handler) error {
+		pairs := [][]byte{}
+		for _, kp := range append([]KeyPair{current}, old...) {
+			if len(kp.Hash) == 0 {
+				return errors.New("cookie hash key can't be empty")
+			}
+			pairs = append(pairs, kp.Hash, kp.Block)
+		}
+
+		cs := sessions.NewCookieStore(pairs...)
+		if opts != nil {
+			cs.Options = opts
+			// MaxAge 0 is a browser session cookie, securecookie would then stop checking the timestamp.
+			// The codecs keep their default of 30 days for it, so stolen cookies still expire.
+			if opts.MaxAge > 0 {
+				cs.MaxAge(opts.MaxAge)
+			}
+		}
+		h.store = cs
+
+		h.currentCodec = cs.Codecs[0]
+		return nil
+	}
+}
+
+// RefreshSessions re-saves sessions which were not encoded with the current keys, see SetCookieKeys.
+// This way active users are moved to the new keys before the old ones are removed.
+// It does nothing for stores that weren't created with SetCookieKeys.
+func (ah Handler) RefreshSessions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ah.currentCodec != nil && ah.needsRefresh(r) {
+			if err := ah.resave(r, w); err != nil {
+				ah.errorHandler(w, r, err, http.StatusInternalServerError)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ah Handler) needsRefresh(r *http.Request) bool {
+	c, err := r.Cookie(ah.nameFor(r))
+	if err != nil {
+		return false
+	}
+
+	var vals map[interface{}]interface{}
+	err = securecookie.DecodeMulti(ah.nameFor(r), c.Value, &vals, ah.currentCodec)
+	return err != nil
+}
+
+func (ah Handler) resave(r *http.Request, w http.ResponseWriter) error {
+	session, err := ah.getSession(r)
+	if err != nil {
+		// not readable with any of the keys, nothing to carry over
+		return nil
+	}
+	if session.IsNew {
+		return nil
+	}
+	return session.Save(r, w)
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotation(t *testing.T) {
+	a := assert.New(t)
+
+	auther := mockProvider{checkMock: func(u, p string) (interface{}, error) {
+		return 23, nil
+	}}
+
+	oldKeys := KeyPair{securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32)}
+	newKeys := KeyPair{securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32)}
+
+	before, err := NewHandler(auther, SetCookieKeys(nil, oldKeys))
+	a.NoError(err)
+
+	vals := url.Values{"user": {"testUser"}, "pass": {"testPassw"}}
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(vals.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rw := httptest.NewRecorder()
+	before.Authorize(rw, req)
+	a.Equal(http.StatusSeeOther, rw.Code)
+	oldCookie := rw.Header().Get("Set-Cookie")
+
+	profile := func(ah *Handler, cookie string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/profile", nil)
+		req.Header.Set("Cookie", cookie)
+		rw := httptest.NewRecorder()
+		ah.RefreshSessions(ah.Authenticate(http.HandlerFunc(restricted))).ServeHTTP(rw, req)
+		return rw
+	}
+
+	// only the new keys: everyone is logged out
+	replaced, err := NewHandler(auther, SetCookieKeys(nil, newKeys))
+	a.NoError(err)
+	a.Equal(http.StatusUnauthorized, profile(replaced, oldCookie).Code)
+
+	// rotated: old sessions are still valid and get re-encoded
+	rotated, err := NewHandler(auther, SetCookieKeys(nil, newKeys, oldKeys))
+	a.NoError(err)
+	resp := profile(rotated, oldCookie)
+	a.Equal(http.StatusOK, resp.Code)
+	refreshed := resp.Header().Get("Set-Cookie")
+	a.NotEqual("", refreshed)
+
+	// once refreshed the old key can be dropped
+	a.Equal(http.StatusOK, profile(replaced, refreshed).Code)
+
+	// sessions with the current key are not re-saved again
+	resp = profile(rotated, refreshed)
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal("", resp.Header().Get("Set-Cookie"))
+}
+
+func TestCookieKeysSessionCookieExpires(t *testing.T) {
+	a := assert.New(t)
+
+	key := securecookie.GenerateRandomKey(32)
+	h, err := NewHandler(mockProvider{}, SetCookieKeys(&sessions.Options{Path: "/", MaxAge: 0}, KeyPair{Hash: key}))
+	a.NoError(err)
+
+	// encodes like securecookie would, but with an older timestamp
+	encodeAt := func(ts time.Time) string {
+		var buf bytes.Buffer
+		a.NoError(gob.NewEncoder(&buf).Encode(map[interface{}]interface{}{"user": "alice"}))
+		b64 := base64.URLEncoding
+		msg := fmt.Sprintf("session|%d|%s", ts.Unix(), b64.EncodeToString(buf.Bytes()))
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(msg))
+		return b64.EncodeToString(append([]byte(msg+"|"), mac.Sum(nil)...)[len("session|"):])
+	}
+
+	var vals map[interface{}]interface{}
+	a.NoError(securecookie.DecodeMulti("session", encodeAt(time.Now().Add(-time.Hour)), &vals, h.currentCodec))
+	a.Equal("alice", vals["user"])
+
+	err = securecookie.DecodeMulti("session", encodeAt(time.Now().Add(-31*24*time.Hour)), &vals, h.currentCodec)
+	a.Error(err, "MaxAge 0 keeps the server side expiry")
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// ScopeFunc returns the cookie name and domain of the session for a request.
+// An empty domain leaves the one of the store options untouched.
+type ScopeFunc func(r *http.Request) (name, domain string)
+
+// PerHostScope derives the cookie name from the Host of the request (prefix_host) and sets the domain to it.
+// This keeps the sessions of different virtual hosts apart, even if they share a parent domain.
+func PerHostScope(prefix string) ScopeFunc {
+	return func(r *http.Request) (string, string) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(host)
+
+		name := strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+				return r
+			}
+			return '_'
+		}, host)
+		return prefix + "_" + name, host
+	}
+}
+
+func (ah Handler) nameFor(r *http.Request) string {
+	if ah.scope == nil {
+		return ah.sessionName
+	}
+	name, _ := ah.scope(r)
+	return name
+}
+
+// getSession loads the session of the request and applies the scope to its options
+func (ah Handler) getSession(r *http.Request) (*sessions.Session, error) {
+	if ah.scope == nil {
+		return ah.store.Get(r, ah.sessionName)
+	}
+	name, domain := ah.scope(r)
+	session, err := ah.store.Get(r, name)
+	ah.applyDomain(session, domain)
+	return session, err
+}
+
+// newSession is like getSession but always starts a fresh session
+func (ah Handler) newSession(r *http.Request) (*sessions.Session, error) {
+	if ah.scope == nil {
+		return ah.store.New(r, ah.sessionName)
+	}
+	name, domain := ah.scope(r)
+	session, err := ah.store.New(r, name)
+	ah.applyDomain(session, domain)
+	return session, err
+}
+
+func (ah Handler) applyDomain(session *sessions.Session, domain string) {
+	if session == nil || domain == "" {
+		return
+	}
+	if session.Options == nil {
+		session.Options = &sessions.Options{Path: "/"}
+	} else {
+		// don't modify the options of the store, they might be shared
+		opts := *session.Options
+		session.Options = &opts
+	}
+	session.Options.Domain = domain
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerHostScope(t *testing.T) {
+	a := assert.New(t)
+
+	auther := mockProvider{checkMock: func(u, p string) (interface{}, error) {
+		return u, nil
+	}}
+	ah, err := NewHandler(auther,
+		SetCookieKeys(nil, KeyPair{Hash: securecookie.GenerateRandomKey(32)}),
+		SetSessionScope(PerHostScope("sess")),
+	)
+	a.NoError(err)
+
+	vals := url.Values{"user": {"alice"}, "pass": {"testPassw"}}
+	req := httptest.NewRequest("POST", "http://tenant-a.example:8080/login", strings.NewReader(vals.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rw := httptest.NewRecorder()
+	ah.Authorize(rw, req)
+	a.Equal(http.StatusSeeOther, rw.Code)
+
+	cookies := rw.Result().Cookies()
+	a.Len(cookies, 1)
+	a.Equal("sess_tenant-a.example", cookies[0].Name)
+	a.Equal("tenant-a.example", cookies[0].Domain)
+
+	check := func(host string) error {
+		req := httptest.NewRequest("GET", "http://"+host+"/profile", nil)
+		// the same cookie, as if the browser would send it to the other host
+		req.AddCookie(&http.Cookie{Name: "sess_tenant-b.example", Value: cookies[0].Value})
+		req.AddCookie(cookies[0])
+		_, err := ah.AuthenticateRequest(req)
+		return err
+	}
+
+	a.NoError(check("tenant-a.example"))
+	a.Error(check("tenant-b.example"), "other tenants can't use the session")
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mindeco.de/http/clientip"
+)
+
+// SessionInfo is the metadata of a session, as shown on a "devices" page
+type SessionInfo struct {
+	ID     string
+	UserID string
+
+	Created  time.Time
+	LastSeen time.Time
+	Expires  time.Time
+
+	IP        string
+	UserAgent string
+}
+
+// SessionRegistry keeps track of the sessions of all users, see SetSessionRegistry
+type SessionRegistry interface {
+	Add(info SessionInfo) error
+
+	// Seen updates LastSeen of the session.
+	// It returns false if the session isn't known (anymore), which makes the session not authorized.
+	Seen(id string, at time.Time) (bool, error)
+
+	// List returns the sessions of the user which haven't expired yet, newest first
+	List(userID string) ([]SessionInfo, error)
+
+	// Remove forgets the session, it's not an error if it doesn't exist
+	Remove(id string) error
+}
+
+// ErrNoSessionRegistry is returned by ListSessions and RevokeSession if SetSessionRegistry wasn't configured
+var ErrNoSessionRegistry = errors.New("auth: no session registry configured")
+
+// ErrUnknownSession is returned by RevokeSession if the user has no such session
+var ErrUnknownSession = errors.New("auth: unknown session")
+
+// ListSessions returns the active sessions of the user
+func (ah Handler) ListSessions(userID string) ([]SessionInfo, error) {
+	if ah.registry == nil {
+		return nil, ErrNoSessionRegistry
+	}
+	return ah.registry.List(userID)
+}
+
+// RevokeSession ends a single session of the user, identified by SessionInfo.ID.
+// Sessions of other users are refused with ErrUnknownSession, so the ID can be taken from a form as it is.
+func (ah Handler) RevokeSession(userID, id string) error {
+	if ah.registry == nil {
+		return ErrNoSessionRegistry
+	}
+
+	list, err := ah.registry.List(userID)
+	if err != nil {
+		return err
+	}
+	for _, info := range list {
+		if info.ID == id {
+			return ah.registry.Remove(id)
+		}
+	}
+	return ErrUnknownSession
+}
+
+// SessionID returns the ID of the session of the request, to mark it in the list of ListSessions
+func (ah Handler) SessionID(r *http.Request) (string, error) {
+	session, err := ah.getSession(r)
+	if err != nil {
+		return "", err
+	}
+
+	id, ok := session.Values[userSessionID].(string)
+	if !ok {
+		return "", ErrNotAuthorized
+	}
+	return id, nil
+}
+
+func (ah Handler) registerSession(r *http.Request, userData interface{}, now time.Time) (string, error) {
+	id, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = ah.registry.Add(SessionInfo{
+		ID:        id,
+		UserID:    ah.userID(userData),
+		Created:   now,
+		LastSeen:  now,
+		Expires:   now.Add(ah.lifetime),
+		IP:        clientip.FromRequest(r),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// MemSessionRegistry is an in-memory SessionRegistry.
+// Expired sessions are forgotten when the registry is used.
+type MemSessionRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]SessionInfo
+	queue    []string // IDs in the order they were added, which is also the order they expire in
+}
+
+// NewMemSessionRegistry returns an empty MemSessionRegistry
+func NewMemSessionRegistry() *MemSessionRegistry {
+	return &MemSessionRegistry{sessions: make(map[string]SessionInfo)}
+}
+
+func expired(info SessionInfo, now time.Time) bool {
+	return !info.Expires.IsZero() && now.After(info.Expires)
+}
+
+// prune drops the expired sessions from the front of the queue and the IDs of removed ones with them
+func (ms *MemSessionRegistry) prune(now time.Time) {
+	for len(ms.queue) > 0 {
+		id := ms.queue[0]
+		if info, has := ms.sessions[id]; has {
+			if !expired(info, now) {
+				return
+			}
+			delete(ms.sessions, id)
+		}
+		ms.queue = ms.queue[1:]
+	}
+}
+
+// Add implements SessionRegistry
+func (ms *MemSessionRegistry) Add(info SessionInfo) error {
+	ms.mu.Lock()
+	ms.prune(info.Created)
+	ms.sessions[info.ID] = info
+	ms.queue = append(ms.queue, info.ID)
+	ms.mu.Unlock()
+	return nil
+}
+
+// Seen implements SessionRegistry
+func (ms *MemSessionRegistry) Seen(id string, at time.Time) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.prune(at)
+
+	info, has := ms.sessions[id]
+	if !has {
+		return false, nil
+	}
+	if expired(info, at) {
+		delete(ms.sessions, id)
+		return false, nil
+	}
+	info.LastSeen = at
+	ms.sessions[id] = info
+	return true, nil
+}
+
+// List implements SessionRegistry
+func (ms *MemSessionRegistry) List(userID string) ([]SessionInfo, error) {
+	now := time.Now()
+
+	ms.mu.Lock()
+	ms.prune(now)
+	var list []SessionInfo
+	for id, info := range ms.sessions {
+		if expired(info, now) {
+			delete(ms.sessions, id)
+			continue
+		}
+		if info.UserID == userID {
+			list = append(list, info)
+		}
+	}
+	ms.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.After(list[j].Created)
+	})
+	return list, nil
+}
+
+// Remove implements SessionRegistry
+func (ms *MemSessionRegistry) Remove(id string) error {
+	ms.mu.Lock()
+	delete(ms.sessions, id)
+	ms.mu.Unlock()
+	return nil
+}
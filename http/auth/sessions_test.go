@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListSessions(t *testing.T) {
+	testOptions = []Option{SetSessionRegistry(NewMemSessionRegistry())}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return u, nil
+	}
+
+	login := func(ua string) string {
+		testClient.ClearCookies() // a different device
+		testClient.SetHeaders(http.Header{"User-Agent": []string{ua}})
+		resp := testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+		testClient.ClearHeaders()
+		a.Equal(http.StatusSeeOther, resp.Code)
+		return resp.Header().Get("Set-Cookie")
+	}
+	laptop := login("laptop")
+	phone := login("phone")
+
+	list, err := testHandler.ListSessions("testUser")
+	a.NoError(err)
+	a.Len(list, 2)
+	agents := map[string]string{}
+	for _, info := range list {
+		a.Equal("testUser", info.UserID)
+		a.False(info.Created.IsZero())
+		a.True(info.Expires.After(info.Created))
+		agents[info.UserAgent] = info.ID
+	}
+	a.Contains(agents, "laptop")
+	a.Contains(agents, "phone")
+
+	testClient.SetHeaders(http.Header{"Cookie": []string{phone}})
+	resp := testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusOK, resp.Code)
+	testClient.ClearHeaders()
+
+	a.Equal(ErrUnknownSession, testHandler.RevokeSession("otherUser", agents["phone"]), "only the own sessions can be revoked")
+	testClient.SetHeaders(http.Header{"Cookie": []string{phone}})
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusOK, resp.Code)
+	testClient.ClearHeaders()
+
+	a.NoError(testHandler.RevokeSession("testUser", agents["phone"]))
+
+	testClient.SetHeaders(http.Header{"Cookie": []string{phone}})
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusUnauthorized, resp.Code)
+	testClient.ClearHeaders()
+
+	testClient.SetHeaders(http.Header{"Cookie": []string{laptop}})
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusOK, resp.Code)
+	testClient.ClearHeaders()
+
+	list, err = testHandler.ListSessions("testUser")
+	a.NoError(err)
+	a.Len(list, 1)
+	a.Equal("laptop", list[0].UserAgent)
+}
+
+func TestListSessionsUnconfigured(t *testing.T) {
+	setup(t)
+	defer teardown()
+	a := assert.New(t)
+
+	_, err := testHandler.ListSessions("testUser")
+	a.Equal(ErrNoSessionRegistry, err)
+	a.Equal(ErrNoSessionRegistry, testHandler.RevokeSession("testUser", "foo"))
+}
+
+func TestMemSessionRegistryExpires(t *testing.T) {
+	a := assert.New(t)
+	ms := NewMemSessionRegistry()
+
+	now := time.Now()
+	a.NoError(ms.Add(SessionInfo{ID: "old", UserID: "u", Created: now.Add(-2 * time.Hour), Expires: now.Add(-time.Hour)}))
+	a.NoError(ms.Add(SessionInfo{ID: "new", UserID: "u", Created: now, Expires: now.Add(time.Hour)}))
+
+	list, err := ms.List("u")
+	a.NoError(err)
+	a.Len(list, 1)
+	a.Equal("new", list[0].ID)
+	a.Len(ms.sessions, 1, "expired sessions are forgotten")
+
+	known, err := ms.Seen("new", now.Add(2*time.Hour))
+	a.NoError(err)
+	a.False(known)
+	a.Len(ms.sessions, 0)
+	a.Len(ms.queue, 0)
+
+	// removed sessions don't pile up in the queue
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprint(i)
+		a.NoError(ms.Add(SessionInfo{ID: id, UserID: "u", Created: now, Expires: now.Add(time.Minute)}))
+		a.NoError(ms.Remove(id))
+	}
+	known, err = ms.Seen("0", now.Add(time.Second))
+	a.NoError(err)
+	a.False(known)
+	a.Len(ms.queue, 0)
+}
@@ -0,0 +1,164 @@
+// Package ssb implements a sign-in flow for Secure Scuttlebutt identities.
+//
+// The client fetches a challenge from the Challenge handler, signs it with the ed25519 key of its feed
+// and posts the feed ID as user and the response as pass to (auth.Handler).Authorize.
+// The response is "<challenge>.<base64 signature>" and the signed message is
+// SignPrefix followed by the challenge, so that signatures can't be reused for something else.
+// The feed ID (@<base64 public key>.ed25519) is stored as the session data.
+package ssb
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mindeco.de/http/auth"
+)
+
+// SignPrefix is prepended to the challenge before it is signed
+const SignPrefix = "go.mindeco.de/http/auth/ssb:sign-in:"
+
+// ErrInvalidFeed is returned by ParseFeedID for malformed feed IDs
+var ErrInvalidFeed = errors.New("ssb: invalid feed ID")
+
+// ParseFeedID returns the public key of a feed ID like @<base64>.ed25519
+func ParseFeedID(ref string) (ed25519.PublicKey, error) {
+	if !strings.HasPrefix(ref, "@") || !strings.HasSuffix(ref, ".ed25519") {
+		return nil, ErrInvalidFeed
+	}
+	b, err := base64.StdEncoding.DecodeString(ref[1 : len(ref)-len(".ed25519")])
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, ErrInvalidFeed
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// FeedID returns the feed ID for a public key
+func FeedID(pub ed25519.PublicKey) string {
+	return "@" + base64.StdEncoding.EncodeToString(pub) + ".ed25519"
+}
+
+// Sign creates the response for a challenge, as a client would
+func Sign(key ed25519.PrivateKey, challenge string) string {
+	sig := ed25519.Sign(key, []byte(SignPrefix+challenge))
+	return challenge + "." + base64.StdEncoding.EncodeToString(sig)
+}
+
+// DefaultMaxChallenges is how many challenges an Auther keeps at most, if MaxChallenges isn't set
+const DefaultMaxChallenges = 10000
+
+// Auther issues challenges and checks the signed responses.
+// Challenges can only be used once and are valid for a limited time.
+type Auther struct {
+	lifetime time.Duration
+
+	// Allowed can be set to restrict which feeds can sign in, by default every valid signature is accepted
+	Allowed func(feedID string) bool
+
+	// MaxChallenges limits the outstanding challenges, the oldest ones are dropped when there are more.
+	// The Challenge handler doesn't need a login, so this bounds the memory anyone can make it use.
+	MaxChallenges int
+
+	mu         sync.Mutex
+	challenges map[string]time.Time
+	queue      []issued // in the order they were issued, which is also the order they expire in
+}
+
+type issued struct {
+	challenge string
+	expires   time.Time
+}
+
+var _ auth.Auther = (*Auther)(nil)
+
+// NewAuther returns an Auther whose challenges are valid for lifetime
+func NewAuther(lifetime time.Duration) *Auther {
+	return &Auther{
+		lifetime:   lifetime,
+		challenges: make(map[string]time.Time),
+	}
+}
+
+// NewChallenge returns a fresh challenge
+func (sa *Auther) NewChallenge() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("ssb: failed to read random challenge: %w", err)
+	}
+	c := base64.RawURLEncoding.EncodeToString(b[:])
+
+	limit := sa.MaxChallenges
+	if limit <= 0 {
+		limit = DefaultMaxChallenges
+	}
+
+	now := time.Now()
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	// all challenges have the same lifetime, so only the front of the queue needs to be looked at
+	for len(sa.queue) > 0 && (len(sa.queue) >= limit || now.After(sa.queue[0].expires)) {
+		delete(sa.challenges, sa.queue[0].challenge)
+		sa.queue = sa.queue[1:]
+	}
+
+	exp := now.Add(sa.lifetime)
+	sa.challenges[c] = exp
+	sa.queue = append(sa.queue, issued{challenge: c, expires: exp})
+	return c, nil
+}
+
+// Challenge is a http.HandlerFunc which responds with a new challenge as text/plain
+func (sa *Auther) Challenge(w http.ResponseWriter, r *http.Request) {
+	c, err := sa.NewChallenge()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, c)
+}
+
+// Check implements auth.Auther with the feed ID as user and the signed challenge as pass
+func (sa *Auther) Check(feedID, response string) (interface{}, error) {
+	pub, err := ParseFeedID(feedID)
+	if err != nil {
+		return nil, auth.ErrBadLogin
+	}
+
+	i := strings.LastIndex(response, ".")
+	if i < 0 {
+		return nil, auth.ErrBadLogin
+	}
+	challenge := response[:i]
+	sig, err := base64.StdEncoding.DecodeString(response[i+1:])
+	if err != nil {
+		return nil, auth.ErrBadLogin
+	}
+
+	if !ed25519.Verify(pub, []byte(SignPrefix+challenge), sig) {
+		return nil, auth.ErrBadLogin
+	}
+
+	if sa.Allowed != nil && !sa.Allowed(feedID) {
+		return nil, auth.ErrBadLogin
+	}
+
+	// only consume the challenge for valid signatures, so others can't burn it
+	sa.mu.Lock()
+	exp, has := sa.challenges[challenge]
+	delete(sa.challenges, challenge)
+	sa.mu.Unlock()
+	if !has || time.Now().After(exp) {
+		return nil, auth.ErrBadLogin
+	}
+
+	return feedID, nil
+}
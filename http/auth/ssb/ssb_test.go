@@ -0,0 +1,88 @@
+package ssb
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mindeco.de/http/auth"
+)
+
+func TestSignIn(t *testing.T) {
+	a := assert.New(t)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	a.NoError(err)
+	feed := FeedID(pub)
+
+	parsed, err := ParseFeedID(feed)
+	a.NoError(err)
+	a.Equal(pub, parsed)
+
+	sa := NewAuther(time.Minute)
+
+	rw := httptest.NewRecorder()
+	sa.Challenge(rw, httptest.NewRequest("GET", "/challenge", nil))
+	a.Equal(http.StatusOK, rw.Code)
+	challenge := rw.Body.String()
+
+	v, err := sa.Check(feed, Sign(priv, challenge))
+	a.NoError(err)
+	a.Equal(feed, v)
+
+	_, err = sa.Check(feed, Sign(priv, challenge))
+	a.Equal(auth.ErrBadLogin, err, "challenges are single use")
+
+	challenge, err = sa.NewChallenge()
+	a.NoError(err)
+
+	otherPub, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	a.NoError(err)
+	_, err = sa.Check(feed, Sign(otherPriv, challenge))
+	a.Equal(auth.ErrBadLogin, err, "wrong key")
+
+	_, err = sa.Check(feed, Sign(priv, "made-up"))
+	a.Equal(auth.ErrBadLogin, err, "unknown challenge")
+
+	sa.Allowed = func(id string) bool { return id == feed }
+	_, err = sa.Check(FeedID(otherPub), Sign(otherPriv, challenge))
+	a.Equal(auth.ErrBadLogin, err, "not allowed")
+
+	_, err = sa.Check(feed, Sign(priv, challenge))
+	a.NoError(err)
+
+	_, err = ParseFeedID("@foo.ed25519")
+	a.Equal(ErrInvalidFeed, err)
+}
+
+func TestMaxChallenges(t *testing.T) {
+	a := assert.New(t)
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	a.NoError(err)
+	feed := FeedID(priv.Public().(ed25519.PublicKey))
+
+	sa := NewAuther(time.Minute)
+	sa.MaxChallenges = 3
+
+	first, err := sa.NewChallenge()
+	a.NoError(err)
+	var last string
+	for i := 0; i < 10; i++ {
+		last, err = sa.NewChallenge()
+		a.NoError(err)
+	}
+	a.Len(sa.challenges, 3)
+	a.Len(sa.queue, 3)
+
+	_, err = sa.Check(feed, Sign(priv, first))
+	a.Equal(auth.ErrBadLogin, err, "the oldest challenges are dropped")
+
+	id, err := sa.Check(feed, Sign(priv, last))
+	a.NoError(err)
+	a.Equal(feed, id)
+}
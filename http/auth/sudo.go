@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ReturnToField is the form field ConfirmPassword reads the location to redirect to from
+const ReturnToField = "return-to"
+
+// RequireRecentAuth only calls next if the user entered the password in the last maxAge.
+// This is meant for dangerous operations like deleting an account or exporting keys.
+// Otherwise the handler set with SetReauthHandler is called, which should lead to ConfirmPassword.
+// Sessions created with SaveUserSession never count as recent, since no password was checked.
+func (ah Handler) RequireRecentAuth(maxAge time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := ah.AuthenticateRequest(r)
+		if err != nil {
+			ah.notAuthorizedHandler.ServeHTTP(w, r)
+			return
+		}
+
+		session, err := ah.getSession(r)
+		if err != nil {
+			ah.errorHandler(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		confirmed, ok := session.Values[userConfirmed].(time.Time)
+		if !ok || time.Since(confirmed) > maxAge {
+			ah.reauthHandler.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), user)))
+	})
+}
+
+// ConfirmPassword is a http.HandlerFunc for a POST request with the form field pass.
+// The password is checked with the Auther against the name the user logged in with.
+// On success the time is recorded for RequireRecentAuth and the client is redirected
+// to the local path in the ReturnToField or the landing location.
+func (ah Handler) ConfirmPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		ah.errorHandler(w, r, fmt.Errorf("method should be POST"), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := ah.AuthenticateRequest(r); err != nil {
+		ah.notAuthorizedHandler.ServeHTTP(w, r)
+		return
+	}
+
+	session, err := ah.getSession(r)
+	if err != nil {
+		ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	login, ok := session.Values[userLogin].(string)
+	if !ok {
+		ah.errorHandler(w, r, ErrReauthRequired, http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	pass := r.Form.Get("pass")
+	if pass == "" {
+		ah.errorHandler(w, r, ErrBadLogin, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := ah.check(r.Context(), "confirm", login, pass); err != nil {
+		ah.errorHandler(w, r, err, StatusOf(err))
+		return
+	}
+
+	session.Values[userConfirmed] = time.Now()
+	if err := session.Save(r, w); err != nil {
+		ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, ah.localRedirect(r.Form.Get(ReturnToField)), http.StatusSeeOther)
+}
+
+// localRedirect only accepts absolute paths on this host and falls back to the landing location
+func (ah Handler) localRedirect(to string) string {
+	if !IsLocalPath(to) {
+		return ah.redirLanding
+	}
+	return to
+}
+
+// IsLocalPath reports whether to is an absolute path on this host, which is safe to redirect to (like a return-to location).
+// Protocol-relative URLs (//evil.example), backslashes and control characters, which browsers strip or treat like slashes, are rejected.
+func IsLocalPath(to string) bool {
+	if !strings.HasPrefix(to, "/") || strings.HasPrefix(to, "//") {
+		return false
+	}
+	for _, c := range to {
+		if c == '\\' || c < 0x20 || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRecentAuth(t *testing.T) {
+	setup(t)
+	defer teardown()
+	a := assert.New(t)
+
+	const maxAge = 50 * time.Millisecond
+	testMux.Handle("/danger", testHandler.RequireRecentAuth(maxAge, http.HandlerFunc(restricted)))
+	testMux.HandleFunc("/confirm", testHandler.ConfirmPassword)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		if !(u == "testUser" && p == "testPassw") {
+			return nil, ErrBadLogin
+		}
+		return 23, nil
+	}
+
+	resp := testClient.GetBody(urlTo("/danger"))
+	a.Equal(http.StatusUnauthorized, resp.Code)
+
+	resp = testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.GetBody(urlTo("/danger"))
+	a.Equal(http.StatusOK, resp.Code, "login should count as recent")
+
+	time.Sleep(2 * maxAge)
+	resp = testClient.GetBody(urlTo("/danger"))
+	a.Equal(http.StatusForbidden, resp.Code)
+	a.Contains(resp.Body.String(), ErrReauthRequired.Error())
+
+	resp = testClient.PostForm(urlTo("/confirm"), url.Values{"pass": {"wrong"}})
+	a.Equal(http.StatusBadRequest, resp.Code)
+
+	resp = testClient.PostForm(urlTo("/confirm"), url.Values{"pass": {"testPassw"}, ReturnToField: {"//evil.example"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal("/landingRedir", resp.Header().Get("Location"))
+
+	resp = testClient.PostForm(urlTo("/confirm"), url.Values{"pass": {"testPassw"}, ReturnToField: {"/danger"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal("/danger", resp.Header().Get("Location"))
+	testClient.ClearHeaders()
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.GetBody(urlTo("/danger"))
+	a.Equal(http.StatusOK, resp.Code)
+}
+
+func TestIsLocalPath(t *testing.T) {
+	for _, ok := range []string{"/", "/posts/1", "/search?q=a%2Fb#top"} {
+		assert.True(t, IsLocalPath(ok), ok)
+	}
+	for _, bad := range []string{"", "posts", "https://evil.example", "//evil.example", "/\\evil.example", "/\t/evil.example", "/\n/evil.example"} {
+		assert.False(t, IsLocalPath(bad), bad)
+	}
+}
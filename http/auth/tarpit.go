@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mindeco.de/backoff"
+	"go.mindeco.de/http/clientip"
+)
+
+// Tarpit slows down repeated failed logins by delaying the next attempt.
+// Failures are counted per account and per client IP, the longer delay of the two is used.
+// The delay is waited out before the credentials are checked, so aborting a slow request doesn't help a guesser.
+// Unlike a lockout this doesn't keep the real user out, it just makes online guessing expensive.
+type Tarpit struct {
+	policy backoff.Backoff
+	window time.Duration
+
+	mu       sync.Mutex
+	failures map[string]*tarpitEntry
+	lastGC   time.Time
+
+	now   func() time.Time
+	sleep func(d time.Duration)
+}
+
+type tarpitEntry struct {
+	count int
+	last  time.Time // of the last failure, or the last reserved attempt
+}
+
+// NewTarpit returns a tarpit which uses policy to compute the delay after the n-th failure.
+// Failures are forgotten if there wasn't another one in window.
+// backoff.Default is a good policy to start with.
+func NewTarpit(policy backoff.Backoff, window time.Duration) *Tarpit {
+	return &Tarpit{
+		policy:   policy,
+		window:   window,
+		failures: make(map[string]*tarpitEntry),
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+// Delay returns how long the next attempt for this user and request would have to wait.
+func (tp *Tarpit) Delay(r *http.Request, user string) time.Duration {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	now := tp.now()
+	ready, _ := tp.ready(now, tarpitKeys(r, user))
+	return ready.Sub(now)
+}
+
+// ready returns when the next attempt for keys may be checked and the entries that delay it
+func (tp *Tarpit) ready(now time.Time, keys []string) (time.Time, []*tarpitEntry) {
+	ready := now
+	var entries []*tarpitEntry
+	for _, k := range keys {
+		e, has := tp.failures[k]
+		if !has || now.Sub(e.last) > tp.window {
+			continue
+		}
+		entries = append(entries, e)
+		if t := e.last.Add(tp.policy.Duration(e.count)); t.After(ready) {
+			ready = t
+		}
+	}
+	return ready, entries
+}
+
+// wait blocks until the delay of the earlier failures has passed. It has to be called before the credentials are checked.
+// The slot is reserved, so that parallel attempts queue up behind each other instead of passing together.
+// Canceling the request doesn't end the wait, the connection is held until the attempt would be allowed.
+func (tp *Tarpit) wait(r *http.Request, user string) {
+	tp.mu.Lock()
+	now := tp.now()
+	tp.gc(now)
+	ready, entries := tp.ready(now, tarpitKeys(r, user))
+	for _, e := range entries {
+		e.last = ready
+	}
+	tp.mu.Unlock()
+
+	if d := ready.Sub(now); d > 0 {
+		tp.sleep(d)
+	}
+}
+
+// fail records a failed attempt, which delays the next one
+func (tp *Tarpit) fail(r *http.Request, user string) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	now := tp.now()
+	for _, k := range tarpitKeys(r, user) {
+		e, has := tp.failures[k]
+		if !has || now.Sub(e.last) > tp.window {
+			e = &tarpitEntry{}
+			tp.failures[k] = e
+		}
+		e.count++
+		if now.After(e.last) {
+			e.last = now
+		}
+	}
+}
+
+// succeed forgets the failures of the account. Those of the IP are kept, so that one valid account doesn't reset guessing on others.
+func (tp *Tarpit) succeed(user string) {
+	tp.mu.Lock()
+	delete(tp.failures, "user:"+user)
+	tp.mu.Unlock()
+}
+
+// gc removes entries that are older then the window, at most once per window
+func (tp *Tarpit) gc(now time.Time) {
+	if now.Sub(tp.lastGC) < tp.window {
+		return
+	}
+	for k, e := range tp.failures {
+		if now.Sub(e.last) > tp.window {
+			delete(tp.failures, k)
+		}
+	}
+	tp.lastGC = now
+}
+
+func tarpitKeys(r *http.Request, user string) []string {
+	return []string{"user:" + user, "ip:" + clientip.FromRequest(r)}
+}
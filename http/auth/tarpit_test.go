@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// linear waits n seconds for the n-th failure
+type linear struct{}
+
+func (linear) Duration(n int) time.Duration { return time.Duration(n) * time.Second }
+
+func TestTarpit(t *testing.T) {
+	tp := NewTarpit(linear{}, time.Minute)
+	clock := time.Now()
+	tp.now = func() time.Time { return clock }
+	var slept []time.Duration
+	tp.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		clock = clock.Add(d)
+	}
+	testOptions = []Option{SetTarpit(tp)}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		if p != "testPassw" {
+			return nil, ErrBadLogin
+		}
+		return 23, nil
+	}
+
+	login := func(user, pass string) int {
+		return testClient.PostForm(urlTo("/login"), url.Values{"user": {user}, "pass": {pass}}).Code
+	}
+
+	a.Equal(http.StatusBadRequest, login("alice", "wrong"))
+	a.Equal(http.StatusBadRequest, login("alice", "wrong"))
+	a.Equal(http.StatusBadRequest, login("alice", "wrong"))
+	a.Equal([]time.Duration{time.Second, 2 * time.Second}, slept, "the first attempt isn't delayed")
+
+	// the ip has failed before, so bob gets the delay too
+	a.Equal(http.StatusBadRequest, login("bob", "wrong"))
+	a.Equal(3*time.Second, slept[2])
+
+	// the right password has to wait as well, otherwise the delay could be skipped by aborting slow attempts
+	a.Equal(http.StatusSeeOther, login("alice", "testPassw"))
+	a.Equal(4*time.Second, slept[3])
+
+	req, err := http.NewRequest("POST", "/login", nil)
+	a.NoError(err)
+	a.Equal(4*time.Second, tp.Delay(req, "alice"), "ip failures are kept, the account is reset")
+	a.Equal(4*time.Second, tp.Delay(req, "bob"))
+}
+
+func TestTarpitQueuesParallelAttempts(t *testing.T) {
+	a := assert.New(t)
+	tp := NewTarpit(linear{}, time.Minute)
+	clock := time.Now()
+	tp.now = func() time.Time { return clock }
+	var slept []time.Duration
+	tp.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	req, err := http.NewRequest("POST", "/login", nil)
+	a.NoError(err)
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	tp.fail(req, "alice")
+	tp.fail(req, "alice")
+
+	// the clock doesn't move, like attempts that are sent at the same time
+	tp.wait(req, "alice")
+	tp.wait(req, "alice")
+	tp.wait(req, "alice")
+	a.Equal([]time.Duration{2 * time.Second, 4 * time.Second, 6 * time.Second}, slept)
+}
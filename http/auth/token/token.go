@@ -0,0 +1,150 @@
+// Package token mints and verifies signed, expiring tokens which carry a small payload.
+//
+// They are meant for links that are send out of band, like email verification, unsubscribe or download URLs.
+// Tokens are not encrypted, the subject and purpose can be read by anyone who has the token.
+// They are also not single-use, anything that needs that has to keep track of used tokens,
+// like the password reset and email verification of auth do with their TokenStore.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errors to be checked against returned
+var (
+	ErrInvalid      = errors.New("token: invalid token")
+	ErrExpired      = errors.New("token: expired")
+	ErrWrongPurpose = errors.New("token: wrong purpose")
+)
+
+// Claims is the payload of a token
+type Claims struct {
+	Purpose string `json:"pur"`
+	Subject string `json:"sub"`
+	Expires int64  `json:"exp"` // unix seconds
+}
+
+// Signer mints tokens with the first of its keys and accepts tokens signed by any of them.
+type Signer struct {
+	keys [][]byte
+}
+
+// NewSigner returns a signer for the passed keys.
+// The first one is used for new tokens, the others only for verification which allows rotating them.
+// The keys are not used directly, so the same ones as for the session store can be passed.
+func NewSigner(keys ...[]byte) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("token: need at least one key")
+	}
+
+	var s Signer
+	for i, k := range keys {
+		if len(k) < 32 {
+			return nil, fmt.Errorf("token: key %d is too short", i)
+		}
+		s.keys = append(s.keys, deriveKey(k))
+	}
+	return &s, nil
+}
+
+// FromKeyPairs takes the same arguments as (securecookie).CodecsFromPairs (hashKey, blockKey, hashKey, blockKey, ...)
+// and uses the hash keys to create a Signer.
+func FromKeyPairs(keyPairs ...[]byte) (*Signer, error) {
+	var hashKeys [][]byte
+	for i := 0; i < len(keyPairs); i += 2 {
+		hashKeys = append(hashKeys, keyPairs[i])
+	}
+	return NewSigner(hashKeys...)
+}
+
+// deriveKey makes sure the tokens use a different key than the session cookies
+func deriveKey(k []byte) []byte {
+	mac := hmac.New(sha256.New, k)
+	mac.Write([]byte("go.mindeco.de/http/auth/token"))
+	return mac.Sum(nil)
+}
+
+// Mint returns a token for the subject (most likely a user ID) which is valid for lifetime.
+// The purpose prevents tokens from being used for something else, like an unsubscribe link for email verification.
+func (s Signer) Mint(purpose, subject string, lifetime time.Duration) (string, error) {
+	c := Claims{
+		Purpose: purpose,
+		Subject: subject,
+		Expires: time.Now().Add(lifetime).Unix(),
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("token: failed to encode claims: %w", err)
+	}
+
+	b64 := base64.RawURLEncoding
+	return b64.EncodeToString(payload) + "." + b64.EncodeToString(sign(s.keys[0], payload)), nil
+}
+
+// Verify checks the signature, expiry and purpose of the token and returns the subject.
+func (s Signer) Verify(purpose, token string) (string, error) {
+	c, err := s.Claims(token)
+	if err != nil {
+		return "", err
+	}
+
+	if c.Purpose != purpose {
+		return "", ErrWrongPurpose
+	}
+
+	if time.Now().Unix() > c.Expires {
+		return "", ErrExpired
+	}
+
+	return c.Subject, nil
+}
+
+// Claims returns the payload of token after checking the signature but without checking expiry or purpose.
+func (s Signer) Claims(token string) (Claims, error) {
+	var c Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return c, ErrInvalid
+	}
+
+	b64 := base64.RawURLEncoding
+	payload, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return c, ErrInvalid
+	}
+	sig, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return c, ErrInvalid
+	}
+
+	var valid bool
+	for _, k := range s.keys {
+		if hmac.Equal(sig, sign(k, payload)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return c, ErrInvalid
+	}
+
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return c, ErrInvalid
+	}
+	return c, nil
+}
+
+func sign(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,60 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMintAndVerify(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := NewSigner(securecookie.GenerateRandomKey(32))
+	a.NoError(err)
+
+	tok, err := s.Mint("verify-email", "user-23", time.Minute)
+	a.NoError(err)
+
+	sub, err := s.Verify("verify-email", tok)
+	a.NoError(err)
+	a.Equal("user-23", sub)
+
+	_, err = s.Verify("unsubscribe", tok)
+	a.Equal(ErrWrongPurpose, err)
+
+	_, err = s.Verify("verify-email", tok[:len(tok)-2])
+	a.Equal(ErrInvalid, err)
+
+	expired, err := s.Mint("verify-email", "user-23", -time.Minute)
+	a.NoError(err)
+	_, err = s.Verify("verify-email", expired)
+	a.Equal(ErrExpired, err)
+
+	other, err := NewSigner(securecookie.GenerateRandomKey(32))
+	a.NoError(err)
+	_, err = other.Verify("verify-email", tok)
+	a.Equal(ErrInvalid, err)
+}
+
+func TestRotation(t *testing.T) {
+	a := assert.New(t)
+
+	oldHash, oldBlock := securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32)
+	newHash, newBlock := securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32)
+
+	old, err := FromKeyPairs(oldHash, oldBlock)
+	a.NoError(err)
+	tok, err := old.Mint("download", "file-1", time.Minute)
+	a.NoError(err)
+
+	rotated, err := FromKeyPairs(newHash, newBlock, oldHash, oldBlock)
+	a.NoError(err)
+	sub, err := rotated.Verify("download", tok)
+	a.NoError(err)
+	a.Equal("file-1", sub)
+
+	_, err = NewSigner([]byte("short"))
+	a.Error(err)
+}
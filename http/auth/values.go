@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"encoding/gob"
+	"net/http"
+)
+
+// appKey is a separate type for keys set by the application so that they can't collide with our own sessionKeys
+type appKey string
+
+func init() {
+	gob.Register(appKey(""))
+}
+
+// SessionGet returns a value that was stored with SessionSet in the session of the request.
+// The second return value is false if there is none.
+func (ah Handler) SessionGet(r *http.Request, key string) (interface{}, bool, error) {
+	session, err := ah.getSession(r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	v, has := session.Values[appKey(key)]
+	return v, has, nil
+}
+
+// SessionSet stores a small value under key in the session of the request and saves it.
+// Custom types need to be registered with encoding/gob, like for gorilla/sessions.
+func (ah Handler) SessionSet(r *http.Request, w http.ResponseWriter, key string, value interface{}) error {
+	session, err := ah.getSession(r)
+	if err != nil {
+		return err
+	}
+
+	session.Values[appKey(key)] = value
+	return session.Save(r, w)
+}
+
+// SessionDelete removes the value under key from the session of the request and saves it.
+func (ah Handler) SessionDelete(r *http.Request, w http.ResponseWriter, key string) error {
+	session, err := ah.getSession(r)
+	if err != nil {
+		return err
+	}
+
+	delete(session.Values, appKey(key))
+	return session.Save(r, w)
+}
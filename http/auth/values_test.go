@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionValues(t *testing.T) {
+	setup(t)
+	defer teardown()
+	a := assert.New(t)
+
+	testMux.HandleFunc("/prefs/set", func(w http.ResponseWriter, r *http.Request) {
+		if err := testHandler.SessionSet(r, w, "theme", r.URL.Query().Get("theme")); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	testMux.HandleFunc("/prefs/get", func(w http.ResponseWriter, r *http.Request) {
+		v, has, err := testHandler.SessionGet(r, "theme")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, v, has)
+	})
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return 23, nil
+	}
+
+	resp := testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.GetBody(&url.URL{Path: "/prefs/set", RawQuery: "theme=dark"})
+	a.Equal(http.StatusOK, resp.Code)
+	testClient.ClearHeaders()
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+
+	resp = testClient.GetBody(urlTo("/prefs/get"))
+	a.Equal("dark true\n", resp.Body.String())
+
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusOK, resp.Code, "user data should be untouched")
+}
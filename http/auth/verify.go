@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mindeco.de/http/auth/token"
+)
+
+// VerificationStore keeps track of which accounts verified their email address
+type VerificationStore interface {
+	IsVerified(userID string) (bool, error)
+
+	// SetVerified is called once a valid verification token was consumed
+	SetVerified(userID string) error
+}
+
+// VerificationDeliverer sends the verification token to the user, most likely as a link in an email
+type VerificationDeliverer interface {
+	DeliverVerificationToken(r *http.Request, userID, token string) error
+}
+
+// EmailVerification issues and consumes email verification tokens.
+// Use it together with SetVerifiedOnly to refuse sessions of accounts that didn't verify their address.
+type EmailVerification struct {
+	ah *Handler
+
+	store   VerificationStore
+	tokens  TokenStore
+	deliver VerificationDeliverer
+
+	signer   *token.Signer
+	lifetime time.Duration
+
+	redirResent string // the url to redirect to after a new token was sent
+	redirDone   string // the url to redirect to after the address was verified
+}
+
+// VerifyOption is a function that changes an EmailVerification during initialization
+type VerifyOption func(ev *EmailVerification) error
+
+// SetVerifyLifetime sets how long verification tokens are valid (default 48 hours)
+func SetVerifyLifetime(d time.Duration) VerifyOption {
+	return func(ev *EmailVerification) error {
+		if d <= 0 {
+			return errors.New("verification lifetime needs to be positive")
+		}
+		ev.lifetime = d
+		return nil
+	}
+}
+
+// SetVerifyRedirects sets where to redirect after a token was resent and after the address was verified.
+// Both default to the landing location of the Handler.
+func SetVerifyRedirects(resent, done string) VerifyOption {
+	return func(ev *EmailVerification) error {
+		if resent == "" || done == "" {
+			return errors.New("verification redirects can't be empty")
+		}
+		ev.redirResent = resent
+		ev.redirDone = done
+		return nil
+	}
+}
+
+// NewEmailVerification creates the handlers for the verification flow.
+// The key is used to sign the tokens (with a token.Signer) and needs to be at least 32 bytes long.
+// The TokenStore can be shared with PasswordReset, the tokens of one can't be used for the other.
+func NewEmailVerification(ah *Handler, key []byte, store VerificationStore, tokens TokenStore, deliver VerificationDeliverer, opts ...VerifyOption) (*EmailVerification, error) {
+	if ah == nil {
+		return nil, errors.New("auth: verification needs a Handler")
+	}
+	if len(key) < 32 {
+		return nil, errors.New("auth: verification key too short")
+	}
+	if store == nil || tokens == nil || deliver == nil {
+		return nil, errors.New("auth: verification needs a verification store, token store and deliverer")
+	}
+
+	signer, err := token.NewSigner(key)
+	if err != nil {
+		return nil, fmt.Errorf("auth: verification key: %w", err)
+	}
+
+	ev := &EmailVerification{
+		ah:      ah,
+		signer:  signer,
+		store:   store,
+		tokens:  tokens,
+		deliver: deliver,
+	}
+
+	for _, o := range opts {
+		if err := o(ev); err != nil {
+			return nil, err
+		}
+	}
+
+	if ev.lifetime == 0 {
+		ev.lifetime = 48 * time.Hour
+	}
+
+	if ev.redirResent == "" {
+		ev.redirResent = ah.redirLanding
+	}
+
+	if ev.redirDone == "" {
+		ev.redirDone = ah.redirLanding
+	}
+
+	return ev, nil
+}
+
+// Issue creates a token for the user and hands it to the VerificationDeliverer.
+// It should be called by the signup handler once the account was created.
+func (ev EmailVerification) Issue(r *http.Request, userID string) error {
+	id, token, err := newSingleUseToken(ev.signer, purposeVerify, ev.lifetime)
+	if err != nil {
+		return err
+	}
+
+	if err := ev.tokens.Put(id, userID, time.Now().Add(ev.lifetime)); err != nil {
+		return fmt.Errorf("auth: failed to store verification token: %w", err)
+	}
+
+	return ev.deliver.DeliverVerificationToken(r, userID, token)
+}
+
+// Resend is a http.HandlerFunc for a POST request of a logged in (but not yet verified) user.
+// It issues a new token for that user.
+func (ev EmailVerification) Resend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		ev.ah.errorHandler(w, r, fmt.Errorf("method should be POST"), http.StatusBadRequest)
+		return
+	}
+
+	// not AuthenticateRequest, that refuses unverified accounts
+	user, _, err := ev.ah.authenticateSession(r)
+	if err != nil {
+		ev.ah.errorHandler(w, r, err, StatusOf(err))
+		return
+	}
+
+	userID := ev.ah.userID(user)
+	verified, err := ev.store.IsVerified(userID)
+	if err != nil {
+		ev.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if !verified {
+		if err := ev.Issue(r, userID); err != nil {
+			ev.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.Redirect(w, r, ev.redirResent, http.StatusSeeOther)
+}
+
+// Consume is a http.HandlerFunc for requests with the token as a form or query field.
+// GET is allowed since the token usually arrives as a link.
+// It marks the user of a valid token as verified.
+func (ev EmailVerification) Consume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		ev.ah.errorHandler(w, r, fmt.Errorf("method should be GET or POST"), http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		ev.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	token := r.Form.Get("token")
+	if token == "" {
+		ev.ah.errorHandler(w, r, ErrInvalidToken, http.StatusBadRequest)
+		return
+	}
+
+	id, err := verifySingleUseToken(ev.signer, purposeVerify, token)
+	if err != nil {
+		ev.ah.errorHandler(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	userID, err := ev.tokens.Pop(id)
+	if err != nil {
+		ev.ah.errorHandler(w, r, err, StatusOf(err))
+		return
+	}
+
+	if err := ev.store.SetVerified(userID); err != nil {
+		ev.ah.errorHandler(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, ev.redirDone, http.StatusSeeOther)
+}
+
+func (ah Handler) checkVerified(userData interface{}) error {
+	if ah.verified == nil {
+		return nil
+	}
+
+	ok, err := ah.verified.IsVerified(ah.userID(userData))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUnverified
+	}
+	return nil
+}
+
+// MemVerificationStore is an in-memory VerificationStore
+type MemVerificationStore struct {
+	mu       sync.Mutex
+	verified map[string]bool
+}
+
+// NewMemVerificationStore returns an empty MemVerificationStore
+func NewMemVerificationStore() *MemVerificationStore {
+	return &MemVerificationStore{verified: make(map[string]bool)}
+}
+
+// IsVerified implements VerificationStore
+func (ms *MemVerificationStore) IsVerified(userID string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.verified[userID], nil
+}
+
+// SetVerified implements VerificationStore
+func (ms *MemVerificationStore) SetVerified(userID string) error {
+	ms.mu.Lock()
+	ms.verified[userID] = true
+	ms.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.mindeco.de/http/auth/token"
+)
+
+type mockVerifyDeliverer struct {
+	tokens map[string]string
+}
+
+func (m mockVerifyDeliverer) DeliverVerificationToken(_ *http.Request, userID, token string) error {
+	m.tokens[userID] = token
+	return nil
+}
+
+func TestEmailVerification(t *testing.T) {
+	verified := NewMemVerificationStore()
+	testOptions = []Option{SetVerifiedOnly(verified)}
+	setup(t)
+	defer teardown()
+	defer func() { testOptions = nil }()
+	a := assert.New(t)
+
+	tokens := NewMemTokenStore()
+	deliver := mockVerifyDeliverer{tokens: make(map[string]string)}
+	key := make([]byte, 32)
+	signer, err := token.NewSigner(key)
+	a.NoError(err)
+
+	ev, err := NewEmailVerification(testHandler, key, verified, tokens, deliver, SetVerifyRedirects("/resent", "/verified"))
+	a.NoError(err)
+	testMux.HandleFunc("/verify/resend", ev.Resend)
+	testMux.HandleFunc("/verify", ev.Consume)
+
+	testAuthProvider.checkMock = func(u, p string) (interface{}, error) {
+		return u, nil
+	}
+
+	resp := testClient.PostForm(urlTo("/login"), url.Values{"user": {"testUser"}, "pass": {"testPassw"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	testClient.SetHeaders(http.Header{"Cookie": []string{resp.Header().Get("Set-Cookie")}})
+	defer testClient.ClearHeaders()
+
+	// not verified yet
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusUnauthorized, resp.Code)
+	a.Len(deliver.tokens, 0)
+
+	resp = testClient.PostForm(urlTo("/verify/resend"), url.Values{})
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal("/resent", resp.Header().Get("Location"))
+	token, has := deliver.tokens["testUser"]
+	a.True(has)
+
+	// tokens of other flows are rejected
+	resetID, resetToken, err := newSingleUseToken(signer, purposeReset, time.Minute)
+	a.NoError(err)
+	a.NoError(tokens.Put(resetID, "testUser", time.Now().Add(time.Minute)))
+	resp = testClient.GetBody(verifyURL(resetToken))
+	a.Equal(http.StatusBadRequest, resp.Code)
+
+	resp = testClient.GetBody(verifyURL(token))
+	a.Equal(http.StatusSeeOther, resp.Code)
+	a.Equal("/verified", resp.Header().Get("Location"))
+
+	ok, err := verified.IsVerified("testUser")
+	a.NoError(err)
+	a.True(ok)
+
+	// single use
+	resp = testClient.GetBody(verifyURL(token))
+	a.Equal(http.StatusBadRequest, resp.Code)
+
+	resp = testClient.GetBody(urlTo("/profile"))
+	a.Equal(http.StatusOK, resp.Code)
+}
+
+func verifyURL(token string) *url.URL {
+	return &url.URL{Path: "/verify", RawQuery: url.Values{"token": {token}}.Encode()}
+}
@@ -0,0 +1,134 @@
+// Package cachectl declares Cache-Control policies, so that handlers don't have to spell out the header by hand.
+//
+//	mux.Handle("/static/", cachectl.Public(365*24*time.Hour).WithImmutable().Wrap(staticFiles))
+//	mux.Handle("/account", cachectl.NoStore().Wrap(accountPage))
+//
+// Wrap sets the header before the handler runs, so a handler can still change it, for instance for errors.
+// The error handler of render already does that.
+package cachectl
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a set of Cache-Control directives. The zero value sends no header.
+type Policy struct {
+	Public         bool
+	Private        bool
+	NoCache        bool // caches have to revalidate before every use
+	NoStore        bool
+	MustRevalidate bool
+	Immutable      bool
+
+	MaxAge               time.Duration
+	SharedMaxAge         time.Duration // s-maxage, for proxies and CDNs
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+}
+
+// Public lets browsers and shared caches keep the response for maxAge
+func Public(maxAge time.Duration) Policy {
+	return Policy{Public: true, MaxAge: maxAge}
+}
+
+// Private only lets the browser keep the response, for pages with user data
+func Private(maxAge time.Duration) Policy {
+	return Policy{Private: true, MaxAge: maxAge}
+}
+
+// NoStore keeps the response out of all caches, for sensitive pages
+func NoStore() Policy {
+	return Policy{NoStore: true}
+}
+
+// Revalidate lets caches store the response but they have to check with an ETag or Last-Modified before using it
+func Revalidate() Policy {
+	return Policy{NoCache: true}
+}
+
+// StaleWhileRevalidate is Public(maxAge) where caches may serve a stale response for another stale while they fetch a new one
+func StaleWhileRevalidate(maxAge, stale time.Duration) Policy {
+	p := Public(maxAge)
+	p.StaleWhileRevalidate = stale
+	return p
+}
+
+// WithImmutable returns a copy of p which tells browsers that the response never changes (for fingerprinted assets)
+func (p Policy) WithImmutable() Policy {
+	p.Immutable = true
+	return p
+}
+
+// WithShared returns a copy of p with a different max age for shared caches
+func (p Policy) WithShared(maxAge time.Duration) Policy {
+	p.SharedMaxAge = maxAge
+	return p
+}
+
+// WithStaleIfError returns a copy of p which lets caches serve a stale response for d if the origin fails
+func (p Policy) WithStaleIfError(d time.Duration) Policy {
+	p.StaleIfError = d
+	return p
+}
+
+// String returns the value of the Cache-Control header
+func (p Policy) String() string {
+	var d []string
+	if p.NoStore {
+		// nothing else matters then
+		return "no-store"
+	}
+	if p.Public {
+		d = append(d, "public")
+	}
+	if p.Private {
+		d = append(d, "private")
+	}
+	if p.NoCache {
+		d = append(d, "no-cache")
+	}
+	if p.MaxAge > 0 || p.Public || p.Private {
+		d = append(d, "max-age="+seconds(p.MaxAge))
+	}
+	if p.SharedMaxAge > 0 {
+		d = append(d, "s-maxage="+seconds(p.SharedMaxAge))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		d = append(d, "stale-while-revalidate="+seconds(p.StaleWhileRevalidate))
+	}
+	if p.StaleIfError > 0 {
+		d = append(d, "stale-if-error="+seconds(p.StaleIfError))
+	}
+	if p.MustRevalidate {
+		d = append(d, "must-revalidate")
+	}
+	if p.Immutable {
+		d = append(d, "immutable")
+	}
+	return strings.Join(d, ", ")
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// Set sets the Cache-Control header of w to p
+func (p Policy) Set(w http.ResponseWriter) {
+	if v := p.String(); v != "" {
+		w.Header().Set("Cache-Control", v)
+	}
+}
+
+// Wrap returns a handler which sets p before calling next. As a method value (policy.Wrap) it fits as a middleware.
+func (p Policy) Wrap(next http.Handler) http.Handler {
+	v := p.String()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v != "" {
+			w.Header().Set("Cache-Control", v)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,48 @@
+package cachectl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyString(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("public, max-age=3600", Public(time.Hour).String())
+	a.Equal("private, max-age=0", Private(0).String())
+	a.Equal("no-store", NoStore().String())
+	a.Equal("no-store", Policy{NoStore: true, Public: true, MaxAge: time.Hour}.String())
+	a.Equal("no-cache", Revalidate().String())
+	a.Equal("public, max-age=60, stale-while-revalidate=600", StaleWhileRevalidate(time.Minute, 10*time.Minute).String())
+	a.Equal("public, max-age=31536000, immutable", Public(365*24*time.Hour).WithImmutable().String())
+	a.Equal("public, max-age=60, s-maxage=3600, stale-if-error=86400", Public(time.Minute).WithShared(time.Hour).WithStaleIfError(24*time.Hour).String())
+	a.Equal("", Policy{}.String())
+}
+
+func TestWrap(t *testing.T) {
+	a := assert.New(t)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	fails := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	var mw func(http.Handler) http.Handler = NoStore().Wrap
+
+	rw := httptest.NewRecorder()
+	mw(ok).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal("no-store", rw.Header().Get("Cache-Control"))
+
+	rw = httptest.NewRecorder()
+	Public(time.Hour).Wrap(fails).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal("no-cache", rw.Header().Get("Cache-Control"), "handlers can override the policy")
+
+	rw = httptest.NewRecorder()
+	Policy{}.Wrap(ok).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Empty(rw.Header().Values("Cache-Control"))
+}
@@ -0,0 +1,160 @@
+// Package clientip resolves the address of the client behind trusted reverse proxies
+// and keeps it in the request context, so that rate limits, session bindings and logs agree on it.
+//
+// It is its own package so that auth and middleware can both use it.
+package clientip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver finds the client address of requests, using the forwarding header only if it was set by a trusted proxy
+type Resolver struct {
+	nets   []*net.IPNet
+	header string
+}
+
+// Option changes how a Resolver works
+type Option func(*Resolver)
+
+// SetHeader names the forwarding header the trusted proxies set, like X-Real-Ip or Forwarded. The default is X-Forwarded-For.
+// Only this header is read, the others are passed through from the client unchanged by most proxies.
+func SetHeader(name string) Option {
+	return func(rs *Resolver) {
+		rs.header = http.CanonicalHeaderKey(name)
+	}
+}
+
+// NewResolver parses the CIDRs of the trusted proxies, like 10.0.0.0/8 or ::1/128.
+// Without any, Resolve always returns the address of the peer.
+func NewResolver(trustedProxies []string, opts ...Option) (*Resolver, error) {
+	rs := Resolver{header: "X-Forwarded-For"}
+	for _, cidr := range trustedProxies {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("clientip: invalid trusted proxy %q: %w", cidr, err)
+		}
+		rs.nets = append(rs.nets, n)
+	}
+	for _, o := range opts {
+		o(&rs)
+	}
+	if rs.header == "" {
+		return nil, errors.New("clientip: empty forwarding header")
+	}
+	return &rs, nil
+}
+
+func (rs *Resolver) trusted(ip net.IP) bool {
+	for _, n := range rs.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Trusted reports whether the peer of the request is a trusted proxy, so that its forwarding headers (like X-Forwarded-Proto) can be believed
+func (rs *Resolver) Trusted(r *http.Request) bool {
+	ip := net.ParseIP(remoteHost(r))
+	return ip != nil && rs.trusted(ip)
+}
+
+// Resolve returns the client address of the request.
+// If the peer is a trusted proxy, the hops of the forwarding header (see SetHeader) are walked
+// from the right and the first address that isn't a trusted proxy is returned.
+func (rs *Resolver) Resolve(r *http.Request) string {
+	peer := remoteHost(r)
+	if !rs.Trusted(r) {
+		return peer
+	}
+
+	var hops []string
+	if rs.header == "Forwarded" {
+		hops = forwardedFor(r.Header.Values("Forwarded"))
+	} else {
+		hops = splitList(r.Header.Values(rs.header))
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(stripPort(hops[i]))
+		if hop == nil {
+			// garbage (or an obfuscated identifier) from the other side of the last trusted proxy
+			break
+		}
+		client = hop.String()
+		if !rs.trusted(hop) {
+			break
+		}
+	}
+	return client
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx with the client address
+func NewContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, ip)
+}
+
+// FromContext returns the client address stored in ctx
+func FromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(ctxKey{}).(string)
+	return ip, ok
+}
+
+// FromRequest returns the client address of the context or, if none was resolved, the host of the remote address
+func FromRequest(r *http.Request) string {
+	if ip, ok := FromContext(r.Context()); ok {
+		return ip
+	}
+	return remoteHost(r)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// forwardedFor returns the for= values of RFC 7239 Forwarded headers
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, elem := range splitList(values) {
+		for _, pair := range strings.Split(elem, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(k, "for") {
+				hops = append(hops, strings.Trim(v, `"`))
+			}
+		}
+	}
+	return hops
+}
+
+func splitList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+	}
+	return list
+}
+
+// stripPort removes the port and the brackets of IPv6 addresses, like in "[2001:db8::1]:4711"
+func stripPort(hop string) string {
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]")
+}
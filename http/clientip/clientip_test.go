@@ -0,0 +1,72 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	proxies := []string{"10.0.0.0/8", "::1/128"}
+	rs, err := NewResolver(proxies)
+	require.NoError(t, err)
+	realIP, err := NewResolver(proxies, SetHeader("x-real-ip"))
+	require.NoError(t, err)
+	forwarded, err := NewResolver(proxies, SetHeader("Forwarded"))
+	require.NoError(t, err)
+
+	tcases := []struct {
+		name   string
+		rs     *Resolver
+		remote string
+		header map[string]string
+		want   string
+	}{
+		{"direct", rs, "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"untrusted peer", rs, "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "192.0.2.1"},
+		{"xff", rs, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "198.51.100.7"},
+		{"xff chain", rs, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.7, 10.1.2.3"}, "198.51.100.7"},
+		{"xff all trusted", rs, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.9.9.9"}, "10.9.9.9"},
+		{"xff garbage", rs, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "nope, 10.1.2.3"}, "10.1.2.3"},
+		{"real ip", realIP, "[::1]:1234", map[string]string{"X-Real-Ip": "198.51.100.7"}, "198.51.100.7"},
+		{"forwarded", forwarded, "10.0.0.1:1234", map[string]string{
+			"Forwarded":       `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`,
+			"X-Forwarded-For": "198.51.100.7",
+		}, "2001:db8:cafe::17"},
+		{"forwarded obfuscated", forwarded, "10.0.0.1:1234", map[string]string{"Forwarded": "for=_hidden"}, "10.0.0.1"},
+		{"spoofed forwarded", rs, "10.0.0.1:1234", map[string]string{
+			"Forwarded":       "for=1.2.3.4",
+			"X-Forwarded-For": "198.51.100.7",
+		}, "198.51.100.7"},
+		{"spoofed forwarded without xff", rs, "10.0.0.1:1234", map[string]string{"Forwarded": "for=1.2.3.4"}, "10.0.0.1"},
+		{"spoofed real ip", rs, "10.0.0.1:1234", map[string]string{"X-Real-Ip": "1.2.3.4"}, "10.0.0.1"},
+		{"spoofed xff", forwarded, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "10.0.0.1"},
+	}
+	for _, tc := range tcases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remote
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+		assert.Equal(t, tc.want, tc.rs.Resolve(req), tc.name)
+	}
+
+	_, err = NewResolver([]string{"10.0.0.1"})
+	assert.Error(t, err)
+
+	_, err = NewResolver(proxies, SetHeader(""))
+	assert.Error(t, err)
+}
+
+func TestFromRequest(t *testing.T) {
+	a := assert.New(t)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	a.Equal("192.0.2.1", FromRequest(req))
+
+	req = req.WithContext(NewContext(req.Context(), "198.51.100.7"))
+	a.Equal("198.51.100.7", FromRequest(req))
+}
@@ -0,0 +1,281 @@
+// Package csrf protects forms and API calls against cross-site request forgery.
+//
+// The tokens are checked against a secret that is kept in the auth session of logged in users (synchronizer tokens).
+// Visitors without a session, like on the login form, get the secret in a signed cookie instead (double-submit).
+// The tokens in the pages are masked differently on every request, so they don't leak the secret through compression (BREACH).
+//
+//	protect, err := csrf.New(key, csrf.SetSessionStore(ah))
+//	logging.CheckFatal(err)
+//	r, err := render.New(fs, render.InjectTemplateFunc("csrfField", csrf.TemplateField))
+//	router.Use(ah.Authenticate, protect.Middleware) // after Authenticate, to use the session of logged in users
+//
+// And in the forms:
+//
+//	<form method="post">{{csrfField}} ...</form>
+//
+// Scripts send the token in the X-CSRF-Token header, for instance from a meta tag with csrf.TemplateToken.
+// In tests, tester.AutoCSRF(csrf.FieldName, csrf.HeaderName) does the same.
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/securecookie"
+
+	"go.mindeco.de/http/auth"
+	"go.mindeco.de/http/render"
+)
+
+const (
+	// FieldName is the name of the form field with the token
+	FieldName = "csrf_token"
+
+	// HeaderName is the header that scripts send the token in
+	HeaderName = "X-CSRF-Token"
+
+	secretLen = 32
+	// the key of the secret in the auth session
+	sessionKey = "csrf-secret"
+)
+
+var (
+	// ErrNoToken means the request didn't have a token
+	ErrNoToken = errors.New("csrf: the request has no token")
+
+	// ErrBadToken means the token doesn't match the secret, for instance because the session changed
+	ErrBadToken = errors.New("csrf: the token is invalid")
+)
+
+// SessionStore keeps values in the session of logged in users. auth.Handler implements it.
+type SessionStore interface {
+	SessionGet(r *http.Request, key string) (interface{}, bool, error)
+	SessionSet(r *http.Request, w http.ResponseWriter, key string, value interface{}) error
+}
+
+// Protector checks the tokens, see New
+type Protector struct {
+	cookie *securecookie.SecureCookie
+
+	cookieName   string
+	cookieSecure bool
+	sessions     SessionStore
+	skip         func(*http.Request) bool
+	errHandler   render.ErrorHandlerFunc
+}
+
+// Option is a function that changes a Protector during initialization
+type Option func(*Protector) error
+
+// SetSessionStore keeps the secret in the session of logged in users (those with auth.FromContext), instead of the cookie
+func SetSessionStore(s SessionStore) Option {
+	return func(p *Protector) error {
+		if s == nil {
+			return errors.New("csrf: nil SessionStore passed")
+		}
+		p.sessions = s
+		return nil
+	}
+}
+
+// SetCookieName sets the name of the fallback cookie, "csrf" by default
+func SetCookieName(name string) Option {
+	return func(p *Protector) error {
+		if name == "" {
+			return errors.New("csrf: cookie name can't be empty")
+		}
+		p.cookieName = name
+		return nil
+	}
+}
+
+// SetCookieSecure sets the Secure flag of the fallback cookie, which should be used for sites that are only served with HTTPS
+func SetCookieSecure(secure bool) Option {
+	return func(p *Protector) error {
+		p.cookieSecure = secure
+		return nil
+	}
+}
+
+// Skip excludes requests from the check, for instance webhooks or API calls with bearer tokens
+func Skip(fn func(*http.Request) bool) Option {
+	return func(p *Protector) error {
+		p.skip = fn
+		return nil
+	}
+}
+
+// SetErrorHandler replaces the default handler for rejected requests, which answers with http.Error and 403
+func SetErrorHandler(fn render.ErrorHandlerFunc) Option {
+	return func(p *Protector) error {
+		if fn == nil {
+			return errors.New("csrf: nil ErrorHandler passed")
+		}
+		p.errHandler = fn
+		return nil
+	}
+}
+
+// New returns a Protector which signs the fallback cookie with hashKey (32 or 64 random bytes)
+func New(hashKey []byte, opts ...Option) (*Protector, error) {
+	if len(hashKey) < 32 {
+		return nil, errors.New("csrf: the hash key needs to be at least 32 bytes")
+	}
+	p := &Protector{
+		cookie:     securecookie.New(hashKey, nil),
+		cookieName: "csrf",
+		errHandler: func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			http.Error(w, err.Error(), status)
+		},
+	}
+	p.cookie.MaxAge(0)
+	for _, o := range opts {
+		if err := o(p); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+type ctxKey struct{}
+
+// Middleware makes sure the visitor has a secret and checks the token of requests with unsafe methods (anything but GET, HEAD, OPTIONS and TRACE).
+func (p *Protector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Cookie")
+
+		secret, err := p.secret(w, r)
+		if err != nil {
+			p.errHandler(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !isSafe(r.Method) && (p.skip == nil || !p.skip(r)) {
+			token := r.Header.Get(HeaderName)
+			if token == "" {
+				token = r.PostFormValue(FieldName)
+			}
+			if token == "" {
+				p.errHandler(w, r, http.StatusForbidden, ErrNoToken)
+				return
+			}
+			if !valid(token, secret) {
+				p.errHandler(w, r, http.StatusForbidden, ErrBadToken)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, secret)))
+	})
+}
+
+// secret returns the secret of the session or the cookie, and creates one if there is none
+func (p *Protector) secret(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	if _, loggedIn := auth.FromContext(r.Context()); loggedIn && p.sessions != nil {
+		v, has, err := p.sessions.SessionGet(r, sessionKey)
+		if err != nil {
+			return nil, fmt.Errorf("csrf: failed to get the session: %w", err)
+		}
+		if s, ok := v.([]byte); has && ok && len(s) == secretLen {
+			return s, nil
+		}
+		s, err := newSecret()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.sessions.SessionSet(r, w, sessionKey, s); err != nil {
+			return nil, fmt.Errorf("csrf: failed to save the session: %w", err)
+		}
+		return s, nil
+	}
+
+	if c, err := r.Cookie(p.cookieName); err == nil {
+		var s []byte
+		if err := p.cookie.Decode(p.cookieName, c.Value, &s); err == nil && len(s) == secretLen {
+			return s, nil
+		}
+	}
+	s, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := p.cookie.Encode(p.cookieName, s)
+	if err != nil {
+		return nil, fmt.Errorf("csrf: failed to encode the cookie: %w", err)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     p.cookieName,
+		Value:    encoded,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   p.cookieSecure,
+		SameSite: http.SameSiteLaxMode,
+		Expires:  time.Now().Add(365 * 24 * time.Hour),
+	})
+	return s, nil
+}
+
+// Token returns a token for the request, masked differently on every call. It is empty if the request didn't pass through the Middleware.
+func Token(r *http.Request) string {
+	secret, ok := r.Context().Value(ctxKey{}).([]byte)
+	if !ok {
+		return ""
+	}
+	pad := make([]byte, secretLen)
+	if _, err := rand.Read(pad); err != nil {
+		panic(fmt.Errorf("csrf: failed to read random bytes: %w", err))
+	}
+	masked := make([]byte, 2*secretLen)
+	copy(masked, pad)
+	for i := range secret {
+		masked[secretLen+i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// TemplateToken is a render.FuncInjector that returns the Token, for meta tags and scripts
+var TemplateToken render.FuncInjector = func(r *http.Request) interface{} {
+	return func() string { return Token(r) }
+}
+
+// TemplateField is a render.FuncInjector that returns a hidden input with the Token, for forms
+var TemplateField render.FuncInjector = func(r *http.Request) interface{} {
+	return func() template.HTML {
+		return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, FieldName, Token(r)))
+	}
+}
+
+func valid(token string, secret []byte) bool {
+	masked, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(masked) != 2*secretLen {
+		return false
+	}
+	unmasked := make([]byte, secretLen)
+	for i := range unmasked {
+		unmasked[i] = masked[i] ^ masked[secretLen+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, secret) == 1
+}
+
+func newSecret() ([]byte, error) {
+	s := make([]byte, secretLen)
+	if _, err := rand.Read(s); err != nil {
+		return nil, fmt.Errorf("csrf: failed to read random bytes: %w", err)
+	}
+	return s, nil
+}
+
+func isSafe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
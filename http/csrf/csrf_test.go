@@ -0,0 +1,110 @@
+package csrf
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mindeco.de/http/auth"
+	"go.mindeco.de/http/tester"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestMux(p *Protector) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/form", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		field := TemplateField(r).(func() template.HTML)
+		fmt.Fprintf(w, `<form method="post">%s</form>`, field())
+	})
+	mux.HandleFunc("/submit", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	return p.Middleware(mux)
+}
+
+func TestCookieFallback(t *testing.T) {
+	a := assert.New(t)
+
+	p, err := New(testKey)
+	require.NoError(t, err)
+	tc := tester.New(newTestMux(p), t)
+
+	resp := tc.PostForm(&url.URL{Path: "/submit"}, nil)
+	a.Equal(http.StatusForbidden, resp.Code)
+	a.Contains(resp.Body.String(), ErrNoToken.Error())
+
+	page := &url.URL{Path: "/form"}
+	doc, resp := tc.GetHTML(page)
+	a.Equal(http.StatusOK, resp.Code)
+	token, _ := doc.Find("input[name=" + FieldName + "]").Attr("value")
+	a.NotEmpty(token)
+
+	resp = tc.PostForm(&url.URL{Path: "/submit"}, url.Values{FieldName: {token}})
+	a.Equal(http.StatusOK, resp.Code)
+
+	again, _ := tc.GetHTML(page)
+	other, _ := again.Find("input[name=" + FieldName + "]").Attr("value")
+	a.NotEqual(token, other, "masked differently every time")
+
+	resp = tc.PostForm(&url.URL{Path: "/submit"}, url.Values{FieldName: {other}})
+	a.Equal(http.StatusOK, resp.Code, "any of them works")
+
+	tc.ClearCookies()
+	resp = tc.PostForm(&url.URL{Path: "/submit"}, url.Values{FieldName: {token}})
+	a.Equal(http.StatusForbidden, resp.Code, "bound to the cookie")
+	a.Contains(resp.Body.String(), ErrBadToken.Error())
+}
+
+type fakeSessions map[string]interface{}
+
+func (fs fakeSessions) SessionGet(_ *http.Request, key string) (interface{}, bool, error) {
+	v, ok := fs[key]
+	return v, ok, nil
+}
+
+func (fs fakeSessions) SessionSet(_ *http.Request, _ http.ResponseWriter, key string, value interface{}) error {
+	fs[key] = value
+	return nil
+}
+
+func TestSessionAndTester(t *testing.T) {
+	a := assert.New(t)
+
+	sessions := make(fakeSessions)
+	p, err := New(testKey, SetSessionStore(sessions), Skip(func(r *http.Request) bool {
+		return r.URL.Path == "/hook"
+	}))
+	require.NoError(t, err)
+
+	loggedIn := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), "alice")))
+		})
+	}
+	tc := tester.New(loggedIn(newTestMux(p)), t, tester.AutoCSRF(FieldName, HeaderName))
+
+	tc.GetHTML(&url.URL{Path: "/form"})
+	a.Len(sessions[sessionKey], secretLen, "kept in the session")
+	a.Empty(tc.Cookies(&url.URL{Path: "/"}), "no fallback cookie")
+	a.NotEmpty(tc.CSRFToken())
+
+	resp := tc.SendJSON(&url.URL{Path: "/submit"}, map[string]string{"hello": "world"})
+	a.Equal(http.StatusOK, resp.Code, "the header is sent automatically")
+
+	resp = tc.PostForm(&url.URL{Path: "/submit"}, nil, tester.WithHeader(HeaderName, "nope"))
+	a.Equal(http.StatusForbidden, resp.Code)
+
+	sessions[sessionKey] = make([]byte, secretLen) // like a new session
+	resp = tc.PostForm(&url.URL{Path: "/submit"}, nil)
+	a.Equal(http.StatusForbidden, resp.Code, "bound to the session")
+
+	resp = tc.PostForm(&url.URL{Path: "/hook"}, nil, tester.WithoutHeader(HeaderName))
+	a.Equal(http.StatusNotFound, resp.Code, "skipped, so it reaches the mux")
+}
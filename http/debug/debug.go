@@ -0,0 +1,102 @@
+// Package debug serves net/http/pprof and expvar behind authentication, so they can be used in production.
+//
+//	dbg, err := debug.Handler("/-/debug", ah.BasicAuth, debug.AllowUser(isAdmin))
+//	logging.CheckFatal(err)
+//	mux.Handle("/-/debug/", dbg)
+//
+// The profiles are then under /-/debug/pprof/ (for go tool pprof) and the variables under /-/debug/vars.
+//
+// Importing net/http/pprof and expvar registers them on http.DefaultServeMux without any protection,
+// which is why DefaultServeMux must not be served by applications that use this package.
+package debug
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"go.mindeco.de/http/auth"
+)
+
+// Option is a function that changes the Handler during initialization
+type Option func(*handler)
+
+// AllowUser restricts the endpoints to the users for which fn returns true, with the data from auth.FromContext.
+// Without it every authenticated user may use them.
+func AllowUser(fn func(user interface{}) bool) Option {
+	return func(h *handler) {
+		h.allow = fn
+	}
+}
+
+type handler struct {
+	prefix string
+	allow  func(user interface{}) bool
+	mux    *http.ServeMux
+}
+
+// Handler returns the debug endpoints for prefix, wrapped by protect (like auth.Handler.Authenticate or BasicAuth).
+// Requests that don't have a user in their context after protect ran are refused,
+// so a middleware that doesn't authenticate can't open them by accident.
+func Handler(prefix string, protect func(http.Handler) http.Handler, opts ...Option) (http.Handler, error) {
+	if protect == nil {
+		return nil, errors.New("debug: refusing to serve without authentication")
+	}
+
+	h := &handler{
+		prefix: strings.TrimSuffix(prefix, "/"),
+		mux:    http.NewServeMux(),
+	}
+	for _, o := range opts {
+		o(h)
+	}
+
+	// pprof.Index expects its own path, the links on its page are relative
+	h.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	h.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	h.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	h.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	h.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	h.mux.Handle("/debug/vars", expvar.Handler())
+	h.mux.HandleFunc("/debug/", h.index)
+
+	return protect(h), nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok || (h.allow != nil && !h.allow(user)) {
+		http.Error(w, "debug: "+http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, h.prefix)
+	if len(rest) == len(r.URL.Path) && h.prefix != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if rest == "" || rest == "/pprof" {
+		// the links are relative and the inner mux doesn't know the prefix for its redirect
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = "/debug/" + strings.TrimPrefix(rest, "/")
+	r2.URL.RawPath = ""
+	h.mux.ServeHTTP(w, r2)
+}
+
+func (h *handler) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/debug/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, `<!DOCTYPE html><title>debug</title><ul><li><a href="pprof/">pprof</a></li><li><a href="vars">vars</a></li></ul>`)
+}
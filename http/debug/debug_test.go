@@ -0,0 +1,64 @@
+package debug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mindeco.de/http/auth"
+)
+
+func fakeAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name := r.Header.Get("X-User"); name != "" {
+			r = r.WithContext(auth.NewContext(r.Context(), name))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := Handler("/-/debug", nil)
+	a.Error(err)
+
+	h, err := Handler("/-/debug/", fakeAuth, AllowUser(func(u interface{}) bool { return u == "admin" }))
+	require.NoError(t, err)
+
+	get := func(path, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	a.Equal(http.StatusForbidden, get("/-/debug/vars", "").Code, "not authenticated")
+	a.Equal(http.StatusForbidden, get("/-/debug/vars", "bob").Code, "not allowed")
+
+	rec := get("/-/debug/vars", "admin")
+	a.Equal(http.StatusOK, rec.Code)
+	a.Contains(rec.Body.String(), `"memstats"`)
+
+	rec = get("/-/debug/pprof/", "admin")
+	a.Equal(http.StatusOK, rec.Code)
+	a.Contains(rec.Body.String(), "goroutine?debug=1", "relative links")
+
+	rec = get("/-/debug/pprof/goroutine?debug=1", "admin")
+	a.Equal(http.StatusOK, rec.Code)
+	a.Contains(rec.Body.String(), "goroutine profile")
+
+	rec = get("/-/debug/pprof", "admin")
+	a.Equal(http.StatusMovedPermanently, rec.Code)
+	a.Equal("/-/debug/pprof/", rec.Header().Get("Location"))
+
+	a.Equal(http.StatusOK, get("/-/debug/", "admin").Code)
+	a.Equal(http.StatusNotFound, get("/-/debug/nope", "admin").Code)
+	a.Equal(http.StatusNotFound, get("/elsewhere", "admin").Code)
+}
@@ -0,0 +1,268 @@
+// Package flash shows one-shot notices (like "your post was saved") on the page after a redirect.
+//
+// The messages are kept in a session of their own, so any sessions.Store works: the one of auth,
+// or a sessions.NewCookieStore with its own key to keep them in a signed cookie.
+//
+//	fl, err := flash.New(sessions.NewCookieStore(key))
+//	r, err := render.New(fs, render.InjectTemplateFunc("flashes", flash.TemplateFunc))
+//	router.Use(fl.Middleware)
+//
+//	func savePost(w http.ResponseWriter, r *http.Request) {
+//		// ...
+//		flash.Add(w, r, flash.Success, "Your post was saved")
+//		http.Redirect(w, r, "/posts", http.StatusSeeOther)
+//	}
+//
+// And in the base template:
+//
+//	{{range flashes}}<div class="flash {{.Level}}">{{.Text}}</div>{{end}}
+package flash
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/sessions"
+
+	"go.mindeco.de/http/render"
+)
+
+func init() {
+	gob.Register([]Message{})
+}
+
+// Level says what kind of message it is. Its String is meant as a CSS class.
+type Level int
+
+// The levels of messages
+const (
+	Info Level = iota
+	Success
+	Warning
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Success:
+		return "success"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "info"
+}
+
+// Message is one notice
+type Message struct {
+	Level Level
+	Text  string
+}
+
+// ErrNoMiddleware is returned by Add if the request didn't pass through Middleware
+var ErrNoMiddleware = errors.New("flash: the request didn't pass through the middleware")
+
+// ErrResponseStarted is returned by Add if the headers were already sent, so the messages can't be saved anymore
+var ErrResponseStarted = errors.New("flash: the response was already started")
+
+const valuesKey = "messages"
+
+// Flasher loads and saves the messages, see New
+type Flasher struct {
+	store sessions.Store
+	name  string
+}
+
+// Option is a function that changes a Flasher during initialization
+type Option func(*Flasher) error
+
+// SetSessionName sets the name of the session (the cookie name, for most stores), "flash" by default
+func SetSessionName(name string) Option {
+	return func(f *Flasher) error {
+		if name == "" {
+			return errors.New("flash: session name can't be empty")
+		}
+		f.name = name
+		return nil
+	}
+}
+
+// New returns a Flasher that keeps the messages in store
+func New(store sessions.Store, opts ...Option) (*Flasher, error) {
+	if store == nil {
+		return nil, errors.New("flash: sessions.Store can't be nil")
+	}
+	f := &Flasher{store: store, name: "flash"}
+	for _, o := range opts {
+		if err := o(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+type stateKey struct{}
+
+type state struct {
+	f *Flasher
+	r *http.Request
+
+	mu      sync.Mutex
+	session *sessions.Session
+	maxAge  int       // of the session options, to restore it after a deletion
+	loaded  []Message // from the previous request, until Pop
+	added   []Message // for the next request
+	popped  bool
+	saved   bool // since the last change
+	started bool
+}
+
+// Middleware loads the messages of the request, so that Pop and Add work for the handlers after it.
+// Once Pop was called, the messages are removed from the session when the response is started.
+func (f *Flasher) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// New instead of Get, the state is kept by this package and not in the registry of gorilla/sessions
+		session, err := f.store.New(r, f.name)
+		if err != nil {
+			// broken or outdated cookies are replaced on the next save
+			session = sessions.NewSession(f.store, f.name)
+		}
+
+		if session.Options == nil {
+			session.Options = &sessions.Options{Path: "/"}
+		}
+		st := &state{f: f, r: r, session: session, maxAge: session.Options.MaxAge, saved: true}
+		st.loaded, _ = session.Values[valuesKey].([]Message)
+
+		fw := &flashWriter{ResponseWriter: w, st: st}
+		next.ServeHTTP(fw, r.WithContext(context.WithValue(r.Context(), stateKey{}, st)))
+	})
+}
+
+// Add saves a message for the next request. It has to be called before anything is written to w, like for a redirect.
+func Add(w http.ResponseWriter, r *http.Request, lvl Level, text string) error {
+	st, ok := r.Context().Value(stateKey{}).(*state)
+	if !ok {
+		return ErrNoMiddleware
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.started {
+		return ErrResponseStarted
+	}
+	st.added = append(st.added, Message{Level: lvl, Text: text})
+	st.saved = false
+	return st.save(w)
+}
+
+// Pop returns the messages of the previous requests and removes them, so they are only shown once.
+// Messages added during this request are not returned, they are for the next one.
+// It has to be called before the response is started, which is the case in templates since render buffers their output.
+func Pop(r *http.Request) []Message {
+	st, ok := r.Context().Value(stateKey{}).(*state)
+	if !ok {
+		return nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	msgs := st.loaded
+	if len(msgs) > 0 {
+		st.loaded = nil
+		st.popped = true
+		st.saved = false
+	}
+	return msgs
+}
+
+// TemplateFunc is a render.FuncInjector for Pop, see the package example
+var TemplateFunc render.FuncInjector = func(r *http.Request) interface{} {
+	return func() []Message { return Pop(r) }
+}
+
+// save writes the session, replacing the cookie an earlier save of this response set
+func (st *state) save(w http.ResponseWriter) error {
+	msgs := append(append([]Message(nil), st.loaded...), st.added...)
+	if len(msgs) == 0 {
+		delete(st.session.Values, valuesKey)
+		st.session.Options.MaxAge = -1
+	} else {
+		st.session.Values[valuesKey] = msgs
+		st.session.Options.MaxAge = st.maxAge
+	}
+
+	h := w.Header()
+	cookies := h["Set-Cookie"][:0]
+	for _, c := range h["Set-Cookie"] {
+		if !strings.HasPrefix(c, st.f.name+"=") {
+			cookies = append(cookies, c)
+		}
+	}
+	if len(cookies) == 0 {
+		h.Del("Set-Cookie")
+	} else {
+		h["Set-Cookie"] = cookies
+	}
+
+	if err := st.session.Save(st.r, w); err != nil {
+		return err
+	}
+	st.saved = true
+	return nil
+}
+
+// start saves the removal of popped messages before the headers are sent
+func (st *state) start(w http.ResponseWriter) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.started {
+		return
+	}
+	st.started = true
+	if !st.saved && st.popped {
+		st.save(w)
+	}
+}
+
+type flashWriter struct {
+	http.ResponseWriter
+	st *state
+}
+
+func (fw *flashWriter) WriteHeader(code int) {
+	fw.st.start(fw.ResponseWriter)
+	fw.ResponseWriter.WriteHeader(code)
+}
+
+func (fw *flashWriter) Write(b []byte) (int, error) {
+	fw.st.start(fw.ResponseWriter)
+	return fw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, for streaming responses
+func (fw *flashWriter) Flush() {
+	if f, ok := fw.ResponseWriter.(http.Flusher); ok {
+		fw.st.start(fw.ResponseWriter)
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, for websockets
+func (fw *flashWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := fw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("flash: the ResponseWriter doesn't support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap is used by http.ResponseController
+func (fw *flashWriter) Unwrap() http.ResponseWriter {
+	return fw.ResponseWriter
+}
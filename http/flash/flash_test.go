@@ -0,0 +1,64 @@
+package flash
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectAndShow(t *testing.T) {
+	a := assert.New(t)
+
+	fl, err := New(sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef")))
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/save", func(w http.ResponseWriter, r *http.Request) {
+		a.NoError(Add(w, r, Success, "saved"))
+		a.NoError(Add(w, r, Warning, "but slowly"))
+		http.Redirect(w, r, "/posts", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/posts", func(w http.ResponseWriter, r *http.Request) {
+		msgs := Pop(r) // before the response is started, like in a template
+		w.WriteHeader(http.StatusOK)
+		for _, m := range msgs {
+			fmt.Fprintf(w, "%s:%s;", m.Level, m.Text)
+		}
+		a.Equal(ErrResponseStarted, Add(w, r, Info, "too late"))
+	})
+	h := fl.Middleware(mux)
+
+	do := func(path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/save", nil)
+	a.Equal(http.StatusSeeOther, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1, "saved once, even with two messages")
+
+	rec = do("/posts", cookies)
+	a.Equal("success:saved;warning:but slowly;", rec.Body.String())
+	cleared := rec.Result().Cookies()
+	require.Len(t, cleared, 1)
+	a.True(cleared[0].MaxAge < 0, "removed after showing them")
+
+	rec = do("/posts", nil)
+	a.Equal("", rec.Body.String())
+	a.Empty(rec.Result().Cookies(), "nothing to clear")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	a.Equal(ErrNoMiddleware, Add(httptest.NewRecorder(), req, Info, "hi"))
+	a.Nil(Pop(req))
+}
@@ -0,0 +1,318 @@
+// Package forms decodes submitted forms into structs and collects the errors per field, to show them next to the inputs.
+//
+//	type postForm struct {
+//		Title   string                `form:"title"`
+//		Tags    []string              `form:"tags"`
+//		Publish time.Time             `form:"publish"` // from <input type="date"> or datetime-local
+//		Expires encodedTime.Millisecs `form:"expires"` // any encoding.TextUnmarshaler works
+//		Draft   bool                  `form:"draft"`
+//		Cover   *multipart.FileHeader `form:"cover"`
+//	}
+//
+//	var pf postForm
+//	err := forms.DecodeRequest(r, &pf)
+//	var errs forms.Errors
+//	if errors.As(err, &errs) {
+//		// render the form again, with {{.Errors.First "publish"}} next to the input
+//	}
+//
+// Fields without a tag use their name, fields tagged with "-" are skipped and embedded structs are flattened.
+// Fields that weren't submitted are left unchanged, so defaults can be set before decoding.
+// The exception are bools, which are false without a value like unchecked checkboxes.
+package forms
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mindeco.de/encodedTime"
+)
+
+// DefaultMaxMemory is how much of a multipart form DecodeRequest keeps in memory, the rest goes to temporary files
+const DefaultMaxMemory = 10 << 20
+
+// Errors are the messages for the fields that couldn't be decoded, by their form name.
+// The validation package adds its messages to the same type, so templates only deal with one.
+type Errors map[string][]string
+
+// Add appends a message for field
+func (e Errors) Add(field, msg string) {
+	e[field] = append(e[field], msg)
+}
+
+// Merge adds the messages of other
+func (e Errors) Merge(other Errors) {
+	for field, msgs := range other {
+		e[field] = append(e[field], msgs...)
+	}
+}
+
+// Any reports whether there are messages for any field
+func (e Errors) Any() bool {
+	return len(e) > 0
+}
+
+// Has reports whether there are messages for field
+func (e Errors) Has(field string) bool {
+	return len(e[field]) > 0
+}
+
+// First returns the first message for field, or an empty string
+func (e Errors) First(field string) string {
+	if msgs := e[field]; len(msgs) > 0 {
+		return msgs[0]
+	}
+	return ""
+}
+
+// Of returns the messages for field
+func (e Errors) Of(field string) []string {
+	return e[field]
+}
+
+// Err returns the Errors if there are any and nil otherwise, to return them as an error
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for f := range e {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f + ": " + strings.Join(e[f], ", ")
+	}
+	return "forms: " + strings.Join(parts, "; ")
+}
+
+// Decode sets the fields of the struct dst points to from vals.
+// It returns Errors if some of the values couldn't be converted, the other fields are still set then.
+func Decode(vals url.Values, dst interface{}) error {
+	return decode(vals, nil, dst)
+}
+
+// DecodeRequest parses the form of r (the query and urlencoded or multipart bodies) and decodes it like Decode.
+// Uploaded files are set on fields of type *multipart.FileHeader or []*multipart.FileHeader.
+func DecodeRequest(r *http.Request, dst interface{}) error {
+	ct := r.Header.Get("Content-Type")
+	if strings.HasPrefix(ct, "multipart/form-data") {
+		if err := r.ParseMultipartForm(DefaultMaxMemory); err != nil {
+			return fmt.Errorf("forms: failed to parse the multipart form: %w", err)
+		}
+	} else if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("forms: failed to parse the form: %w", err)
+	}
+
+	var files map[string][]*multipart.FileHeader
+	if r.MultipartForm != nil {
+		files = r.MultipartForm.File
+	}
+	return decode(r.Form, files, dst)
+}
+
+func decode(vals url.Values, files map[string][]*multipart.FileHeader, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("forms: need a pointer to a struct, not %T", dst)
+	}
+
+	errs := make(Errors)
+	if err := decodeStruct(v.Elem(), vals, files, errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+	unmarshalType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func decodeStruct(v reflect.Value, vals url.Values, files map[string][]*multipart.FileHeader, errs Errors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+
+		name, hasTag := sf.Tag.Lookup("form")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct {
+			if err := decodeStruct(fv, vals, files, errs); err != nil {
+				return err
+			}
+			continue
+		}
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		switch {
+		case sf.Type == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case sf.Type.Kind() == reflect.Slice && sf.Type.Elem() == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+
+		raws, sent := vals[name]
+		if !sent || len(raws) == 0 {
+			if fv.Kind() == reflect.Bool {
+				fv.SetBool(false)
+			}
+			continue
+		}
+
+		if fv.Kind() == reflect.Slice && !fv.Type().Implements(unmarshalType) && !reflect.PtrTo(fv.Type()).Implements(unmarshalType) {
+			slice := reflect.MakeSlice(fv.Type(), 0, len(raws))
+			for _, raw := range raws {
+				if raw == "" {
+					continue
+				}
+				ev := reflect.New(fv.Type().Elem()).Elem()
+				msg, err := setValue(ev, raw)
+				if err != nil {
+					return fmt.Errorf("forms: field %s: %w", sf.Name, err)
+				}
+				if msg != "" {
+					errs.Add(name, msg)
+					continue
+				}
+				slice = reflect.Append(slice, ev)
+			}
+			fv.Set(slice)
+			continue
+		}
+
+		raw := strings.TrimSpace(raws[0])
+		if raw == "" && fv.Kind() != reflect.String {
+			if fv.Kind() == reflect.Bool {
+				fv.SetBool(false)
+			}
+			continue
+		}
+		msg, err := setValue(fv, raws[0])
+		if err != nil {
+			return fmt.Errorf("forms: field %s: %w", sf.Name, err)
+		}
+		if msg != "" {
+			errs.Add(name, msg)
+		}
+	}
+	return nil
+}
+
+// the layouts of <input type="date">, datetime-local and time, tried before encodedTime.Flexible
+var timeLayouts = []string{"2006-01-02", "2006-01-02T15:04", "2006-01-02T15:04:05", "15:04"}
+
+// setValue converts raw for v. It returns a message for the user if raw is invalid
+// and an error if the type of v isn't supported, which is a mistake in the struct.
+func setValue(v reflect.Value, raw string) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if strings.TrimSpace(raw) == "" {
+			return "", nil
+		}
+		pv := reflect.New(v.Type().Elem())
+		msg, err := setValue(pv.Elem(), raw)
+		if err == nil && msg == "" {
+			v.Set(pv)
+		}
+		return msg, err
+	}
+
+	if v.Type() == timeType {
+		raw = strings.TrimSpace(raw)
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return "", nil
+			}
+		}
+		var ft encodedTime.Flexible
+		if err := ft.UnmarshalText([]byte(raw)); err != nil {
+			return "must be a date", nil
+		}
+		v.Set(reflect.ValueOf(time.Time(ft)))
+		return "", nil
+	}
+
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := u.UnmarshalText([]byte(strings.TrimSpace(raw))); err != nil {
+				return "is invalid", nil
+			}
+			return "", nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		switch strings.ToLower(strings.TrimSpace(raw)) {
+		case "on", "yes", "1", "true":
+			v.SetBool(true)
+		case "off", "no", "0", "false":
+			v.SetBool(false)
+		default:
+			return "must be yes or no", nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, v.Type().Bits())
+		if err != nil {
+			return rangeOr(err, "must be a whole number"), nil
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(raw), 10, v.Type().Bits())
+		if err != nil {
+			return rangeOr(err, "must be a positive whole number"), nil
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), v.Type().Bits())
+		if err != nil {
+			return rangeOr(err, "must be a number"), nil
+		}
+		v.SetFloat(f)
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return "", nil
+}
+
+func rangeOr(err error, msg string) string {
+	if errors.Is(err, strconv.ErrRange) {
+		return "is out of range"
+	}
+	return msg
+}
@@ -0,0 +1,127 @@
+package forms
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mindeco.de/encodedTime"
+)
+
+type meta struct {
+	Lang string `form:"lang"`
+}
+
+type post struct {
+	meta
+
+	Title   string                `form:"title"`
+	Tags    []string              `form:"tags"`
+	Stars   int                   `form:"stars"`
+	Ratio   *float64              `form:"ratio"`
+	IDs     []uint                `form:"ids"`
+	Publish time.Time             `form:"publish"`
+	At      time.Time             `form:"at"`
+	Expires encodedTime.Millisecs `form:"expires"`
+	Draft   bool                  `form:"draft"`
+	Secret  string                `form:"-"`
+	Name    string
+	Cover   *multipart.FileHeader `form:"cover"`
+}
+
+func TestDecode(t *testing.T) {
+	a := assert.New(t)
+
+	p := post{Stars: 3, Draft: true, Secret: "keep"}
+	err := Decode(url.Values{
+		"lang":    {"de"},
+		"title":   {" Hello "},
+		"tags":    {"a", "", "b"},
+		"ratio":   {"0.5"},
+		"ids":     {"1", "2"},
+		"publish": {"2023-02-01"},
+		"at":      {"1700000000"},
+		"expires": {"1700000000000"},
+		"Name":    {"untagged"},
+		"Secret":  {"nope"},
+	}, &p)
+	require.NoError(t, err)
+
+	a.Equal("de", p.Lang, "embedded")
+	a.Equal(" Hello ", p.Title)
+	a.Equal([]string{"a", "b"}, p.Tags)
+	a.Equal(3, p.Stars, "unchanged without a value")
+	a.Equal(0.5, *p.Ratio)
+	a.Equal([]uint{1, 2}, p.IDs)
+	a.Equal(time.Date(2023, 2, 1, 0, 0, 0, 0, time.Local), p.Publish)
+	a.Equal(int64(1700000000), p.At.Unix(), "epoch seconds via encodedTime.Flexible")
+	a.Equal(int64(1700000000), time.Time(p.Expires).Unix())
+	a.False(p.Draft, "unchecked")
+	a.Equal("keep", p.Secret)
+	a.Equal("untagged", p.Name)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	a := assert.New(t)
+
+	var p post
+	err := Decode(url.Values{
+		"title":   {"still set"},
+		"stars":   {"many"},
+		"ids":     {"1", "-2"},
+		"publish": {"yesterday"},
+		"draft":   {"maybe"},
+		"ratio":   {"x"},
+	}, &p)
+
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	a.Equal("must be a whole number", errs.First("stars"))
+	a.Equal("must be a positive whole number", errs.First("ids"))
+	a.Equal("must be a date", errs.First("publish"))
+	a.Equal("must be yes or no", errs.First("draft"))
+	a.True(errs.Has("ratio"))
+	a.False(errs.Has("title"))
+	a.Equal("", errs.First("title"))
+	a.Equal("still set", p.Title)
+	a.Nil(p.Ratio)
+	a.Equal([]uint{1}, p.IDs)
+	a.Equal("forms: draft: must be yes or no; ids: must be a positive whole number; publish: must be a date; ratio: must be a number; stars: must be a whole number", err.Error())
+
+	a.Error(Decode(url.Values{}, p), "not a pointer")
+	var unsupported struct {
+		Ch chan int `form:"ch"`
+	}
+	err = Decode(url.Values{"ch": {"1"}}, &unsupported)
+	a.EqualError(err, "forms: field Ch: unsupported type chan int")
+}
+
+func TestDecodeRequest(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("title", "with a cover")
+	fw, err := mw.CreateFormFile("cover", "cat.jpg")
+	require.NoError(t, err)
+	fw.Write([]byte("meow"))
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest("POST", "/posts?lang=en", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var p post
+	require.NoError(t, DecodeRequest(req, &p))
+	a.Equal("with a cover", p.Title)
+	a.Equal("en", p.Lang, "the query is included")
+	require.NotNil(t, p.Cover)
+	a.Equal("cat.jpg", p.Cover.Filename)
+	a.Equal(int64(4), p.Cover.Size)
+}
@@ -0,0 +1,190 @@
+// Package healthcheck answers the liveness and readiness probes of orchestrators like Kubernetes or Nomad.
+//
+//	hc := healthcheck.New()
+//	hc.AddReadiness("db", db.PingContext)
+//	hc.AddReadiness("templates", func(context.Context) error { return renderer.ParseError() })
+//	mux.Handle("/healthz", hc.Liveness())
+//	mux.Handle("/readyz", hc.Readiness())
+//
+// Liveness checks should only fail if the process needs to be restarted, since that is what the orchestrator does then.
+// Things the service needs for serving, like the database, are readiness checks: while they fail, it gets no traffic.
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check returns an error if the thing it checks isn't usable. It should return once ctx is done.
+type Check func(ctx context.Context) error
+
+// ErrDraining is what Readiness reports after Drain was called
+var ErrDraining = errors.New("healthcheck: the service is shutting down")
+
+// DefaultTimeout is how long the checks may take together, if SetTimeout isn't used
+const DefaultTimeout = 5 * time.Second
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker holds the registered checks, see New
+type Checker struct {
+	timeout  time.Duration
+	draining int32
+
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+}
+
+// Option is a function that changes a Checker during initialization
+type Option func(*Checker)
+
+// SetTimeout sets how long the checks of one probe may take, DefaultTimeout otherwise
+func SetTimeout(d time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = d
+	}
+}
+
+// New returns a Checker without checks, where both probes succeed
+func New(opts ...Option) *Checker {
+	c := &Checker{timeout: DefaultTimeout}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// AddLiveness adds a check for the Liveness handler
+func (c *Checker) AddLiveness(name string, check Check) {
+	c.mu.Lock()
+	c.liveness = append(c.liveness, namedCheck{name: name, check: check})
+	c.mu.Unlock()
+}
+
+// AddReadiness adds a check for the Readiness handler
+func (c *Checker) AddReadiness(name string, check Check) {
+	c.mu.Lock()
+	c.readiness = append(c.readiness, namedCheck{name: name, check: check})
+	c.mu.Unlock()
+}
+
+// Drain makes the readiness probe fail, so that the orchestrator stops sending traffic before the server shuts down.
+// It fits as the first thing that is done after the stop signal, before the grace period of httpserver starts.
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+// Liveness returns the handler for /healthz
+func (c *Checker) Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.RLock()
+		checks := c.liveness
+		c.mu.RUnlock()
+		c.serve(w, r, checks, nil)
+	})
+}
+
+// Readiness returns the handler for /readyz
+func (c *Checker) Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.RLock()
+		checks := c.readiness
+		c.mu.RUnlock()
+
+		var overall error
+		if atomic.LoadInt32(&c.draining) == 1 {
+			overall = ErrDraining
+		}
+		c.serve(w, r, checks, overall)
+	})
+}
+
+// Report is the JSON body of both handlers
+type Report struct {
+	Status string                 `json:"status"` // ok or fail
+	Error  string                 `json:"error,omitempty"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Took   string `json:"took"`
+}
+
+// run runs the checks concurrently and returns their results. ok is false if one of them failed.
+func (c *Checker) run(ctx context.Context, checks []namedCheck) (map[string]CheckResult, bool) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(checks))
+		ok      = true
+	)
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			start := time.Now()
+
+			errc := make(chan error, 1)
+			go func() { errc <- nc.check(ctx) }()
+
+			var err error
+			select {
+			case err = <-errc:
+			case <-ctx.Done():
+				err = ctx.Err() // don't wait for checks that ignore the context
+			}
+
+			res := CheckResult{Status: "ok", Took: time.Since(start).String()}
+			if err != nil {
+				res.Status, res.Error = "fail", err.Error()
+			}
+			mu.Lock()
+			results[nc.name] = res
+			if err != nil {
+				ok = false
+			}
+			mu.Unlock()
+		}(nc)
+	}
+	wg.Wait()
+	return results, ok
+}
+
+func (c *Checker) serve(w http.ResponseWriter, r *http.Request, checks []namedCheck, overall error) {
+	results, ok := c.run(r.Context(), checks)
+
+	rep := Report{Status: "ok", Checks: results}
+	status := http.StatusOK
+	if !ok || overall != nil {
+		rep.Status = "fail"
+		status = http.StatusServiceUnavailable
+	}
+	if overall != nil {
+		rep.Error = overall.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(rep)
+}
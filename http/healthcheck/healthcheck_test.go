@@ -0,0 +1,62 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler) (int, Report) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	var rep Report
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&rep))
+	return rec.Code, rep
+}
+
+func TestProbes(t *testing.T) {
+	a := assert.New(t)
+
+	hc := New(SetTimeout(20 * time.Millisecond))
+	code, rep := probe(t, hc.Liveness())
+	a.Equal(http.StatusOK, code, "no checks are fine")
+	a.Equal("ok", rep.Status)
+
+	var dbErr error
+	hc.AddReadiness("db", func(context.Context) error { return dbErr })
+	hc.AddReadiness("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	code, rep = probe(t, hc.Readiness())
+	a.Equal(http.StatusServiceUnavailable, code)
+	a.Equal("fail", rep.Status)
+	a.Equal("ok", rep.Checks["db"].Status)
+	a.Equal("context deadline exceeded", rep.Checks["stuck"].Error)
+
+	hc = New()
+	hc.AddReadiness("db", func(context.Context) error { return dbErr })
+	code, _ = probe(t, hc.Readiness())
+	a.Equal(http.StatusOK, code)
+
+	dbErr = errors.New("connection refused")
+	code, rep = probe(t, hc.Readiness())
+	a.Equal(http.StatusServiceUnavailable, code)
+	a.Equal(CheckResult{Status: "fail", Error: "connection refused", Took: rep.Checks["db"].Took}, rep.Checks["db"])
+
+	dbErr = nil
+	hc.Drain()
+	code, rep = probe(t, hc.Readiness())
+	a.Equal(http.StatusServiceUnavailable, code)
+	a.Equal(ErrDraining.Error(), rep.Error)
+	code, _ = probe(t, hc.Liveness())
+	a.Equal(http.StatusOK, code, "still alive while draining")
+}
@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// AutoTLS configures certificates from Let's Encrypt (or another ACME CA), for deployments without a reverse proxy
+type AutoTLS struct {
+	// Hosts are the names certificates are requested for, others are refused
+	Hosts []string
+
+	// CacheDir keeps the account key and the certificates across restarts, so the rate limits of the CA aren't hit
+	CacheDir string
+
+	// Email is passed to the CA, for notices about expiring certificates
+	Email string
+
+	// HTTPAddr serves the HTTP-01 challenges and redirects everything else to https (default :80).
+	// Set it to "-" if the TLS-ALPN-01 challenge on the main address is enough.
+	HTTPAddr string
+}
+
+// SetAutoTLS serves https on the address of the Server, with certificates that are requested and renewed by autocert
+func SetAutoTLS(cfg AutoTLS) Option {
+	return func(s *Server) error {
+		if len(cfg.Hosts) == 0 {
+			return errors.New("httpserver: auto TLS needs at least one host")
+		}
+		if cfg.CacheDir == "" {
+			return errors.New("httpserver: auto TLS needs a cache directory")
+		}
+
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Hosts...),
+			Cache:      autocert.DirCache(cfg.CacheDir),
+			Email:      cfg.Email,
+		}
+		s.srv.TLSConfig = m.TLSConfig()
+		s.tls = true
+
+		switch cfg.HTTPAddr {
+		case "-":
+			s.challenge = nil
+		case "":
+			cfg.HTTPAddr = ":80"
+			fallthrough
+		default:
+			s.challenge = &http.Server{
+				Addr:              cfg.HTTPAddr,
+				Handler:           m.HTTPHandler(nil),
+				ReadHeaderTimeout: s.srv.ReadHeaderTimeout,
+				IdleTimeout:       s.srv.IdleTimeout,
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoTLS(t *testing.T) {
+	a := assert.New(t)
+
+	srv, err := New(":443", http.NotFoundHandler(), SetAutoTLS(AutoTLS{
+		Hosts:    []string{"example.com"},
+		CacheDir: t.TempDir(),
+	}))
+	require.NoError(t, err)
+
+	cfg := srv.HTTP().TLSConfig
+	require.NotNil(t, cfg)
+	a.Contains(cfg.NextProtos, "acme-tls/1")
+
+	_, err = cfg.GetCertificate(&tls.ClientHelloInfo{ServerName: "evil.example"})
+	a.Error(err, "hosts that aren't listed are refused")
+
+	require.NotNil(t, srv.challenge)
+	a.Equal(":80", srv.challenge.Addr)
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "http://example.com/posts?page=2", nil)
+	srv.challenge.Handler.ServeHTTP(rw, req)
+	a.Equal(http.StatusFound, rw.Code)
+	a.Equal("https://example.com/posts?page=2", rw.Header().Get("Location"))
+}
+
+func TestAutoTLSOptions(t *testing.T) {
+	a := assert.New(t)
+
+	srv, err := New(":443", http.NotFoundHandler(), SetAutoTLS(AutoTLS{Hosts: []string{"example.com"}, CacheDir: t.TempDir(), HTTPAddr: "-"}))
+	require.NoError(t, err)
+	a.Nil(srv.challenge)
+
+	_, err = New(":443", http.NotFoundHandler(), SetAutoTLS(AutoTLS{CacheDir: t.TempDir()}))
+	a.Error(err)
+	_, err = New(":443", http.NotFoundHandler(), SetAutoTLS(AutoTLS{Hosts: []string{"example.com"}}))
+	a.Error(err)
+}
@@ -0,0 +1,258 @@
+// Package httpserver runs a http.Server with sane timeouts until the process is told to stop
+// and then drains its connections, which is what the main() of every service used to do by hand.
+//
+//	srv, err := httpserver.New(":8080", handler, httpserver.OnShutdown(db.Close))
+//	logging.CheckFatal(err)
+//	logging.CheckFatal(srv.Run(context.Background()))
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+// Timeouts are copied to the http.Server, see its documentation for what they mean
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// DefaultTimeouts protect against slow clients but leave enough room for uploads and slow pages
+var DefaultTimeouts = Timeouts{
+	ReadHeader: 10 * time.Second,
+	Read:       30 * time.Second,
+	Write:      60 * time.Second,
+	Idle:       120 * time.Second,
+}
+
+// ShutdownHook is called after the server stopped, with a context that ends with the grace period
+type ShutdownHook func(ctx context.Context) error
+
+// Server wraps a http.Server, see New and Run
+type Server struct {
+	srv *http.Server
+
+	listener     net.Listener
+	socketName   string
+	noActivation bool
+	unixPath     string // see SetUnixSocket
+	unixMode     os.FileMode
+
+	tls       bool
+	challenge *http.Server // for ACME HTTP-01, see SetAutoTLS
+
+	grace   time.Duration
+	signals []os.Signal
+	hooks   []ShutdownHook
+	log     kitlog.Logger
+}
+
+// Option is a function that changes a Server during initialization
+type Option func(s *Server) error
+
+// SetTimeouts replaces DefaultTimeouts
+func SetTimeouts(t Timeouts) Option {
+	return func(s *Server) error {
+		s.srv.ReadHeaderTimeout = t.ReadHeader
+		s.srv.ReadTimeout = t.Read
+		s.srv.WriteTimeout = t.Write
+		s.srv.IdleTimeout = t.Idle
+		return nil
+	}
+}
+
+// SetGracePeriod sets how long open requests have to finish once the server stops (default 30 seconds)
+func SetGracePeriod(d time.Duration) Option {
+	return func(s *Server) error {
+		if d <= 0 {
+			return errors.New("httpserver: grace period needs to be positive")
+		}
+		s.grace = d
+		return nil
+	}
+}
+
+// SetSignals sets the signals that stop the server, SIGINT and SIGTERM by default.
+// Without any, only the context of Run stops it, like when a lifecycle.Supervisor handles the signals.
+func SetSignals(sigs ...os.Signal) Option {
+	return func(s *Server) error {
+		s.signals = sigs
+		return nil
+	}
+}
+
+// SetLogger sets the logger for startup and shutdown messages and the ErrorLog of the http.Server
+func SetLogger(l kitlog.Logger) Option {
+	return func(s *Server) error {
+		if l == nil {
+			return errors.New("httpserver: nil logger passed")
+		}
+		s.log = l
+		return nil
+	}
+}
+
+// OnShutdown adds a hook that is called once the connections are drained, like closing the database.
+// Hooks are called in reverse order of registration, like deferred calls.
+func OnShutdown(fn ShutdownHook) Option {
+	return func(s *Server) error {
+		if fn == nil {
+			return errors.New("httpserver: nil shutdown hook passed")
+		}
+		s.hooks = append(s.hooks, fn)
+		return nil
+	}
+}
+
+// New returns a Server for h on addr (like :8080). Nothing is bound until Listen or Run are called.
+func New(addr string, h http.Handler, opts ...Option) (*Server, error) {
+	s := &Server{
+		srv:     &http.Server{Addr: addr, Handler: h},
+		grace:   30 * time.Second,
+		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+		log:     logging.Logger("httpserver"),
+	}
+	SetTimeouts(DefaultTimeouts)(s)
+
+	for _, o := range opts {
+		if err := o(s); err != nil {
+			return nil, err
+		}
+	}
+
+	s.srv.ErrorLog = stdlog.New(kitlog.NewStdlibAdapter(s.log), "", 0)
+	return s, nil
+}
+
+// HTTP returns the wrapped http.Server, for settings that don't have an Option
+func (s *Server) HTTP() *http.Server {
+	return s.srv
+}
+
+// Listen binds the address, so that Addr can be used before Run (for instance with :0 in tests).
+// If the process was started by systemd socket activation (LISTEN_FDS), the passed socket is used instead,
+// which allows restarts without refusing connections.
+func (s *Server) Listen() error {
+	if s.listener != nil {
+		return nil
+	}
+
+	if !s.noActivation {
+		l, err := systemdListener(s.socketName)
+		if err != nil {
+			return err
+		}
+		if l != nil {
+			s.listener = l
+			return nil
+		}
+	}
+
+	if s.unixPath != "" {
+		l, err := listenUnix(s.unixPath, s.unixMode)
+		if err != nil {
+			return err
+		}
+		s.listener = l
+		return nil
+	}
+
+	l, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("httpserver: failed to listen: %w", err)
+	}
+	s.listener = l
+	return nil
+}
+
+// Addr returns the address the server listens on or nil before Listen
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Run listens (if Listen wasn't called already) and serves until ctx is done or one of the signals arrives.
+// Then it stops accepting connections, waits for open requests for up to the grace period and calls the shutdown hooks.
+// It returns the first error of serving, draining or the hooks.
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.Listen(); err != nil {
+		return err
+	}
+
+	if len(s.signals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, s.signals...)
+		defer stop()
+	}
+
+	served := make(chan error, 2)
+	if s.challenge != nil {
+		l, err := net.Listen("tcp", s.challenge.Addr)
+		if err != nil {
+			return fmt.Errorf("httpserver: failed to listen for ACME challenges: %w", err)
+		}
+		s.challenge.ErrorLog = s.srv.ErrorLog
+		go func() {
+			served <- s.challenge.Serve(l)
+		}()
+		s.log.Log("event", "listening", "addr", l.Addr(), "for", "acme-challenges")
+	}
+
+	go func() {
+		if s.tls {
+			served <- s.srv.ServeTLS(s.listener, "", "")
+			return
+		}
+		served <- s.srv.Serve(s.listener)
+	}()
+	s.log.Log("event", "listening", "addr", s.Addr(), "tls", s.tls)
+
+	var err error
+	select {
+	case err = <-served:
+		// Serve only returns by itself if something is wrong
+		err = fmt.Errorf("httpserver: serving failed: %w", err)
+	case <-ctx.Done():
+		s.log.Log("event", "shutdown", "grace", s.grace)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.grace)
+	defer cancel()
+
+	if s.challenge != nil {
+		s.challenge.Shutdown(shutdownCtx)
+	}
+	if shutErr := s.srv.Shutdown(shutdownCtx); shutErr != nil {
+		s.srv.Close()
+		if err == nil {
+			err = fmt.Errorf("httpserver: draining connections failed: %w", shutErr)
+		}
+	}
+
+	for i := len(s.hooks) - 1; i >= 0; i-- {
+		if hookErr := s.hooks[i](shutdownCtx); hookErr != nil {
+			s.log.Log("event", "error", "msg", "shutdown hook failed", "err", hookErr)
+			if err == nil {
+				err = hookErr
+			}
+		}
+	}
+
+	s.log.Log("event", "stopped")
+	return err
+}
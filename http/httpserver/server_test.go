@@ -0,0 +1,148 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kitlog "go.mindeco.de/log"
+)
+
+func TestRunShutdown(t *testing.T) {
+	a := assert.New(t)
+
+	var (
+		started  = make(chan struct{})
+		finished = make(chan struct{})
+		order    []string
+	)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finished
+		w.Write([]byte("drained"))
+	})
+
+	srv, err := New("127.0.0.1:0", h,
+		SetLogger(kitlog.NewNopLogger()),
+		SetGracePeriod(time.Second),
+		OnShutdown(func(ctx context.Context) error { order = append(order, "first"); return nil }),
+		OnShutdown(func(ctx context.Context) error { order = append(order, "second"); return errors.New("oops") }),
+	)
+	require.NoError(t, err)
+	a.Equal(DefaultTimeouts.ReadHeader, srv.HTTP().ReadHeaderTimeout)
+	require.NoError(t, srv.Listen())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error)
+	go func() { ran <- srv.Run(ctx) }()
+
+	body := make(chan string)
+	go func() {
+		resp, err := http.Get("http://" + srv.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		body <- string(b)
+	}()
+
+	<-started
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(finished)
+
+	a.Equal("drained", <-body, "the open request is finished")
+	a.EqualError(<-ran, "oops")
+	a.Equal([]string{"second", "first"}, order, "hooks run in reverse order")
+}
+
+func TestRunSignal(t *testing.T) {
+	srv, err := New("127.0.0.1:0", http.NotFoundHandler(), SetLogger(kitlog.NewNopLogger()), SetSignals(syscall.SIGUSR1))
+	require.NoError(t, err)
+
+	// so that the process isn't killed if the signal arrives before Run is listening for it
+	ignore := make(chan os.Signal, 1)
+	signal.Notify(ignore, syscall.SIGUSR1)
+	defer signal.Stop(ignore)
+
+	ran := make(chan error)
+	go func() { ran <- srv.Run(context.Background()) }()
+
+	timeout := time.After(time.Second)
+	for {
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+		select {
+		case err := <-ran:
+			assert.NoError(t, err)
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("the signal didn't stop the server")
+		}
+	}
+}
+
+func TestOptionErrors(t *testing.T) {
+	_, err := New(":0", http.NotFoundHandler(), SetGracePeriod(0))
+	assert.Error(t, err)
+	_, err = New(":0", http.NotFoundHandler(), OnShutdown(nil))
+	assert.Error(t, err)
+}
+
+func TestUnixSocket(t *testing.T) {
+	a := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "app.sock")
+	// a stale socket of an earlier run
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	srv, err := New("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("over unix"))
+	}), SetLogger(kitlog.NewNopLogger()), SetUnixSocket(path, 0660))
+	require.NoError(t, err)
+	require.NoError(t, srv.Listen())
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	a.Equal(os.FileMode(0660), fi.Mode().Perm())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error)
+	go func() { ran <- srv.Run(ctx) }()
+
+	client := http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://localhost/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	a.Equal("over unix", string(body))
+
+	other, err := New("", http.NotFoundHandler(), SetUnixSocket(path, 0660))
+	require.NoError(t, err)
+	a.Error(other.Listen(), "the socket is in use")
+
+	cancel()
+	a.NoError(<-ran)
+	_, err = os.Stat(path)
+	a.True(os.IsNotExist(err), "the socket is removed")
+}
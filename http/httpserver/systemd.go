@@ -0,0 +1,75 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// the first file descriptor systemd passes, SD_LISTEN_FDS_START. A variable for the tests.
+var listenFdsStart = 3
+
+// SetSocketName picks the socket with this FileDescriptorName= if systemd passes more than one, by default the first one is used
+func SetSocketName(name string) Option {
+	return func(s *Server) error {
+		if name == "" {
+			return errors.New("httpserver: empty socket name")
+		}
+		s.socketName = name
+		return nil
+	}
+}
+
+// NoSocketActivation makes Listen always bind the address, even if systemd passed sockets
+func NoSocketActivation() Option {
+	return func(s *Server) error {
+		s.noActivation = true
+		return nil
+	}
+}
+
+// systemdListener returns the listener systemd passed to the process (see sd_listen_fds(3)) or nil if there is none.
+// The environment variables are removed, so that child processes don't pick them up.
+func systemdListener(name string) (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	idx := 0
+	if name != "" {
+		idx = -1
+		for i, fdName := range names {
+			if fdName == name && i < n {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return nil, fmt.Errorf("httpserver: systemd passed no socket named %q (got %s)", name, strings.Join(names, ":"))
+		}
+	}
+
+	fd := listenFdsStart + idx
+	syscall.CloseOnExec(fd)
+	f := os.NewFile(uintptr(fd), "systemd-socket")
+	l, err := net.FileListener(f)
+	f.Close() // FileListener dups it
+	if err != nil {
+		return nil, fmt.Errorf("httpserver: socket %d from systemd is no listener: %w", fd, err)
+	}
+	return l, nil
+}
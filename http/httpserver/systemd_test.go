@@ -0,0 +1,95 @@
+package httpserver
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// passSockets makes the listeners look like they were passed by systemd
+func passSockets(t *testing.T, names string, ls ...*net.TCPListener) {
+	start := -1
+	for i, l := range ls {
+		f, err := l.File()
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		if i == 0 {
+			start = int(f.Fd())
+		} else {
+			require.Equal(t, start+i, int(f.Fd()), "the test needs consecutive descriptors")
+		}
+	}
+
+	old := listenFdsStart
+	listenFdsStart = start
+	t.Cleanup(func() { listenFdsStart = old })
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", strconv.Itoa(len(ls)))
+	t.Setenv("LISTEN_FDNAMES", names)
+}
+
+func listenTCP(t *testing.T) *net.TCPListener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	return l.(*net.TCPListener)
+}
+
+func TestSocketActivation(t *testing.T) {
+	a := assert.New(t)
+
+	passed := listenTCP(t)
+	passSockets(t, "http", passed)
+
+	srv, err := New("127.0.0.1:1", http.NotFoundHandler())
+	require.NoError(t, err)
+	require.NoError(t, srv.Listen())
+	a.Equal(passed.Addr().String(), srv.Addr().String())
+	a.Empty(os.Getenv("LISTEN_FDS"), "the variables are removed")
+}
+
+func TestSocketActivationNamed(t *testing.T) {
+	a := assert.New(t)
+
+	first, second := listenTCP(t), listenTCP(t)
+	passSockets(t, "admin:http", first, second)
+
+	srv, err := New("127.0.0.1:1", http.NotFoundHandler(), SetSocketName("http"))
+	require.NoError(t, err)
+	require.NoError(t, srv.Listen())
+	a.Equal(second.Addr().String(), srv.Addr().String())
+
+	// the names are reported, even though the variables were removed already
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "2")
+	t.Setenv("LISTEN_FDNAMES", "admin:http")
+	srv, err = New("127.0.0.1:1", http.NotFoundHandler(), SetSocketName("metrics"))
+	require.NoError(t, err)
+	a.EqualError(srv.Listen(), `httpserver: systemd passed no socket named "metrics" (got admin:http)`)
+}
+
+func TestSocketActivationFallback(t *testing.T) {
+	a := assert.New(t)
+
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	srv, err := New("127.0.0.1:0", http.NotFoundHandler())
+	require.NoError(t, err)
+	require.NoError(t, srv.Listen())
+	a.NotNil(srv.Addr(), "not our pid, the address is bound")
+
+	passed := listenTCP(t)
+	passSockets(t, "", passed)
+	srv, err = New("127.0.0.1:0", http.NotFoundHandler(), NoSocketActivation())
+	require.NoError(t, err)
+	require.NoError(t, srv.Listen())
+	a.NotEqual(passed.Addr().String(), srv.Addr().String())
+	a.NotEmpty(os.Getenv("LISTEN_FDS"), "left alone")
+}
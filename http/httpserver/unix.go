@@ -0,0 +1,48 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// SetUnixSocket listens on a unix socket at path instead of the TCP address, for servers behind nginx or caddy on the same host.
+// mode sets the permissions of the socket file, like 0660 to let the group of the proxy connect.
+// A stale socket from an earlier run is removed, the socket is removed again when the server stops.
+func SetUnixSocket(path string, mode os.FileMode) Option {
+	return func(s *Server) error {
+		if path == "" {
+			return errors.New("httpserver: empty unix socket path")
+		}
+		s.unixPath = path
+		s.unixMode = mode
+		return nil
+	}
+}
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("httpserver: %s exists and is not a socket", path)
+		}
+		// nothing answers on it anymore, or listening below fails anyhow
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("httpserver: %s is still in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("httpserver: failed to remove stale socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("httpserver: failed to listen: %w", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("httpserver: failed to set the socket permissions: %w", err)
+	}
+	return l, nil
+}
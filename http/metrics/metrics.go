@@ -0,0 +1,221 @@
+// Package metrics records Prometheus metrics of a HTTP service and serves them for scraping.
+//
+//	m, err := metrics.New(metrics.SetNamespace("myapp"))
+//	logging.CheckFatal(err)
+//	r, err := render.New(fs, render.SetInstrumenter(m.Render()))
+//	a, err := auth.NewHandler(auther, auth.SetInstrumenter(m.Auth()))
+//	router.Use(m.Middleware)
+//	router.Handle("/metrics", m.Handler())
+//
+// Used with mux.Router.Use the requests are labeled with the template of their route, like /posts/{id}.
+// Requests without a route are labeled "other" and unknown methods "OTHER", so that random requests don't create new series.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"go.mindeco.de/http/auth"
+	"go.mindeco.de/http/middleware"
+	"go.mindeco.de/http/render"
+)
+
+// Metrics holds the collectors, see New
+type Metrics struct {
+	namespace string
+	buckets   []float64
+	reg       prometheus.Registerer
+	gatherer  prometheus.Gatherer
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+	renders  *prometheus.HistogramVec
+	checks   *prometheus.CounterVec
+}
+
+// Option is a function that changes Metrics during initialization
+type Option func(m *Metrics) error
+
+// SetNamespace prefixes all metric names, like myapp_http_requests_total
+func SetNamespace(ns string) Option {
+	return func(m *Metrics) error {
+		m.namespace = ns
+		return nil
+	}
+}
+
+// SetBuckets replaces prometheus.DefBuckets for the durations of requests and template executions
+func SetBuckets(b []float64) Option {
+	return func(m *Metrics) error {
+		if len(b) == 0 {
+			return errors.New("metrics: no buckets passed")
+		}
+		m.buckets = b
+		return nil
+	}
+}
+
+// SetRegistry registers the collectors with reg and serves its metrics from Handler.
+// By default a new registry with the Go and process collectors is used, instead of the global one.
+func SetRegistry(reg *prometheus.Registry) Option {
+	return func(m *Metrics) error {
+		if reg == nil {
+			return errors.New("metrics: nil registry passed")
+		}
+		m.reg = reg
+		m.gatherer = reg
+		return nil
+	}
+}
+
+// New creates and registers the collectors
+func New(opts ...Option) (*Metrics, error) {
+	m := &Metrics{
+		buckets: prometheus.DefBuckets,
+	}
+	for _, o := range opts {
+		if err := o(m); err != nil {
+			return nil, err
+		}
+	}
+	if m.reg == nil {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+		m.reg = reg
+		m.gatherer = reg
+	}
+
+	labels := []string{"method", "route", "status"}
+	m.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled requests",
+	}, labels)
+	m.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "How long it took to handle the requests",
+		Buckets:   m.buckets,
+	}, labels)
+	m.size = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: "http",
+		Name:      "response_size_bytes",
+		Help:      "Size of the response bodies",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 8), // 256B to 4MiB
+	}, labels)
+	m.inFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: m.namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of requests that are currently handled",
+	})
+	m.renders = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: "render",
+		Name:      "duration_seconds",
+		Help:      "How long it took to execute the templates",
+		Buckets:   m.buckets,
+	}, []string{"template", "result"})
+	m.checks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: "auth",
+		Name:      "checks_total",
+		Help:      "Number of checked credentials, by kind of check",
+	}, []string{"check", "result"})
+
+	if err := m.Register(m.requests, m.duration, m.size, m.inFlight, m.renders, m.checks); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Register adds collectors of the application, so that Handler serves them as well
+func (m *Metrics) Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := m.reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the metrics for scraping
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
+}
+
+// Middleware counts the requests and records their duration and response size
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		started := time.Now()
+		sw := middleware.NewStatusWriter(w)
+		next.ServeHTTP(sw, r)
+
+		status := sw.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := "other"
+		if cur := mux.CurrentRoute(r); cur != nil {
+			if tpl, err := cur.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		labels := prometheus.Labels{"method": method(r.Method), "route": route, "status": strconv.Itoa(status)}
+		m.requests.With(labels).Inc()
+		m.duration.With(labels).Observe(time.Since(started).Seconds())
+		m.size.With(labels).Observe(float64(sw.Size()))
+	})
+}
+
+// Render returns a render.Instrumenter that records how long the templates take to execute
+func (m *Metrics) Render() render.Instrumenter {
+	return func(_ context.Context, template string) func(error) {
+		started := time.Now()
+		return func(err error) {
+			m.renders.WithLabelValues(template, result(err)).Observe(time.Since(started).Seconds())
+		}
+	}
+}
+
+// Auth returns an auth.Instrumenter that counts the checked credentials and how many of them failed
+func (m *Metrics) Auth() auth.Instrumenter {
+	return func(ctx context.Context, check string) (context.Context, func(error)) {
+		return ctx, func(err error) {
+			m.checks.WithLabelValues(check, result(err)).Inc()
+		}
+	}
+}
+
+// method returns m for the standard methods and OTHER for the rest, which clients can make up freely
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return m
+	}
+	return "OTHER"
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	m, err := New(SetNamespace("test"), SetRegistry(prometheus.NewRegistry()))
+	r.NoError(err)
+
+	router := mux.NewRouter()
+	router.Use(m.Middleware)
+	router.HandleFunc("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		a.Equal(1.0, testutil.ToFloat64(m.inFlight), "counted while running")
+		fmt.Fprint(w, "hello")
+	})
+	router.Handle("/metrics", m.Handler())
+
+	for _, p := range []string{"/posts/1", "/posts/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+	}
+	m.Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/random", nil))
+	for _, meth := range []string{"FOO", "BAR"} {
+		m.Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(meth, "/random", nil))
+	}
+
+	a.Equal(2.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "/posts/{id}", "200")))
+	a.Equal(1.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "other", "404")))
+	a.Equal(2.0, testutil.ToFloat64(m.requests.WithLabelValues("OTHER", "other", "404")), "made up methods share one series")
+	a.Equal(0.0, testutil.ToFloat64(m.inFlight))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	a.Equal(http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	a.Contains(body, `test_http_requests_total{method="GET",route="/posts/{id}",status="200"} 2`)
+	a.Contains(body, `test_http_response_size_bytes_sum{method="GET",route="/posts/{id}",status="200"} 10`)
+	a.Contains(body, "test_http_request_duration_seconds_bucket")
+}
+
+func TestInstrumenters(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := New(SetRegistry(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	m.Render()(context.Background(), "/index.tmpl")(nil)
+	m.Render()(context.Background(), "/index.tmpl")(errors.New("broken"))
+
+	ctx, done := m.Auth()(context.Background(), "login")
+	a.NotNil(ctx)
+	done(errors.New("wrong password"))
+
+	a.Equal(2, testutil.CollectAndCount(m.renders), "one series per result")
+	a.Equal(1.0, testutil.ToFloat64(m.checks.WithLabelValues("login", "error")))
+
+	custom := prometheus.NewCounter(prometheus.CounterOpts{Name: "jobs_total", Help: "Number of jobs"})
+	a.NoError(m.Register(custom))
+	a.Error(m.Register(custom), "registered twice")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	a.True(strings.Contains(rec.Body.String(), "jobs_total 0"))
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mindeco.de/http/auth"
+	"go.mindeco.de/http/clientip"
+	kitlog "go.mindeco.de/log"
+)
+
+// AccessLogOption changes how AccessLog logs
+type AccessLogOption func(*accessLog)
+
+// LogUserWith sets how the session data of auth.FromContext is turned into the user field (fmt.Sprint by default)
+func LogUserWith(fn func(userData interface{}) string) AccessLogOption {
+	return func(al *accessLog) {
+		al.userFn = fn
+	}
+}
+
+type accessLog struct {
+	logger kitlog.Logger
+	userFn func(interface{}) string
+}
+
+// AccessLog logs every request once it was served, with method, path, status, size and duration (took)
+// and the request ID and user, if there are any. For the ID it has to be behind RequestID.
+//
+// auth.Handler.Authenticate adds the user to the context it passes on, which the AccessLog in front of it doesn't see.
+// LogUser can be put behind it to pass the user back:
+//
+//	h = middleware.AccessLog(logger)(ah.Authenticate(middleware.LogUser(h)))
+func AccessLog(logger kitlog.Logger, opts ...AccessLogOption) func(http.Handler) http.Handler {
+	al := accessLog{
+		logger: logger,
+		userFn: func(v interface{}) string { return fmt.Sprint(v) },
+	}
+	for _, o := range opts {
+		o(&al)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+			sw := &StatusWriter{ResponseWriter: w}
+			entry := &accessEntry{}
+			if user, ok := auth.FromContext(r.Context()); ok {
+				entry.setUser(user)
+			}
+
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), accessEntryKey{}, entry)))
+
+			status := sw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			kv := []interface{}{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", status,
+				"size", sw.size,
+				"took", time.Since(started),
+				"ip", clientip.FromRequest(r),
+			}
+			if id := FromContext(r.Context()); id != "" {
+				kv = append(kv, "reqID", id)
+			}
+			if user, ok := entry.user(); ok {
+				kv = append(kv, "user", al.userFn(user))
+			}
+			al.logger.Log(kv...)
+		})
+	}
+}
+
+// LogUser passes the user of auth.FromContext to the AccessLog in front of it
+func LogUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if entry, ok := r.Context().Value(accessEntryKey{}).(*accessEntry); ok {
+			if user, ok := auth.FromContext(r.Context()); ok {
+				entry.setUser(user)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type accessEntryKey struct{}
+
+// accessEntry collects what the handlers behind AccessLog know about the request
+type accessEntry struct {
+	mu       sync.Mutex
+	userData interface{}
+}
+
+func (e *accessEntry) setUser(v interface{}) {
+	e.mu.Lock()
+	e.userData = v
+	e.mu.Unlock()
+}
+
+func (e *accessEntry) user() (interface{}, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.userData, e.userData != nil
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mindeco.de/http/auth"
+	kitlog "go.mindeco.de/log"
+)
+
+func TestAccessLog(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := kitlog.NewLogfmtLogger(&buf)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+
+	// like auth.Authenticate, which adds the user to the context it passes on
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), 23)))
+		})
+	}
+
+	h := RequestID(AccessLog(logger, LogUserWith(func(v interface{}) string {
+		return fmt.Sprintf("user-%d", v)
+	}))(authenticate(LogUser(mux))))
+
+	req := httptest.NewRequest("GET", "/hello", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	a.Regexp(regexp.MustCompile(`^method=GET path=/hello status=200 size=5 took=\S+ ip=192.0.2.1 reqID=abc user=user-23\n$`), buf.String())
+
+	buf.Reset()
+	AccessLog(logger)(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/missing", nil))
+	a.Regexp(regexp.MustCompile(`^method=POST path=/missing status=404 size=19 took=\S+ ip=192.0.2.1\n$`), buf.String())
+
+	buf.Reset()
+	AccessLog(logger)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	a.Regexp(regexp.MustCompile(`^method=GET path=/ status=200 size=0`), buf.String(), "nothing written is a 200")
+}
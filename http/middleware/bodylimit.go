@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"go.mindeco.de/http/render"
+)
+
+// ErrBodyTooLarge is returned by the body of requests that are bigger than the BodyLimit
+// and passed to its error handler. Handlers that read the body themselves should answer it with 413.
+var ErrBodyTooLarge = errors.New("the request body is too large")
+
+// MultipartLimits are checked for multipart/form-data requests, instead of the limit of BodyLimit
+type MultipartLimits struct {
+	// MaxSize is the limit of the whole body, the max of BodyLimit if it is 0
+	MaxSize int64
+
+	// MaxMemory is passed to ParseMultipartForm, bigger files are stored on disk (default 10MiB)
+	MaxMemory int64
+
+	// MaxFiles is how many files can be uploaded at once, 0 means no limit
+	MaxFiles int
+}
+
+// BodyLimitOption changes how BodyLimit works
+type BodyLimitOption func(*bodyLimiter)
+
+// LimitMultipart parses multipart forms before the handler and enforces l on them.
+// The handler can use r.MultipartForm (or FormFile) directly, its ParseMultipartForm is a no-op then.
+func LimitMultipart(l MultipartLimits) BodyLimitOption {
+	return func(bl *bodyLimiter) {
+		if l.MaxMemory == 0 {
+			l.MaxMemory = 10 << 20
+		}
+		bl.multipart = &l
+	}
+}
+
+// BodyLimitErrorHandler sets how requests that are too big are answered, with a plain text 413 by default.
+// render.Renderer.Error fits, to show the error template.
+func BodyLimitErrorHandler(fn render.ErrorHandlerFunc) BodyLimitOption {
+	return func(bl *bodyLimiter) {
+		bl.errHandler = fn
+	}
+}
+
+type bodyLimiter struct {
+	max        int64
+	multipart  *MultipartLimits
+	errHandler render.ErrorHandlerFunc
+}
+
+// BodyLimit limits the request body to max bytes, with http.MaxBytesReader.
+// Requests that say they are bigger with their Content-Length are answered with 413 Request Entity Too Large right away,
+// bodies that turn out bigger return ErrBodyTooLarge while they are read.
+// Use it per route, upload endpoints likely need a bigger limit than the rest.
+func BodyLimit(max int64, opts ...BodyLimitOption) func(http.Handler) http.Handler {
+	bl := bodyLimiter{
+		max: max,
+		errHandler: func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			http.Error(w, err.Error(), status)
+		},
+	}
+	for _, o := range opts {
+		o(&bl)
+	}
+	if bl.multipart != nil && bl.multipart.MaxSize == 0 {
+		bl.multipart.MaxSize = bl.max
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			max := bl.max
+			isMultipart := bl.multipart != nil && isMultipartForm(r.Header.Get("Content-Type"))
+			if isMultipart {
+				max = bl.multipart.MaxSize
+			}
+
+			if r.ContentLength > max {
+				bl.errHandler(w, r, http.StatusRequestEntityTooLarge, ErrBodyTooLarge)
+				return
+			}
+			r.Body = &limitedBody{rc: http.MaxBytesReader(w, r.Body, max), max: max}
+
+			if isMultipart {
+				if status, err := bl.parseMultipart(r); err != nil {
+					bl.errHandler(w, r, status, err)
+					return
+				}
+				defer r.MultipartForm.RemoveAll()
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (bl bodyLimiter) parseMultipart(r *http.Request) (int, error) {
+	if err := r.ParseMultipartForm(bl.multipart.MaxMemory); err != nil {
+		if r.MultipartForm != nil {
+			r.MultipartForm.RemoveAll()
+		}
+		if errors.Is(err, ErrBodyTooLarge) {
+			return http.StatusRequestEntityTooLarge, err
+		}
+		return http.StatusBadRequest, fmt.Errorf("invalid multipart form: %w", err)
+	}
+
+	if bl.multipart.MaxFiles > 0 {
+		n := 0
+		for _, files := range r.MultipartForm.File {
+			n += len(files)
+		}
+		if n > bl.multipart.MaxFiles {
+			r.MultipartForm.RemoveAll()
+			return http.StatusRequestEntityTooLarge, fmt.Errorf("too many files, at most %d are allowed", bl.multipart.MaxFiles)
+		}
+	}
+	return 0, nil
+}
+
+func isMultipartForm(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mt == "multipart/form-data"
+}
+
+// limitedBody turns the error of http.MaxBytesReader into ErrBodyTooLarge
+type limitedBody struct {
+	rc   io.ReadCloser
+	max  int64
+	read int64
+}
+
+func (lb *limitedBody) Read(p []byte) (int, error) {
+	n, err := lb.rc.Read(p)
+	lb.read += int64(n)
+	if err != nil && err != io.EOF && lb.read >= lb.max {
+		err = ErrBodyTooLarge
+	}
+	return n, err
+}
+
+func (lb *limitedBody) Close() error {
+	return lb.rc.Close()
+}
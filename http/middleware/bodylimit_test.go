@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimit(t *testing.T) {
+	a := assert.New(t)
+
+	h := BodyLimit(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		if errors.Is(err, ErrBodyTooLarge) {
+			http.Error(w, "handler: "+err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write(b)
+	}))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("POST", "/", strings.NewReader("12345678")))
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("12345678", rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("POST", "/", strings.NewReader("123456789")))
+	a.Equal(http.StatusRequestEntityTooLarge, rw.Code)
+	a.Equal("the request body is too large", strings.TrimSpace(rw.Body.String()), "rejected by the Content-Length")
+
+	// without a Content-Length the handler runs into it
+	req := httptest.NewRequest("POST", "/", io.MultiReader(strings.NewReader("123456789")))
+	req.ContentLength = -1
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal(http.StatusRequestEntityTooLarge, rw.Code)
+	a.Equal("handler: the request body is too large", strings.TrimSpace(rw.Body.String()))
+}
+
+func TestBodyLimitMultipart(t *testing.T) {
+	a := assert.New(t)
+
+	var errs []error
+	h := BodyLimit(16,
+		LimitMultipart(MultipartLimits{MaxSize: 4096, MaxMemory: 1024, MaxFiles: 2}),
+		BodyLimitErrorHandler(func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			errs = append(errs, err)
+			w.WriteHeader(status)
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.MultipartForm.Value["title"][0]))
+	}))
+
+	upload := func(files int, size int) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		mw.WriteField("title", "holiday")
+		for i := 0; i < files; i++ {
+			fw, err := mw.CreateFormFile("photo", "photo.jpg")
+			require.NoError(t, err)
+			fw.Write(bytes.Repeat([]byte("x"), size))
+		}
+		mw.Close()
+
+		req := httptest.NewRequest("POST", "/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.ContentLength = -1
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := upload(2, 100)
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("holiday", rw.Body.String())
+
+	a.Equal(http.StatusRequestEntityTooLarge, upload(3, 100).Code)
+	a.Equal(http.StatusRequestEntityTooLarge, upload(1, 5000).Code)
+	require.Len(t, errs, 2)
+	a.EqualError(errs[0], "too many files, at most 2 are allowed")
+	a.Equal(ErrBodyTooLarge, errs[1])
+
+	req := httptest.NewRequest("POST", "/upload", strings.NewReader("not multipart"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=nope")
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal(http.StatusBadRequest, rw.Code)
+
+	// without MaxSize the limit of BodyLimit applies
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "holiday")
+	mw.Close()
+	h = BodyLimit(1024, LimitMultipart(MultipartLimits{MaxFiles: 5}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.MultipartForm.Value["title"][0]))
+	}))
+	req = httptest.NewRequest("POST", "/upload", bytes.NewReader(body.Bytes()))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("holiday", rw.Body.String())
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.mindeco.de/http/clientip"
+)
+
+// ClientIP resolves the client address with rs and stores it in the request context (see clientip.FromRequest).
+// RateLimit, AccessLog and the session binding of auth all use it, so it should be in front of them.
+func ClientIP(rs *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := clientip.NewContext(r.Context(), rs.Resolve(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
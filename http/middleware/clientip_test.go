@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mindeco.de/http/clientip"
+)
+
+func TestClientIPRateLimit(t *testing.T) {
+	a := assert.New(t)
+
+	rs, err := clientip.NewResolver([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	h := ClientIP(rs)(RateLimit(Rate{Burst: 1, Every: time.Hour})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(clientip.FromRequest(r)))
+	})))
+
+	get := func(forwarded string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:4242"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := get("198.51.100.7")
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("198.51.100.7", rw.Body.String())
+
+	a.Equal(http.StatusTooManyRequests, get("198.51.100.7").Code)
+	a.Equal(http.StatusOK, get("198.51.100.8").Code, "clients behind the same proxy have their own buckets")
+}
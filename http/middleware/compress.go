@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// DefaultCompressTypes are the MIME types Compress compresses, unless CompressTypes is used
+var DefaultCompressTypes = []string{
+	"text/html", "text/plain", "text/css", "text/csv", "text/javascript", "text/xml", "text/markdown",
+	"application/json", "application/javascript", "application/xml", "application/xhtml+xml",
+	"application/rss+xml", "application/atom+xml", "application/manifest+json", "image/svg+xml",
+}
+
+// CompressOption changes how Compress works
+type CompressOption func(*compressor)
+
+// CompressTypes replaces DefaultCompressTypes. A type like "text/*" matches all of its subtypes.
+func CompressTypes(types ...string) CompressOption {
+	return func(c *compressor) {
+		c.types = types
+	}
+}
+
+// CompressMinSize sets the size in bytes below which responses are sent uncompressed (default 1024)
+func CompressMinSize(n int) CompressOption {
+	return func(c *compressor) {
+		c.minSize = n
+	}
+}
+
+type compressor struct {
+	types   []string
+	minSize int
+}
+
+var gzipPool = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+
+// Compress compresses responses with brotli or gzip, depending on the Accept-Encoding of the request.
+// Only the allowed MIME types are compressed (see CompressTypes) and only if the response is at least CompressMinSize big,
+// for which up to that many bytes are buffered. Responses that already have a Content-Encoding are left alone.
+//
+// It adds Accept-Encoding to the Vary header, removes the Content-Length of compressed responses and weakens their ETag.
+func Compress(opts ...CompressOption) func(http.Handler) http.Handler {
+	c := compressor{types: DefaultCompressTypes, minSize: 1024}
+	for _, o := range opts {
+		o(&c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if enc == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// not deferred: after a panic the buffered start of the response is dropped, so that a Recover in front can send its error
+			cw := &compressWriter{ResponseWriter: w, c: &c, enc: enc}
+			next.ServeHTTP(cw, r)
+			cw.close()
+		})
+	}
+}
+
+// negotiateEncoding returns br, gzip or an empty string, preferring br if both are accepted equally.
+// Encodings with q=0 are refused by the client.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, q := part, 1.0
+		if i := strings.IndexByte(part, ';'); i != -1 {
+			name = part[:i]
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				f, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					continue
+				}
+				q = f
+			}
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if (name != "br" && name != "gzip") || q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "br") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+func (c *compressor) allowed(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range c.types {
+		if t == mt || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mt, t[:len(t)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter buffers the start of the response until it knows whether it should be compressed
+type compressWriter struct {
+	http.ResponseWriter
+	c   *compressor
+	enc string
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = code
+	if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified || code == http.StatusPartialContent {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		return cw.write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.c.minSize {
+		cw.decide(true)
+		if err := cw.flushBuffer(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (cw *compressWriter) write(b []byte) (int, error) {
+	if cw.encoder != nil {
+		return cw.encoder.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// decide sends the header, compressing if big is true and the type allows it
+func (cw *compressWriter) decide(big bool) {
+	if cw.decided {
+		return
+	}
+	cw.decided = true
+
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if big && h.Get("Content-Encoding") == "" && cw.c.allowed(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", cw.enc)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		switch cw.enc {
+		case "br":
+			cw.encoder = brotli.NewWriterLevel(cw.ResponseWriter, brotli.DefaultCompression)
+		default:
+			gw := gzipPool.Get().(*gzip.Writer)
+			gw.Reset(cw.ResponseWriter)
+			cw.encoder = gw
+		}
+	}
+
+	status := cw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressWriter) flushBuffer() error {
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	_, err := cw.write(cw.buf)
+	cw.buf = nil
+	return err
+}
+
+// close sends what is still buffered and finishes the compression
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			// nothing was written, let net/http send its default response
+			return
+		}
+		cw.decide(len(cw.buf) >= cw.c.minSize)
+	}
+	cw.flushBuffer()
+
+	if cw.encoder != nil {
+		cw.encoder.Close()
+		if gw, ok := cw.encoder.(*gzip.Writer); ok {
+			gzipPool.Put(gw)
+		}
+		cw.encoder = nil
+	}
+}
+
+// Flush implements http.Flusher. Streamed responses are compressed regardless of their size.
+func (cw *compressWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	cw.decide(true)
+	cw.flushBuffer()
+
+	switch enc := cw.encoder.(type) {
+	case *gzip.Writer:
+		enc.Flush()
+	case *brotli.Writer:
+		enc.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, for websockets
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("middleware: the ResponseWriter doesn't support hijacking")
+	}
+	cw.decided = true
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
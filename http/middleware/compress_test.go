@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("", negotiateEncoding(""))
+	a.Equal("gzip", negotiateEncoding("gzip, deflate"))
+	a.Equal("br", negotiateEncoding("gzip, deflate, br"))
+	a.Equal("gzip", negotiateEncoding("br;q=0.5, gzip"))
+	a.Equal("", negotiateEncoding("gzip;q=0, identity"))
+	a.Equal("", negotiateEncoding("br;q=0"))
+	a.Equal("", negotiateEncoding("gzip;q=0, br;q=0"))
+	a.Equal("gzip", negotiateEncoding("br;q=0, gzip"))
+}
+
+func TestCompressPanic(t *testing.T) {
+	a := assert.New(t)
+
+	inject := logging.InjectHandler(kitlog.NewNopLogger())
+	h := inject(Recover(nil)(Compress(CompressMinSize(32))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("half of"))
+		panic("boom")
+	}))))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal(http.StatusInternalServerError, rw.Code)
+	a.Equal("", rw.Header().Get("Content-Encoding"))
+	a.NotContains(rw.Body.String(), "half of")
+}
+
+func TestCompress(t *testing.T) {
+	a := assert.New(t)
+
+	big := strings.Repeat("hello compression ", 100)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Length", "1800")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(big[:900]))
+		w.Write([]byte(big[900:]))
+	})
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"a":1}`))
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(big))
+	})
+	mux.HandleFunc("/sniffed", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>" + big))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := Compress()(mux)
+
+	get := func(path, ae string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if ae != "" {
+			req.Header.Set("Accept-Encoding", ae)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := get("/big", "gzip")
+	a.Equal("gzip", rw.Header().Get("Content-Encoding"))
+	a.Equal("Accept-Encoding", rw.Header().Get("Vary"))
+	a.Equal("", rw.Header().Get("Content-Length"))
+	a.Equal(`W/"v1"`, rw.Header().Get("ETag"))
+	gr, err := gzip.NewReader(rw.Body)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(gr)
+	require.NoError(t, err)
+	a.Equal(big, string(body))
+
+	rw = get("/big", "gzip, br")
+	a.Equal("br", rw.Header().Get("Content-Encoding"))
+	body, err = ioutil.ReadAll(brotli.NewReader(rw.Body))
+	require.NoError(t, err)
+	a.Equal(big, string(body))
+
+	rw = get("/big", "")
+	a.Equal("", rw.Header().Get("Content-Encoding"))
+	a.Equal("1800", rw.Header().Get("Content-Length"))
+	a.Equal("Accept-Encoding", rw.Header().Get("Vary"))
+	a.Equal(big, rw.Body.String())
+
+	rw = get("/small", "gzip")
+	a.Equal("", rw.Header().Get("Content-Encoding"))
+	a.Equal(`{"a":1}`, rw.Body.String())
+
+	rw = get("/image", "gzip")
+	a.Equal("", rw.Header().Get("Content-Encoding"))
+	a.Equal(big, rw.Body.String())
+
+	rw = get("/sniffed", "gzip")
+	a.Equal("gzip", rw.Header().Get("Content-Encoding"))
+	a.Equal("text/html; charset=utf-8", rw.Header().Get("Content-Type"))
+
+	rw = get("/empty", "gzip")
+	a.Equal(http.StatusNoContent, rw.Code)
+	a.Equal("", rw.Header().Get("Content-Encoding"))
+}
+
+func TestCompressFlush(t *testing.T) {
+	a := assert.New(t)
+
+	h := Compress(CompressTypes("text/*"), CompressMinSize(1<<20))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: one\n\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("data: two\n\n"))
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.True(rw.Flushed)
+	a.Equal("gzip", rw.Header().Get("Content-Encoding"))
+
+	gr, err := gzip.NewReader(rw.Body)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(gr)
+	require.NoError(t, err)
+	a.Equal("data: one\n\ndata: two\n\n", string(body))
+}
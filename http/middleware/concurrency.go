@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"go.mindeco.de/http/render"
+)
+
+// ErrOverloaded is passed to the error handler of ConcurrencyLimit for requests that were shed
+var ErrOverloaded = errors.New("the server is overloaded, try again later")
+
+// ConcurrencyOption changes how ConcurrencyLimit works
+type ConcurrencyOption func(*concurrencyLimiter)
+
+// ConcurrencyErrorHandler sets how shed requests are answered, with a plain text 503 by default.
+// render.Renderer.Error fits, to show the error template.
+func ConcurrencyErrorHandler(fn render.ErrorHandlerFunc) ConcurrencyOption {
+	return func(cl *concurrencyLimiter) {
+		cl.errHandler = fn
+	}
+}
+
+type concurrencyLimiter struct {
+	slots      chan struct{}
+	maxWait    time.Duration
+	errHandler render.ErrorHandlerFunc
+}
+
+// ConcurrencyLimit serves at most max requests at the same time. Requests over that wait for up to maxWait for a free slot
+// and are then answered with 503 Service Unavailable, so that a stalled database or slow renders don't pile up
+// until the process falls over.
+//
+// Every call has its own slots: wrap the whole router for a global limit and single routes for their own.
+func ConcurrencyLimit(max int, maxWait time.Duration, opts ...ConcurrencyOption) func(http.Handler) http.Handler {
+	cl := concurrencyLimiter{
+		slots:   make(chan struct{}, max),
+		maxWait: maxWait,
+		errHandler: func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			http.Error(w, err.Error(), status)
+		},
+	}
+	for _, o := range opts {
+		o(&cl)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cl.acquire(r) {
+				logger(r.Context()).Log("event", "shed", "method", r.Method, "path", r.URL.Path, "waited", cl.maxWait)
+				w.Header().Set("Retry-After", "1")
+				cl.errHandler(w, r, http.StatusServiceUnavailable, ErrOverloaded)
+				return
+			}
+			defer func() { <-cl.slots }()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquire takes a slot, waiting for at most maxWait or until the client goes away
+func (cl concurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case cl.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if cl.maxWait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(cl.maxWait)
+	defer timer.Stop()
+	select {
+	case cl.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
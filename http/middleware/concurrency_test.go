@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	a := assert.New(t)
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	h := ConcurrencyLimit(2, 100*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		w.Write([]byte("ok"))
+	}))
+	h = logging.InjectHandler(kitlog.NewNopLogger())(h)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", path, nil))
+		return rw
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Equal(http.StatusOK, serve("/slow").Code)
+		}()
+		<-started
+	}
+
+	rw := serve("/fast")
+	a.Equal(http.StatusServiceUnavailable, rw.Code, "both slots are taken")
+	a.Equal("1", rw.Header().Get("Retry-After"))
+
+	// a queued request gets the slot that is freed while it waits
+	done := make(chan int)
+	go func() { done <- serve("/fast").Code }()
+	time.Sleep(5 * time.Millisecond)
+	release <- struct{}{}
+	a.Equal(http.StatusOK, <-done)
+
+	release <- struct{}{}
+	wg.Wait()
+	a.Equal(http.StatusOK, serve("/fast").Code)
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig says which cross-origin requests are allowed
+type CORSConfig struct {
+	// AllowedOrigins are full origins (https://example.com), ones with a single wildcard (https://*.example.com) or * for any
+	AllowedOrigins []string
+
+	// AllowedOriginPatterns are checked in addition to AllowedOrigins. They have to match the full origin,
+	// CORS anchors them, so https://app\.example\.com doesn't allow https://app.example.com.evil.net
+	AllowedOriginPatterns []*regexp.Regexp
+
+	AllowedMethods []string // GET, HEAD and POST if empty
+	AllowedHeaders []string // the request headers a client may send, * for any
+	ExposedHeaders []string // the response headers scripts may read
+
+	// AllowCredentials lets scripts send cookies. The origin is then always echoed, even for *.
+	AllowCredentials bool
+
+	// MaxAge is how long browsers may cache the result of a preflight request
+	MaxAge time.Duration
+}
+
+// CORS answers preflight requests and adds the Access-Control headers to the responses for allowed origins.
+// Requests from other origins are passed on without them, so the browser doesn't let scripts read the response.
+func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+
+	anchored := make([]*regexp.Regexp, len(cfg.AllowedOriginPatterns))
+	for i, re := range cfg.AllowedOriginPatterns {
+		anchored[i] = regexp.MustCompile(`^(?:` + re.String() + `)$`)
+	}
+	cfg.AllowedOriginPatterns = anchored
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !cfg.originAllowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.allowsAny() && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if len(cfg.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reqMethod := r.Header.Get("Access-Control-Request-Method")
+			reqHeaders := parseHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+			if !containsFold(cfg.AllowedMethods, reqMethod) || !cfg.headersAllowed(reqHeaders) {
+				h.Del("Access-Control-Allow-Origin")
+				h.Del("Access-Control-Allow-Credentials")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Methods", methods)
+			if len(reqHeaders) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(reqHeaders, ", "))
+			}
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge/time.Second)))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func (cfg CORSConfig) allowsAny() bool {
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg CORSConfig) originAllowed(origin string) bool {
+	lower := strings.ToLower(origin)
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.ToLower(o)
+		if o == "*" || o == lower {
+			return true
+		}
+		if i := strings.IndexByte(o, '*'); i != -1 {
+			prefix, suffix := o[:i], o[i+1:]
+			if len(lower) > len(prefix)+len(suffix) && strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) {
+				return true
+			}
+		}
+	}
+	for _, re := range cfg.AllowedOriginPatterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg CORSConfig) headersAllowed(headers []string) bool {
+	for _, h := range headers {
+		if !containsFold(cfg.AllowedHeaders, h) && !containsFold(cfg.AllowedHeaders, "*") {
+			return false
+		}
+	}
+	return true
+}
+
+func parseHeaderList(v string) []string {
+	var list []string
+	for _, h := range strings.Split(v, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			list = append(list, http.CanonicalHeaderKey(h))
+		}
+	}
+	return list
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	a := assert.New(t)
+
+	served := 0
+	h := CORS(CORSConfig{
+		AllowedOrigins:        []string{"https://app.example", "https://*.example.com"},
+		AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`), regexp.MustCompile(`https://dev\.example\.org`)},
+		AllowedMethods:        []string{"GET", "PUT"},
+		AllowedHeaders:        []string{"Content-Type", "X-Token"},
+		ExposedHeaders:        []string{"X-Total"},
+		AllowCredentials:      true,
+		MaxAge:                10 * time.Minute,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+
+	send := func(method, origin string, hdr map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for k, v := range hdr {
+			req.Header.Set(k, v)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := send("GET", "https://app.example", nil)
+	a.Equal("https://app.example", rw.Header().Get("Access-Control-Allow-Origin"))
+	a.Equal("true", rw.Header().Get("Access-Control-Allow-Credentials"))
+	a.Equal("X-Total", rw.Header().Get("Access-Control-Expose-Headers"))
+	a.Equal("Origin", rw.Header().Get("Vary"))
+	a.Equal(1, served)
+
+	for _, ok := range []string{"https://api.example.com", "https://A.B.example.com", "http://localhost:3000", "https://dev.example.org"} {
+		a.Equal(ok, send("GET", ok, nil).Header().Get("Access-Control-Allow-Origin"), ok)
+	}
+	for _, bad := range []string{"https://evil.example", "https://example.com", "https://app.example.evil", "http://localhost",
+		"https://dev.example.org.evil.net", "http://evil.net/https://dev.example.org"} {
+		a.Empty(send("GET", bad, nil).Header().Get("Access-Control-Allow-Origin"), bad)
+	}
+	a.Empty(send("GET", "", nil).Header().Get("Access-Control-Allow-Origin"))
+
+	served = 0
+	rw = send("OPTIONS", "https://app.example", map[string]string{
+		"Access-Control-Request-Method":  "PUT",
+		"Access-Control-Request-Headers": "content-type, x-token",
+	})
+	a.Equal(http.StatusNoContent, rw.Code)
+	a.Equal(0, served, "preflights are answered by the middleware")
+	a.Equal("https://app.example", rw.Header().Get("Access-Control-Allow-Origin"))
+	a.Equal("GET, PUT", rw.Header().Get("Access-Control-Allow-Methods"))
+	a.Equal("Content-Type, X-Token", rw.Header().Get("Access-Control-Allow-Headers"))
+	a.Equal("600", rw.Header().Get("Access-Control-Max-Age"))
+	a.Equal([]string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, rw.Header()["Vary"])
+
+	rw = send("OPTIONS", "https://app.example", map[string]string{
+		"Access-Control-Request-Method":  "PUT",
+		"Access-Control-Request-Headers": "X-Other",
+	})
+	a.Equal(http.StatusNoContent, rw.Code)
+	a.Empty(rw.Header().Get("Access-Control-Allow-Origin"))
+
+	rw = send("OPTIONS", "https://app.example", map[string]string{"Access-Control-Request-Method": "DELETE"})
+	a.Empty(rw.Header().Get("Access-Control-Allow-Methods"))
+
+	// a plain OPTIONS request is not a preflight
+	send("OPTIONS", "https://app.example", nil)
+	a.Equal(1, served)
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	a := assert.New(t)
+
+	h := CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	req := httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "X-Whatever")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal("*", rw.Header().Get("Access-Control-Allow-Origin"))
+	a.Equal("GET, HEAD, POST", rw.Header().Get("Access-Control-Allow-Methods"))
+	a.Equal("X-Whatever", rw.Header().Get("Access-Control-Allow-Headers"))
+	a.Empty(rw.Header().Get("Access-Control-Allow-Credentials"))
+}
@@ -0,0 +1,313 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRedactHeaders are replaced by [redacted] in dumps, in addition to the ones of DumpRedact
+var DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// DefaultRedactFields are the form fields and JSON keys that are replaced by [redacted] in dumped bodies
+var DefaultRedactFields = []string{"pass", "password", "token", "secret", "csrf"}
+
+const redacted = "[redacted]"
+
+// Dump is a request and its response, as recorded by DumpRequests
+type Dump struct {
+	Time   time.Time
+	Took   time.Duration
+	Method string
+	URL    string
+
+	RequestHeader http.Header
+	RequestBody   string // only what the handler read
+
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   string
+
+	Truncated bool // one of the bodies was longer than DumpMaxBody
+}
+
+// String formats the dump like a HTTP exchange
+func (d Dump) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "=== %s %s %s (took %s)\n", d.Time.Format(time.RFC3339), d.Method, d.URL, d.Took)
+	writeHeader(&b, d.RequestHeader)
+	if d.RequestBody != "" {
+		b.WriteString("\n" + d.RequestBody + "\n")
+	}
+	fmt.Fprintf(&b, "--- %d %s\n", d.Status, http.StatusText(d.Status))
+	writeHeader(&b, d.ResponseHeader)
+	if d.ResponseBody != "" {
+		b.WriteString("\n" + d.ResponseBody + "\n")
+	}
+	if d.Truncated {
+		b.WriteString("[truncated]\n")
+	}
+	return b.String()
+}
+
+func writeHeader(b *strings.Builder, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(b, "%s: %s\n", k, v)
+		}
+	}
+}
+
+// DumpRing keeps the last dumps. It's a http.Handler that shows them, newest first, for a debug endpoint.
+type DumpRing struct {
+	mu    sync.Mutex
+	dumps []Dump
+	next  int
+	full  bool
+}
+
+// NewDumpRing returns a DumpRing that keeps the last n dumps
+func NewDumpRing(n int) *DumpRing {
+	return &DumpRing{dumps: make([]Dump, n)}
+}
+
+func (dr *DumpRing) add(d Dump) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	if len(dr.dumps) == 0 {
+		return
+	}
+	dr.dumps[dr.next] = d
+	dr.next = (dr.next + 1) % len(dr.dumps)
+	if dr.next == 0 {
+		dr.full = true
+	}
+}
+
+// Dumps returns the kept dumps, newest first
+func (dr *DumpRing) Dumps() []Dump {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	n := dr.next
+	if dr.full {
+		n = len(dr.dumps)
+	}
+	list := make([]Dump, 0, n)
+	for i := 1; i <= n; i++ {
+		list = append(list, dr.dumps[(dr.next-i+len(dr.dumps))%len(dr.dumps)])
+	}
+	return list
+}
+
+func (dr *DumpRing) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	for _, d := range dr.Dumps() {
+		io.WriteString(w, d.String()+"\n")
+	}
+}
+
+// DumpOption changes how DumpRequests works
+type DumpOption func(*dumper)
+
+// DumpMaxBody sets how many bytes of each body are kept (default 4KiB)
+func DumpMaxBody(n int) DumpOption {
+	return func(d *dumper) {
+		d.maxBody = n
+	}
+}
+
+// DumpRedact adds headers, form fields and JSON keys whose values are left out of the dumps
+func DumpRedact(names ...string) DumpOption {
+	return func(d *dumper) {
+		d.redact = append(d.redact, names...)
+	}
+}
+
+// DumpTo keeps the dumps in dr instead of logging them
+func DumpTo(dr *DumpRing) DumpOption {
+	return func(d *dumper) {
+		d.ring = dr
+	}
+}
+
+type dumper struct {
+	maxBody int
+	redact  []string
+	ring    *DumpRing
+}
+
+// DumpRequests records the headers and bodies of requests and their responses, for diagnosing clients during development.
+// They are logged (with event=dump) or kept in a DumpRing. Credentials in the headers and bodies are redacted,
+// see DefaultRedactHeaders and DefaultRedactFields, but it shouldn't be used in production anyhow.
+func DumpRequests(opts ...DumpOption) func(http.Handler) http.Handler {
+	d := dumper{maxBody: 4 << 10}
+	d.redact = append(d.redact, DefaultRedactHeaders...)
+	d.redact = append(d.redact, DefaultRedactFields...)
+	for _, o := range opts {
+		o(&d)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+			reqBody := &cappedBuffer{max: d.maxBody}
+			if r.Body != nil {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, reqBody), r.Body}
+			}
+
+			dw := &dumpWriter{StatusWriter: &StatusWriter{ResponseWriter: w}, body: &cappedBuffer{max: d.maxBody}}
+			next.ServeHTTP(dw, r)
+
+			status := dw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			dump := Dump{
+				Time:   started,
+				Took:   time.Since(started),
+				Method: r.Method,
+				URL:    r.URL.String(),
+
+				RequestHeader: d.redactHeader(r.Header),
+				RequestBody:   d.redactBody(r.Header.Get("Content-Type"), reqBody),
+
+				Status:         status,
+				ResponseHeader: d.redactHeader(w.Header()),
+				ResponseBody:   d.redactBody(w.Header().Get("Content-Type"), dw.body),
+
+				Truncated: reqBody.truncated || dw.body.truncated,
+			}
+
+			if d.ring != nil {
+				d.ring.add(dump)
+				return
+			}
+			logger(r.Context()).Log("event", "dump", "dump", dump.String())
+		})
+	}
+}
+
+func (d dumper) redactHeader(h http.Header) http.Header {
+	c := h.Clone()
+	for _, k := range d.redact {
+		if _, has := c[http.CanonicalHeaderKey(k)]; has {
+			c.Set(k, redacted)
+		}
+	}
+	return c
+}
+
+// redactBody replaces the values of redacted fields in form and JSON bodies, at any depth of the JSON.
+// Truncated bodies can't be parsed and only a notice is returned for them, since the secret might be in there.
+// Text is shown as it is, other types and bodies without a type only by their size.
+func (d dumper) redactBody(contentType string, cb *cappedBuffer) string {
+	body := cb.buf.Bytes()
+	if len(body) == 0 {
+		return ""
+	}
+	mt, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mt == "application/x-www-form-urlencoded":
+		if cb.truncated {
+			return "[form body too long to redact]"
+		}
+		vals, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[unparsable form body]"
+		}
+		for k := range vals {
+			if containsFold(d.redact, k) {
+				vals[k] = []string{redacted}
+			}
+		}
+		return vals.Encode()
+
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		if cb.truncated {
+			return "[JSON body too long to redact]"
+		}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return "[unparsable JSON body]"
+		}
+		out, _ := json.Marshal(d.redactJSON(v))
+		return string(out)
+
+	case strings.HasPrefix(mt, "text/"):
+		return string(body)
+
+	case mt == "":
+		// without a type there is no telling what's in there
+		return fmt.Sprintf("[%d bytes without a content type]", len(body))
+	}
+	return fmt.Sprintf("[%d bytes of %s]", len(body), mt)
+}
+
+// redactJSON replaces the values of redacted keys in all objects of v, also nested ones and those in arrays
+func (d dumper) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, el := range v {
+			if containsFold(d.redact, k) {
+				v[k] = redacted
+			} else {
+				v[k] = d.redactJSON(el)
+			}
+		}
+	case []interface{}:
+		for i, el := range v {
+			v[i] = d.redactJSON(el)
+		}
+	}
+	return v
+}
+
+// cappedBuffer keeps the first max bytes that are written to it
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (cb *cappedBuffer) Write(p []byte) (int, error) {
+	if room := cb.max - cb.buf.Len(); room < len(p) {
+		cb.truncated = true
+		if room > 0 {
+			cb.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return cb.buf.Write(p)
+}
+
+// dumpWriter copies the start of the response into body
+type dumpWriter struct {
+	*StatusWriter
+	body *cappedBuffer
+}
+
+func (dw *dumpWriter) Write(b []byte) (int, error) {
+	n, err := dw.StatusWriter.Write(b)
+	dw.body.Write(b[:n])
+	return n, err
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+func TestDumpRequests(t *testing.T) {
+	a := assert.New(t)
+
+	ring := NewDumpRing(2)
+	h := DumpRequests(DumpTo(ring), DumpMaxBody(64), DumpRedact("X-Session"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("X-Session", "abc")
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+
+	send := func(ct, body string) {
+		req := httptest.NewRequest("POST", "/login?next=/", strings.NewReader(body))
+		req.Header.Set("Content-Type", ct)
+		req.Header.Set("Authorization", "Bearer xyz")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	send("application/x-www-form-urlencoded", "user=alice&pass=secret")
+	dumps := ring.Dumps()
+	require.Len(t, dumps, 1)
+	d := dumps[0]
+	a.Equal("POST", d.Method)
+	a.Equal("/login?next=/", d.URL)
+	a.Equal(redacted, d.RequestHeader.Get("Authorization"))
+	a.Equal("pass=%5Bredacted%5D&user=alice", d.RequestBody)
+	a.Equal(http.StatusCreated, d.Status)
+	a.Equal(redacted, d.ResponseHeader.Get("Set-Cookie"))
+	a.Equal(redacted, d.ResponseHeader.Get("X-Session"))
+	a.Equal(d.RequestBody, d.ResponseBody)
+	a.False(d.Truncated)
+
+	send("application/json", `{"user":"alice","password":"secret"}`)
+	send("text/plain", strings.Repeat("x", 100))
+	dumps = ring.Dumps()
+	require.Len(t, dumps, 2, "only the last two are kept")
+	a.True(dumps[0].Truncated)
+	a.Len(dumps[0].RequestBody, 64)
+	a.JSONEq(`{"user":"alice","password":"[redacted]"}`, dumps[1].RequestBody)
+
+	send("application/json", `{"password":"`+strings.Repeat("x", 100)+`"}`)
+	a.Equal("[JSON body too long to redact]", ring.Dumps()[0].RequestBody)
+
+	// nested objects and arrays are redacted too
+	send("application/json", `{"user":{"password":"x"},"keys":[{"token":"y","n":1.50}]}`)
+	a.JSONEq(`{"user":{"password":"[redacted]"},"keys":[{"token":"[redacted]","n":1.50}]}`, ring.Dumps()[0].RequestBody)
+
+	send("application/json", `{"password":"x"`)
+	a.Equal("[unparsable JSON body]", ring.Dumps()[0].RequestBody)
+
+	send("", "password=secret")
+	a.Equal("[15 bytes without a content type]", ring.Dumps()[0].RequestBody)
+
+	rw := httptest.NewRecorder()
+	ring.ServeHTTP(rw, httptest.NewRequest("GET", "/debug/dumps", nil))
+	a.Contains(rw.Body.String(), "POST /login?next=/")
+	a.Contains(rw.Body.String(), "--- 201 Created")
+	a.NotContains(rw.Body.String(), "Bearer xyz")
+}
+
+func TestDumpRequestsLog(t *testing.T) {
+	var buf bytes.Buffer
+	h := logging.InjectHandler(kitlog.NewLogfmtLogger(&buf))(DumpRequests()(http.NotFoundHandler()))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nope", nil))
+	assert.Contains(t, buf.String(), "event=dump")
+	assert.Contains(t, buf.String(), "GET /nope")
+	assert.Contains(t, buf.String(), "404 Not Found")
+}
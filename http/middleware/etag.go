@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETagOption changes how ETag works
+type ETagOption func(*etagger)
+
+// ETagMaxSize sets the size in bytes up to which responses are buffered and tagged (default 256KiB).
+// Bigger responses are sent as they are.
+func ETagMaxSize(n int) ETagOption {
+	return func(e *etagger) {
+		e.maxSize = n
+	}
+}
+
+type etagger struct {
+	maxSize int
+}
+
+type etagKey struct{}
+
+// ETag buffers successful responses to GET requests, sets a strong ETag from their hash
+// and answers a matching If-None-Match with 304 Not Modified. It works for any handler, not just the Renderer.
+// Responses that already have an ETag are only checked against If-None-Match. Streamed (flushed) responses aren't tagged.
+// Routes can opt out with SkipETag.
+func ETag(opts ...ETagOption) func(http.Handler) http.Handler {
+	e := etagger{maxSize: 256 << 10}
+	for _, o := range opts {
+		o(&e)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w, e: &e, r: r}
+			ctx := context.WithValue(r.Context(), etagKey{}, ew)
+			next.ServeHTTP(ew, r.WithContext(ctx))
+			ew.finish()
+		})
+	}
+}
+
+// SkipETag turns off the ETag in front of it for the wrapped route, for instance for responses that differ on every request
+func SkipETag(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ew, ok := r.Context().Value(etagKey{}).(*etagWriter); ok {
+			ew.pass()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// etagWriter buffers the response until it's done or too big
+type etagWriter struct {
+	http.ResponseWriter
+	e *etagger
+	r *http.Request
+
+	status    int
+	buf       []byte
+	passing   bool // the response is sent as it is
+	wroteHead bool
+}
+
+func (ew *etagWriter) WriteHeader(code int) {
+	if ew.status != 0 {
+		return
+	}
+	ew.status = code
+	if ew.passing {
+		ew.writeHead(code)
+		return
+	}
+	if code != http.StatusOK {
+		ew.pass()
+	}
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	if ew.status == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.passing {
+		return ew.ResponseWriter.Write(b)
+	}
+
+	if len(ew.buf)+len(b) > ew.e.maxSize {
+		ew.pass()
+		return ew.ResponseWriter.Write(b)
+	}
+	ew.buf = append(ew.buf, b...)
+	return len(b), nil
+}
+
+// pass sends the header and what was buffered so far, without an ETag
+func (ew *etagWriter) pass() {
+	if ew.passing {
+		return
+	}
+	ew.passing = true
+	if ew.status == 0 {
+		// nothing written yet, the handler will send everything itself
+		return
+	}
+	ew.writeHead(ew.status)
+	if len(ew.buf) > 0 {
+		ew.ResponseWriter.Write(ew.buf)
+		ew.buf = nil
+	}
+}
+
+func (ew *etagWriter) writeHead(code int) {
+	if ew.wroteHead {
+		return
+	}
+	ew.wroteHead = true
+	ew.ResponseWriter.WriteHeader(code)
+}
+
+// finish tags and sends the buffered response
+func (ew *etagWriter) finish() {
+	if ew.passing {
+		if ew.status != 0 {
+			ew.writeHead(ew.status)
+		}
+		return
+	}
+	if ew.status == 0 {
+		// nothing was written, let net/http send its default response
+		return
+	}
+
+	h := ew.Header()
+	tag := h.Get("ETag")
+	if tag == "" {
+		sum := sha256.Sum256(ew.buf)
+		tag = `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+		h.Set("ETag", tag)
+	}
+
+	if etagMatches(ew.r.Header.Get("If-None-Match"), tag) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		ew.writeHead(http.StatusNotModified)
+		return
+	}
+
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(ew.buf))
+	}
+	h.Set("Content-Length", strconv.Itoa(len(ew.buf)))
+	ew.writeHead(http.StatusOK)
+	ew.ResponseWriter.Write(ew.buf)
+}
+
+// etagMatches does the weak comparison If-None-Match asks for
+func etagMatches(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush implements http.Flusher. Streamed responses don't get an ETag.
+func (ew *etagWriter) Flush() {
+	if ew.status == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+	ew.pass()
+	if f, ok := ew.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, for websockets
+func (ew *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := ew.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("middleware: the ResponseWriter doesn't support hijacking")
+	}
+	ew.passing = true
+	ew.wroteHead = true
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (ew *etagWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello "))
+		w.Write([]byte("world"))
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 64)))
+	})
+	mux.HandleFunc("/own", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `W/"v1"`)
+		w.Write([]byte("versioned"))
+	})
+	mux.Handle("/skip", SkipETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("random"))
+	})))
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	})
+	h := ETag(ETagMaxSize(32))(mux)
+
+	get := func(method, path, inm string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if inm != "" {
+			req.Header.Set("If-None-Match", inm)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := get("GET", "/page", "")
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("hello world", rw.Body.String())
+	a.Equal("11", rw.Header().Get("Content-Length"))
+	tag := rw.Header().Get("ETag")
+	a.Regexp(`^"[\w-]{22}"$`, tag)
+	a.Equal(tag, get("GET", "/page", "").Header().Get("ETag"), "stable for the same body")
+
+	rw = get("GET", "/page", `"other", `+tag)
+	a.Equal(http.StatusNotModified, rw.Code)
+	a.Empty(rw.Body.String())
+	a.Equal(tag, rw.Header().Get("ETag"))
+	a.Equal(http.StatusNotModified, get("GET", "/page", "W/"+tag).Code)
+	a.Equal(http.StatusOK, get("GET", "/page", `"other"`).Code)
+
+	rw = get("GET", "/big", "")
+	a.Equal(http.StatusOK, rw.Code)
+	a.Len(rw.Body.String(), 64)
+	a.Empty(rw.Header().Get("ETag"), "too big")
+
+	rw = get("GET", "/own", `"v1"`)
+	a.Equal(http.StatusNotModified, rw.Code)
+	a.Equal(`W/"v1"`, rw.Header().Get("ETag"))
+
+	rw = get("GET", "/skip", "")
+	a.Equal(http.StatusAccepted, rw.Code)
+	a.Equal("random", rw.Body.String())
+	a.Empty(rw.Header().Get("ETag"))
+
+	rw = get("GET", "/missing", "*")
+	a.Equal(http.StatusNotFound, rw.Code)
+	a.Empty(rw.Header().Get("ETag"))
+
+	rw = get("POST", "/page", "*")
+	a.Equal(http.StatusOK, rw.Code)
+	a.Empty(rw.Header().Get("ETag"))
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// CanonicalOption changes how CanonicalHost works
+type CanonicalOption func(*canonicalHost)
+
+// CanonicalScheme sets the scheme of the redirects. By default it's https for TLS requests and http otherwise,
+// sites behind a TLS terminating proxy should set it to https.
+func CanonicalScheme(scheme string) CanonicalOption {
+	return func(ch *canonicalHost) {
+		ch.scheme = scheme
+	}
+}
+
+// CanonicalExcept serves requests for the hosts as they are, like localhost or the internal name health checks use
+func CanonicalExcept(hosts ...string) CanonicalOption {
+	return func(ch *canonicalHost) {
+		for _, h := range hosts {
+			ch.except = append(ch.except, normalizeHost(h))
+		}
+	}
+}
+
+type canonicalHost struct {
+	host   string
+	scheme string
+	except []string
+}
+
+// CanonicalHost redirects requests for all other hosts (like www. or an old domain) to host, keeping the path and query.
+// It uses 301 Moved Permanently (308 Permanent Redirect for methods other than GET and HEAD, so the body is sent again).
+// If host has no port, the port of the request is ignored in the comparison.
+func CanonicalHost(host string, opts ...CanonicalOption) func(http.Handler) http.Handler {
+	ch := canonicalHost{host: normalizeHost(host)}
+	for _, o := range opts {
+		o(&ch)
+	}
+	_, _, err := net.SplitHostPort(ch.host)
+	withPort := err == nil
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqHost := normalizeHost(r.Host)
+			if !withPort {
+				if h, _, err := net.SplitHostPort(reqHost); err == nil {
+					reqHost = h
+				}
+			}
+			if reqHost == ch.host || containsString(ch.except, reqHost) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme := ch.scheme
+			if scheme == "" {
+				scheme = "http"
+				if r.TLS != nil {
+					scheme = "https"
+				}
+			}
+
+			code := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				code = http.StatusPermanentRedirect
+			}
+			w.Header().Set("Location", scheme+"://"+ch.host+r.URL.RequestURI())
+			w.WriteHeader(code)
+		})
+	}
+}
+
+// normalizeHost lowercases the host and removes the dot of fully qualified names
+func normalizeHost(h string) string {
+	h = strings.ToLower(h)
+	if host, port, err := net.SplitHostPort(h); err == nil {
+		return net.JoinHostPort(strings.TrimSuffix(host, "."), port)
+	}
+	return strings.TrimSuffix(h, ".")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	h := CanonicalHost("example.com", CanonicalExcept("localhost"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tcases := []struct {
+		method, host, target string
+		tls                  bool
+		code                 int
+		location             string
+	}{
+		{"GET", "example.com", "/", false, 200, ""},
+		{"GET", "Example.COM.", "/", false, 200, ""},
+		{"GET", "example.com:8080", "/", false, 200, ""},
+		{"GET", "localhost:3000", "/", false, 200, ""},
+		{"GET", "www.example.com", "/posts/23?page=2", false, 301, "http://example.com/posts/23?page=2"},
+		{"GET", "old.example", "/", true, 301, "https://example.com/"},
+		{"POST", "www.example.com", "/login", true, 308, "https://example.com/login"},
+	}
+	for _, tc := range tcases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		req.Host = tc.host
+		if tc.tls {
+			req.TLS = &tls.ConnectionState{}
+		} else {
+			req.TLS = nil
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		assert.Equal(t, tc.code, rw.Code, "%s %s", tc.host, tc.target)
+		assert.Equal(t, tc.location, rw.Header().Get("Location"), "%s %s", tc.host, tc.target)
+	}
+}
+
+func TestCanonicalHostScheme(t *testing.T) {
+	a := assert.New(t)
+
+	h := CanonicalHost("example.com:8443", CanonicalScheme("https"))(http.NotFoundHandler())
+
+	req := httptest.NewRequest("GET", "/a", nil)
+	req.Host = "example.com"
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal(http.StatusMovedPermanently, rw.Code)
+	a.Equal("https://example.com:8443/a", rw.Header().Get("Location"))
+}
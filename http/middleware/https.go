@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mindeco.de/http/clientip"
+)
+
+// HTTPSOption changes how RequireHTTPS works
+type HTTPSOption func(*httpsEnforcer)
+
+// HTTPSExcept serves plaintext requests below the path prefixes as they are.
+// The ACME HTTP-01 challenges (/.well-known/acme-challenge/) are always excepted.
+func HTTPSExcept(prefixes ...string) HTTPSOption {
+	return func(he *httpsEnforcer) {
+		he.except = append(he.except, prefixes...)
+	}
+}
+
+// HTTPSStrictTransport adds a Strict-Transport-Security header with maxAge to secure responses.
+// Sites that use SecurityHeaders already get one from there.
+func HTTPSStrictTransport(maxAge time.Duration, includeSubdomains bool) HTTPSOption {
+	return func(he *httpsEnforcer) {
+		he.hsts = "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+		if includeSubdomains {
+			he.hsts += "; includeSubDomains"
+		}
+	}
+}
+
+type httpsEnforcer struct {
+	proxies *clientip.Resolver
+	except  []string
+	hsts    string
+}
+
+// RequireHTTPS redirects plaintext requests to https, with 301 Moved Permanently (308 Permanent Redirect for methods other than GET and HEAD).
+// Requests are secure if they came in over TLS or if a trusted proxy of proxies says so with X-Forwarded-Proto or Forwarded.
+// proxies can be nil if the server terminates TLS itself.
+func RequireHTTPS(proxies *clientip.Resolver, opts ...HTTPSOption) func(http.Handler) http.Handler {
+	he := httpsEnforcer{
+		proxies: proxies,
+		except:  []string{"/.well-known/acme-challenge/"},
+	}
+	for _, o := range opts {
+		o(&he)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if he.secure(r) {
+				if he.hsts != "" {
+					w.Header().Set("Strict-Transport-Security", he.hsts)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for _, p := range he.except {
+				if strings.HasPrefix(r.URL.Path, p) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			host := r.Host
+			if h, port, err := net.SplitHostPort(host); err == nil && port == "80" {
+				host = h
+			}
+			code := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				code = http.StatusPermanentRedirect
+			}
+			w.Header().Set("Location", "https://"+host+r.URL.RequestURI())
+			w.WriteHeader(code)
+		})
+	}
+}
+
+func (he httpsEnforcer) secure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if he.proxies == nil || !he.proxies.Trusted(r) {
+		return false
+	}
+
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		first, _, _ := strings.Cut(proto, ",")
+		return strings.EqualFold(strings.TrimSpace(first), "https")
+	}
+	if fwd := r.Header.Get("Forwarded"); fwd != "" {
+		first, _, _ := strings.Cut(fwd, ",")
+		for _, pair := range strings.Split(first, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(k, "proto") {
+				return strings.EqualFold(strings.Trim(v, `"`), "https")
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mindeco.de/http/clientip"
+)
+
+func TestRequireHTTPS(t *testing.T) {
+	rs, err := clientip.NewResolver([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	h := RequireHTTPS(rs, HTTPSExcept("/healthz"), HTTPSStrictTransport(24*time.Hour, true))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tcases := []struct {
+		name     string
+		method   string
+		target   string
+		remote   string
+		tls      bool
+		header   map[string]string
+		code     int
+		location string
+		hsts     bool
+	}{
+		{"plain", "GET", "http://example.com/posts?page=2", "192.0.2.1:1234", false, nil, 301, "https://example.com/posts?page=2", false},
+		{"port 80", "GET", "http://example.com:80/", "192.0.2.1:1234", false, nil, 301, "https://example.com/", false},
+		{"post", "POST", "http://example.com/login", "192.0.2.1:1234", false, nil, 308, "https://example.com/login", false},
+		{"tls", "GET", "https://example.com/", "192.0.2.1:1234", true, nil, 200, "", true},
+		{"trusted proxy", "GET", "http://example.com/", "10.0.0.1:1234", false, map[string]string{"X-Forwarded-Proto": "https"}, 200, "", true},
+		{"trusted forwarded", "GET", "http://example.com/", "10.0.0.1:1234", false, map[string]string{"Forwarded": "for=192.0.2.1;proto=https"}, 200, "", true},
+		{"trusted proxy plain", "GET", "http://example.com/", "10.0.0.1:1234", false, map[string]string{"X-Forwarded-Proto": "http"}, 301, "https://example.com/", false},
+		{"untrusted proxy", "GET", "http://example.com/", "192.0.2.1:1234", false, map[string]string{"X-Forwarded-Proto": "https"}, 301, "https://example.com/", false},
+		{"acme", "GET", "http://example.com/.well-known/acme-challenge/token", "192.0.2.1:1234", false, nil, 200, "", false},
+		{"excepted", "GET", "http://example.com/healthz", "192.0.2.1:1234", false, nil, 200, "", false},
+	}
+	for _, tc := range tcases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		req.RemoteAddr = tc.remote
+		if !tc.tls {
+			req.TLS = nil
+		} else {
+			req.TLS = &tls.ConnectionState{}
+		}
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		assert.Equal(t, tc.code, rw.Code, tc.name)
+		assert.Equal(t, tc.location, rw.Header().Get("Location"), tc.name)
+		if tc.hsts {
+			assert.Equal(t, "max-age=86400; includeSubDomains", rw.Header().Get("Strict-Transport-Security"), tc.name)
+		} else {
+			assert.Empty(t, rw.Header().Get("Strict-Transport-Security"), tc.name)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.mindeco.de/http/render"
+)
+
+// MaintenanceSwitch is a toggle for Maintenance that can be flipped at runtime, for instance from an admin endpoint or a signal handler.
+// The zero value is switched off.
+type MaintenanceSwitch struct {
+	on int32
+}
+
+// Enable turns maintenance mode on
+func (s *MaintenanceSwitch) Enable() { atomic.StoreInt32(&s.on, 1) }
+
+// Disable turns maintenance mode off
+func (s *MaintenanceSwitch) Disable() { atomic.StoreInt32(&s.on, 0) }
+
+// Enabled reports whether maintenance mode is on. Pass it to Maintenance.
+func (s *MaintenanceSwitch) Enabled() bool { return atomic.LoadInt32(&s.on) == 1 }
+
+// MaintenanceOption changes how Maintenance works
+type MaintenanceOption func(*maintenance)
+
+// MaintenanceAllow keeps serving the paths, like health checks or the admin area, while maintenance mode is on.
+// Paths ending in a slash match everything below them, like with http.ServeMux.
+func MaintenanceAllow(paths ...string) MaintenanceOption {
+	return func(m *maintenance) {
+		m.allow = append(m.allow, paths...)
+	}
+}
+
+// MaintenanceRetryAfter sets the Retry-After header of the responses, it's left out by default
+func MaintenanceRetryAfter(d time.Duration) MaintenanceOption {
+	return func(m *maintenance) {
+		m.retryAfter = d
+	}
+}
+
+// MaintenanceTemplate renders the named template of r instead of a plain text response.
+// It gets the StatusCode, Status and RetryAfter (a time.Duration) as data.
+func MaintenanceTemplate(r *render.Renderer, name string) MaintenanceOption {
+	return func(m *maintenance) {
+		m.renderer = r
+		m.template = name
+	}
+}
+
+type maintenance struct {
+	allow      []string
+	retryAfter time.Duration
+	renderer   *render.Renderer
+	template   string
+}
+
+// Maintenance answers all requests with 503 Service Unavailable while enabled returns true,
+// so that traffic can be drained during migrations. MaintenanceSwitch.Enabled fits as a toggle.
+func Maintenance(enabled func() bool, opts ...MaintenanceOption) func(http.Handler) http.Handler {
+	var m maintenance
+	for _, o := range opts {
+		o(&m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !enabled() || m.allowed(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("Cache-Control", "no-store")
+			if m.retryAfter > 0 {
+				h.Set("Retry-After", strconv.Itoa(int(m.retryAfter/time.Second)))
+			}
+
+			if m.renderer == nil {
+				http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+				return
+			}
+
+			status := http.StatusServiceUnavailable
+			err := m.renderer.Render(w, r, m.template, status, map[string]interface{}{
+				"StatusCode": status,
+				"Status":     http.StatusText(status),
+				"RetryAfter": m.retryAfter,
+			})
+			if err != nil {
+				logger(r.Context()).Log("event", "error", "msg", "rendering the maintenance template failed", "err", err)
+				http.Error(w, "down for maintenance", status)
+			}
+		})
+	}
+}
+
+func (m maintenance) allowed(path string) bool {
+	for _, p := range m.allow {
+		if p == path || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mindeco.de/http/render"
+	kitlog "go.mindeco.de/log"
+)
+
+func TestMaintenance(t *testing.T) {
+	a := assert.New(t)
+
+	r, err := render.New(http.Dir("testdata"), render.AddTemplates("/maintenance.tmpl"), render.SetLogger(kitlog.NewNopLogger()))
+	require.NoError(t, err)
+
+	var sw MaintenanceSwitch
+	h := Maintenance(sw.Enabled,
+		MaintenanceAllow("/healthz", "/admin/"),
+		MaintenanceRetryAfter(5*time.Minute),
+		MaintenanceTemplate(r, "/maintenance.tmpl"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", path, nil))
+		return rw
+	}
+
+	a.Equal(http.StatusOK, get("/page").Code)
+
+	sw.Enable()
+	rw := get("/page")
+	a.Equal(http.StatusServiceUnavailable, rw.Code)
+	a.Equal("300", rw.Header().Get("Retry-After"))
+	a.Equal("no-store", rw.Header().Get("Cache-Control"))
+	doc, err := goquery.NewDocumentFromReader(rw.Body)
+	require.NoError(t, err)
+	a.Equal("Maintenance", doc.Find("title").Text())
+	a.Equal("5m0s", doc.Find("#retry").Text())
+
+	a.Equal(http.StatusOK, get("/healthz").Code)
+	a.Equal(http.StatusOK, get("/admin/migrations").Code)
+	a.Equal(http.StatusServiceUnavailable, get("/healthz/deep").Code)
+	a.Equal(http.StatusServiceUnavailable, get("/admin").Code)
+
+	sw.Disable()
+	a.Equal(http.StatusOK, get("/page").Code)
+}
+
+func TestMaintenancePlain(t *testing.T) {
+	a := assert.New(t)
+
+	h := Maintenance(func() bool { return true })(http.NotFoundHandler())
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal(http.StatusServiceUnavailable, rw.Code)
+	a.Empty(rw.Header().Get("Retry-After"))
+	a.Equal("down for maintenance", strings.TrimSpace(rw.Body.String()))
+}
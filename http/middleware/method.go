@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	// MethodOverrideField is the form field MethodOverride reads, as in
+	//	<form method="POST" action="/posts/23">
+	//		<input type="hidden" name="_method" value="DELETE">
+	//	</form>
+	MethodOverrideField = "_method"
+
+	// MethodOverrideHeader is checked before the form field, for scripts
+	MethodOverrideHeader = "X-HTTP-Method-Override"
+)
+
+// MethodOverride changes the method of POST requests to PUT, PATCH or DELETE if the header or form field asks for it,
+// since HTML forms can only GET and POST. Other methods and other values are ignored.
+//
+// To read the field it parses the form of url-encoded and multipart bodies.
+func MethodOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			m := r.Header.Get(MethodOverrideHeader)
+			if m == "" && isForm(r.Header.Get("Content-Type")) {
+				m = r.PostFormValue(MethodOverrideField)
+			}
+
+			switch m = strings.ToUpper(m); m {
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				r.Method = m
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isForm(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/x-www-form-urlencoded" || mt == "multipart/form-data"
+}
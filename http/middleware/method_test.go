@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodOverride(t *testing.T) {
+	h := MethodOverride(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.PostFormValue("title")))
+	}))
+
+	tcases := []struct {
+		method, body, header string
+		want                 string
+	}{
+		{"POST", "_method=DELETE", "", "DELETE "},
+		{"POST", "_method=put&title=hi", "", "PUT hi"},
+		{"POST", "title=hi", "PATCH", "PATCH hi"},
+		{"POST", "_method=DELETE", "PUT", "PUT "},
+		{"POST", "_method=GET", "", "POST "},
+		{"POST", "_method=CONNECT", "", "POST "},
+		{"GET", "", "DELETE", "GET "},
+	}
+	for _, tc := range tcases {
+		req := httptest.NewRequest(tc.method, "/posts/23", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tc.header != "" {
+			req.Header.Set(MethodOverrideHeader, tc.header)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		assert.Equal(t, tc.want, rw.Body.String(), "%+v", tc)
+	}
+
+	req := httptest.NewRequest("POST", "/api", strings.NewReader(`{"_method":"DELETE"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	assert.Equal(t, "POST ", rw.Body.String(), "JSON bodies are left alone")
+}
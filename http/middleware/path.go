@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"go.mindeco.de/http/render"
+)
+
+// ErrBadPath is passed to the error handler of NormalizePath for paths with (encoded) traversal sequences
+var ErrBadPath = errors.New("invalid path")
+
+// TrailingSlash says what NormalizePath does with slashes at the end of paths
+type TrailingSlash int
+
+const (
+	// KeepTrailingSlash leaves them as they are
+	KeepTrailingSlash TrailingSlash = iota
+
+	// StripTrailingSlash redirects /posts/ to /posts
+	StripTrailingSlash
+
+	// AddTrailingSlash redirects /posts to /posts/. Paths that look like files (/style.css) are left alone.
+	AddTrailingSlash
+)
+
+// PathOption changes how NormalizePath works
+type PathOption func(*pathNormalizer)
+
+// PathTrailingSlash sets what happens with trailing slashes, they are kept by default
+func PathTrailingSlash(ts TrailingSlash) PathOption {
+	return func(pn *pathNormalizer) {
+		pn.trailing = ts
+	}
+}
+
+// PathErrorHandler sets how rejected paths are answered, with a plain text 400 by default.
+// render.Renderer.Error fits, to show the error template.
+func PathErrorHandler(fn render.ErrorHandlerFunc) PathOption {
+	return func(pn *pathNormalizer) {
+		pn.errHandler = fn
+	}
+}
+
+type pathNormalizer struct {
+	trailing   TrailingSlash
+	errHandler render.ErrorHandlerFunc
+}
+
+// NormalizePath should be in front of the router. It rejects paths with traversal sequences (../ and encoded slashes or dots)
+// and redirects paths with duplicate slashes, or the wrong trailing slash, to their canonical form
+// with 301 Moved Permanently (308 Permanent Redirect for methods other than GET and HEAD, so the body is sent again).
+func NormalizePath(opts ...PathOption) func(http.Handler) http.Handler {
+	pn := pathNormalizer{
+		errHandler: func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			http.Error(w, err.Error(), status)
+		},
+	}
+	for _, o := range opts {
+		o(&pn)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hasTraversal(r.URL.EscapedPath()) {
+				pn.errHandler(w, r, http.StatusBadRequest, ErrBadPath)
+				return
+			}
+
+			p := pn.normalize(r.URL.Path)
+			if p == r.URL.Path {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			u := *r.URL
+			u.Path, u.RawPath = p, ""
+			code := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				code = http.StatusPermanentRedirect
+			}
+			// not http.Redirect, it would clean the path again and make relative paths absolute
+			w.Header().Set("Location", u.RequestURI())
+			w.WriteHeader(code)
+		})
+	}
+}
+
+func (pn pathNormalizer) normalize(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' && i > 0 && p[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(p[i])
+	}
+	p = b.String()
+
+	if p == "/" {
+		return p
+	}
+	switch pn.trailing {
+	case StripTrailingSlash:
+		p = strings.TrimSuffix(p, "/")
+	case AddTrailingSlash:
+		if !strings.HasSuffix(p, "/") && !strings.Contains(path.Base(p), ".") {
+			p += "/"
+		}
+	}
+	return p
+}
+
+// hasTraversal checks the escaped path for dot segments and encoded dots, slashes, backslashes and NUL bytes
+func hasTraversal(escaped string) bool {
+	lower := strings.ToLower(escaped)
+	for _, enc := range []string{"%2e", "%2f", "%5c", "%00"} {
+		if strings.Contains(lower, enc) {
+			return true
+		}
+	}
+	for _, seg := range strings.Split(lower, "/") {
+		if seg == ".." || seg == "." || strings.Contains(seg, `\`) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePath(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})
+
+	tcases := []struct {
+		mode     TrailingSlash
+		method   string
+		target   string
+		code     int
+		location string
+	}{
+		{KeepTrailingSlash, "GET", "/posts/", 200, ""},
+		{KeepTrailingSlash, "GET", "/", 200, ""},
+		{KeepTrailingSlash, "GET", "//posts///23?x=1", 301, "/posts/23?x=1"},
+		{KeepTrailingSlash, "POST", "/posts//23", 308, "/posts/23"},
+		{StripTrailingSlash, "GET", "/posts/", 301, "/posts"},
+		{StripTrailingSlash, "GET", "/", 200, ""},
+		{StripTrailingSlash, "GET", "/posts", 200, ""},
+		{AddTrailingSlash, "GET", "/posts", 301, "/posts/"},
+		{AddTrailingSlash, "GET", "/static/style.css", 200, ""},
+		{AddTrailingSlash, "GET", "/posts/", 200, ""},
+		{KeepTrailingSlash, "GET", "/static/%2e%2e/secret", 400, ""},
+		{KeepTrailingSlash, "GET", "/static/..%2fsecret", 400, ""},
+		{KeepTrailingSlash, "GET", "/static/%2E./secret", 400, ""},
+		{KeepTrailingSlash, "GET", "/static/..%5csecret", 400, ""},
+		{KeepTrailingSlash, "GET", "/files/a%00b", 400, ""},
+		{KeepTrailingSlash, "GET", "/hello%20world", 200, ""},
+	}
+	for _, tc := range tcases {
+		h := NormalizePath(PathTrailingSlash(tc.mode))(ok)
+		req := httptest.NewRequest(tc.method, "/", nil)
+		u, err := url.ParseRequestURI(tc.target)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		req.URL = u
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		assert.Equal(t, tc.code, rw.Code, "%s %s", tc.method, tc.target)
+		assert.Equal(t, tc.location, rw.Header().Get("Location"), "%s %s", tc.method, tc.target)
+	}
+}
+
+func TestNormalizePathDotSegments(t *testing.T) {
+	h := NormalizePath()(http.NotFoundHandler())
+
+	// httptest.NewRequest would resolve them already
+	req := httptest.NewRequest("GET", "/", nil)
+	req.URL.Path = "/static/../secret"
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+}
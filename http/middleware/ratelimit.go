@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mindeco.de/http/clientip"
+	"go.mindeco.de/http/render"
+)
+
+// Rate is a token bucket: it allows bursts of Burst requests and refills one token every Every
+type Rate struct {
+	Burst int
+	Every time.Duration
+}
+
+// PerMinute returns a Rate of n requests per minute, with bursts of up to n. It panics if n isn't positive.
+func PerMinute(n int) Rate {
+	if n <= 0 {
+		panic(fmt.Sprintf("middleware: PerMinute needs a positive number of requests, not %d", n))
+	}
+	return Rate{Burst: n, Every: time.Minute / time.Duration(n)}
+}
+
+// Validate returns an error if Burst or Every aren't positive
+func (r Rate) Validate() error {
+	if r.Burst <= 0 || r.Every <= 0 {
+		return fmt.Errorf("middleware: invalid rate %+v", r)
+	}
+	return nil
+}
+
+// RateStore keeps the buckets of RateLimit. MemRateStore works for single instances,
+// deployments with multiple instances need a shared one (like redis).
+type RateStore interface {
+	// Take removes a token from the bucket of key.
+	// If it is empty it returns false and how long it takes until the next token is available.
+	Take(key string, rate Rate, now time.Time) (ok bool, retryAfter time.Duration, err error)
+}
+
+// ErrRateLimited is passed to the error handler of RateLimit
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (e ErrRateLimited) Error() string {
+	return fmt.Sprintf("too many requests, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// RateLimitOption changes how RateLimit works
+type RateLimitOption func(*rateLimiter)
+
+// LimitBy sets the key of the buckets, the client IP (see ClientIP) by default.
+// For instance the user of auth.FromContext, if RateLimit is behind auth.Authenticate.
+// Requests for which fn returns an empty string are not limited.
+func LimitBy(fn func(*http.Request) string) RateLimitOption {
+	return func(rl *rateLimiter) {
+		rl.key = fn
+	}
+}
+
+// LimitStore sets the store of the buckets, a MemRateStore by default
+func LimitStore(s RateStore) RateLimitOption {
+	return func(rl *rateLimiter) {
+		rl.store = s
+	}
+}
+
+// LimitErrorHandler sets how limited requests are answered, with a plain text 429 by default.
+// render.Renderer.Error fits, to show the error template.
+func LimitErrorHandler(fn render.ErrorHandlerFunc) RateLimitOption {
+	return func(rl *rateLimiter) {
+		rl.errHandler = fn
+	}
+}
+
+type rateLimiter struct {
+	rate       Rate
+	key        func(*http.Request) string
+	store      RateStore
+	errHandler render.ErrorHandlerFunc
+}
+
+// RateLimit answers requests with 429 Too Many Requests (and a Retry-After header) once their client used up its bucket.
+// If the store fails the request is served and the error is logged, so that a broken store doesn't take down the site.
+// It panics if the rate is invalid, instead of failing every request later.
+func RateLimit(rate Rate, opts ...RateLimitOption) func(http.Handler) http.Handler {
+	if err := rate.Validate(); err != nil {
+		panic(err)
+	}
+	rl := rateLimiter{
+		rate:  rate,
+		key:   clientip.FromRequest,
+		store: NewMemRateStore(),
+		errHandler: func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			http.Error(w, err.Error(), status)
+		},
+	}
+	for _, o := range opts {
+		o(&rl)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := rl.key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ok, retry, err := rl.store.Take(key, rl.rate, time.Now())
+			if err != nil {
+				logger(r.Context()).Log("event", "error", "msg", "rate limit store failed", "err", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !ok {
+				secs := int(math.Ceil(retry.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+				rl.errHandler(w, r, http.StatusTooManyRequests, ErrRateLimited{RetryAfter: retry})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MemRateStore is an in-memory RateStore.
+// Buckets that are full again are removed from time to time, so it doesn't grow with every client it ever saw.
+type MemRateStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemRateStore returns an empty MemRateStore
+func NewMemRateStore() *MemRateStore {
+	return &MemRateStore{buckets: make(map[string]*bucket)}
+}
+
+// Take implements RateStore
+func (ms *MemRateStore) Take(key string, rate Rate, now time.Time) (bool, time.Duration, error) {
+	if err := rate.Validate(); err != nil {
+		return false, 0, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.takes++
+	if ms.takes%1000 == 0 {
+		ms.prune(rate, now)
+	}
+
+	b, has := ms.buckets[key]
+	if !has {
+		b = &bucket{tokens: float64(rate.Burst), last: now}
+		ms.buckets[key] = b
+	}
+
+	b.tokens = refill(b.tokens, b.last, rate, now)
+	b.last = now
+	if b.tokens < 1 {
+		missing := 1 - b.tokens
+		return false, time.Duration(missing * float64(rate.Every)), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+func (ms *MemRateStore) prune(rate Rate, now time.Time) {
+	for k, b := range ms.buckets {
+		if refill(b.tokens, b.last, rate, now) >= float64(rate.Burst) {
+			delete(ms.buckets, k)
+		}
+	}
+}
+
+func refill(tokens float64, last time.Time, rate Rate, now time.Time) float64 {
+	tokens += float64(now.Sub(last)) / float64(rate.Every)
+	return math.Min(tokens, float64(rate.Burst))
+}
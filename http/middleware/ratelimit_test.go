@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+func TestMemRateStore(t *testing.T) {
+	a := assert.New(t)
+
+	ms := NewMemRateStore()
+	rate := Rate{Burst: 2, Every: time.Second}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		ok, _, err := ms.Take("a", rate, now)
+		a.NoError(err)
+		a.True(ok)
+	}
+	ok, retry, err := ms.Take("a", rate, now)
+	a.NoError(err)
+	a.False(ok)
+	a.Equal(time.Second, retry)
+
+	ok, _, _ = ms.Take("b", rate, now)
+	a.True(ok, "other keys have their own bucket")
+
+	ok, retry, _ = ms.Take("a", rate, now.Add(750*time.Millisecond))
+	a.False(ok)
+	a.Equal(250*time.Millisecond, retry)
+
+	ok, _, _ = ms.Take("a", rate, now.Add(time.Second))
+	a.True(ok)
+
+	_, _, err = ms.Take("a", Rate{}, now)
+	a.Error(err)
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(string, Rate, time.Time) (bool, time.Duration, error) {
+	return false, 0, errors.New("store down")
+}
+
+func TestRateLimit(t *testing.T) {
+	a := assert.New(t)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := RateLimit(Rate{Burst: 1, Every: time.Minute})(ok)
+
+	send := func(h http.Handler, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw
+	}
+
+	a.Equal(http.StatusOK, send(h, "10.0.0.1:1234").Code)
+	rw := send(h, "10.0.0.1:5678")
+	a.Equal(http.StatusTooManyRequests, rw.Code)
+	a.Equal("60", rw.Header().Get("Retry-After"))
+	a.Contains(rw.Body.String(), "too many requests, retry in 1m0s")
+	a.Equal(http.StatusOK, send(h, "10.0.0.2:1234").Code)
+
+	var gotErr error
+	h = RateLimit(Rate{Burst: 1, Every: time.Minute},
+		LimitBy(func(r *http.Request) string { return r.Header.Get("X-User") }),
+		LimitErrorHandler(func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			gotErr = err
+			w.WriteHeader(status)
+		}),
+	)(ok)
+	a.Equal(http.StatusOK, send(h, "10.0.0.1:1").Code, "empty keys are not limited")
+	a.Equal(http.StatusOK, send(h, "10.0.0.1:1").Code)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-User", "alice")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal(http.StatusTooManyRequests, rw.Code)
+	var limited ErrRateLimited
+	if a.True(errors.As(gotErr, &limited)) {
+		a.True(limited.RetryAfter > 59*time.Second)
+	}
+
+	var buf bytes.Buffer
+	h = logging.InjectHandler(kitlog.NewLogfmtLogger(&buf))(RateLimit(PerMinute(1), LimitStore(failingStore{}))(ok))
+	a.Equal(http.StatusOK, send(h, "10.0.0.1:1").Code, "should fail open")
+	a.Contains(buf.String(), `msg="rate limit store failed" err="store down"`)
+
+	// misconfigured limits fail at setup, not by silently serving everything
+	a.Panics(func() { RateLimit(Rate{Burst: 0, Every: time.Second}) })
+	a.Panics(func() { RateLimit(Rate{Burst: 5}) })
+	a.Panics(func() { PerMinute(0) })
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"go.mindeco.de/http/render"
+)
+
+// RecoverOption changes how Recover responds
+type RecoverOption func(*recoverer)
+
+// RecoverJSONFor sets which requests get a JSON error instead of the error template.
+// By default these are the ones that accept application/json but not text/html.
+func RecoverJSONFor(fn func(*http.Request) bool) RecoverOption {
+	return func(rc *recoverer) {
+		rc.isAPI = fn
+	}
+}
+
+// PanicError is a panic that was recovered in another goroutine, with the stack it happened on.
+// Timeout re-panics with it, so that Recover logs the stack of the handler and not that of the re-panic.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (pe *PanicError) Error() string {
+	return fmt.Sprint(pe.Value)
+}
+
+type recoverer struct {
+	r     *render.Renderer
+	isAPI func(*http.Request) bool
+}
+
+// Recover catches panics of the handlers behind it and logs them with their stack and the request ID.
+// It responds with a 500 using the error template of r (which can be nil for a plain text response) or JSON for API requests (see RecoverJSONFor).
+// The panic value is not shown to the client, only the request ID.
+// Nothing is sent if the handler already started its response and http.ErrAbortHandler is passed on.
+func Recover(r *render.Renderer, opts ...RecoverOption) func(http.Handler) http.Handler {
+	rc := recoverer{r: r, isAPI: acceptsJSON}
+	for _, o := range opts {
+		o(&rc)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			sw := &StatusWriter{ResponseWriter: w}
+			defer func() {
+				rv := recover()
+				if rv == nil {
+					return
+				}
+				if rv == http.ErrAbortHandler {
+					panic(rv)
+				}
+				rc.handle(sw, req, rv)
+			}()
+			next.ServeHTTP(sw, req)
+		})
+	}
+}
+
+func (rc recoverer) handle(sw *StatusWriter, req *http.Request, rv interface{}) {
+	id := FromContext(req.Context())
+
+	stack := debug.Stack()
+	if pe, ok := rv.(*PanicError); ok {
+		rv, stack = pe.Value, pe.Stack
+	}
+
+	l := logger(req.Context())
+	l.Log("event", "panic", "method", req.Method, "path", req.URL.Path, "panic", fmt.Sprint(rv), "stack", string(stack))
+
+	if sw.Status() != 0 {
+		// too late to send an error
+		return
+	}
+
+	msg := "internal server error"
+	if id != "" {
+		msg += " (request " + id + ")"
+	}
+
+	switch {
+	case rc.isAPI(req):
+		sw.Header().Set("Content-Type", "application/json")
+		sw.Header().Set("Cache-Control", "no-cache")
+		sw.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(sw).Encode(struct {
+			Error     string `json:"error"`
+			RequestID string `json:"requestID,omitempty"`
+		}{"internal server error", id})
+
+	case rc.r != nil:
+		rc.r.Error(sw, req, http.StatusInternalServerError, errors.New(msg))
+
+	default:
+		http.Error(sw, msg, http.StatusInternalServerError)
+	}
+}
+
+func acceptsJSON(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
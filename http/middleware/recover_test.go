@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mindeco.de/http/render"
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+func panics(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func TestRecoverRenders(t *testing.T) {
+	a := assert.New(t)
+
+	r, err := render.New(http.Dir("testdata"), render.AddTemplates("/error.tmpl"), render.SetLogger(kitlog.NewNopLogger()))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	inject := logging.InjectHandler(kitlog.NewLogfmtLogger(&buf))
+	h := inject(RequestID(Recover(r)(http.HandlerFunc(panics))))
+
+	req := httptest.NewRequest("GET", "/page", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal(http.StatusInternalServerError, rw.Code)
+
+	doc, err := goquery.NewDocumentFromReader(rw.Body)
+	require.NoError(t, err)
+	a.Equal("Error 500", doc.Find("title").Text())
+	a.Equal("internal server error (request abc)", doc.Find("#errBody").Text())
+
+	a.Contains(buf.String(), "urlPath=/page reqID=abc event=panic method=GET path=/page panic=boom stack=")
+	a.Contains(buf.String(), "recover_test.go")
+}
+
+func TestRecoverJSON(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	inject := logging.InjectHandler(kitlog.NewLogfmtLogger(&buf))
+	h := RequestID(inject(Recover(nil)(http.HandlerFunc(panics))))
+
+	req := httptest.NewRequest("GET", "/api", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	req.Header.Set("Accept", "application/json")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal(http.StatusInternalServerError, rw.Code)
+	a.Equal("application/json", rw.Header().Get("Content-Type"))
+	a.JSONEq(`{"error":"internal server error","requestID":"abc"}`, rw.Body.String())
+	a.Contains(buf.String(), "urlPath=/api reqID=abc event=panic", "should add the ID to a logger injected after RequestID")
+
+	// plain text without a Renderer
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal(http.StatusInternalServerError, rw.Code)
+	a.Contains(rw.Body.String(), "internal server error (request ")
+}
+
+func TestRecoverAfterWrite(t *testing.T) {
+	a := assert.New(t)
+
+	nop := logging.InjectHandler(kitlog.NewNopLogger())
+	h := nop(Recover(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		panic("late")
+	})))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal(http.StatusAccepted, rw.Code)
+	a.Equal("partial", rw.Body.String())
+
+	a.Panics(func() {
+		Recover(nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
@@ -0,0 +1,91 @@
+// Package middleware has http.Handler wrappers that most of our services use, like request IDs and access logging.
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+// RequestIDHeader is read to propagate the ID of a request and set on the response
+const RequestIDHeader = "X-Request-Id"
+
+// incoming IDs which are longer than this are replaced
+const maxRequestIDLength = 128
+
+type ctxKey struct{}
+
+// set if the logger in the context already has the request ID
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx which carries the request ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the ID that was stored by RequestID or an empty string
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// RequestID gives every request an ID, which is taken from the X-Request-Id header (if it is set by a proxy) or generated.
+// It is stored in the context of the request (see FromContext) and set as the X-Request-Id header of the response.
+// If there already is a logger in the context (see logging.InjectHandler), the ID is added to it as reqID.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		ctx := NewContext(r.Context(), id)
+		if l := logging.FromContext(ctx); l != nil {
+			ctx = logging.NewContext(ctx, kitlog.With(l, "reqID", id))
+			ctx = context.WithValue(ctx, loggerKey{}, true)
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("middleware: failed to read random request ID: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// validRequestID only accepts short, printable ASCII IDs, so that they can't inject anything into logs or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// logger returns the logger of the context (or a new one for the package) which includes the request ID, if there is one
+func logger(ctx context.Context) kitlog.Logger {
+	l := logging.FromContext(ctx)
+	if l == nil {
+		l = logging.Logger("middleware")
+	} else if has, _ := ctx.Value(loggerKey{}).(bool); has {
+		return l
+	}
+
+	if id := FromContext(ctx); id != "" {
+		l = kitlog.With(l, "reqID", id)
+	}
+	return l
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+func TestRequestID(t *testing.T) {
+	a := assert.New(t)
+
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, FromContext(r.Context()))
+	}))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	generated := rw.Body.String()
+	a.Len(generated, 22)
+	a.Equal(generated, rw.Header().Get(RequestIDHeader))
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.NotEqual(generated, rw.Body.String(), "should be unique")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "from-the-proxy")
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	a.Equal("from-the-proxy", rw.Body.String())
+	a.Equal("from-the-proxy", rw.Header().Get(RequestIDHeader))
+
+	for _, bad := range []string{"with space", "new\nline", strings.Repeat("x", 129)} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, bad)
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		a.NotEqual(bad, rw.Body.String())
+		a.Len(rw.Body.String(), 22)
+	}
+
+	a.Equal("", FromContext(req.Context()))
+}
+
+func TestRequestIDLogger(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := kitlog.NewLogfmtLogger(&buf)
+
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Log("msg", "hello")
+	}))
+	h = logging.InjectHandler(logger)(h)
+
+	req := httptest.NewRequest("GET", "/x", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	a.Equal("urlPath=/x reqID=abc msg=hello\n", buf.String())
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SecurityConfig are the values of the headers SecurityHeaders sets. Empty (or zero) fields leave their header out.
+type SecurityConfig struct {
+	// HSTS is the max-age of Strict-Transport-Security
+	HSTS                  time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+
+	// NoSniff sets X-Content-Type-Options: nosniff
+	NoSniff bool
+
+	FrameOptions string // X-Frame-Options, like DENY or SAMEORIGIN
+
+	// FrameAncestors is set as the frame-ancestors of a Content-Security-Policy, like 'none' or 'self'.
+	// Apps with their own policy should leave it empty and include it in theirs.
+	FrameAncestors string
+
+	ReferrerPolicy    string
+	PermissionsPolicy string
+}
+
+// DefaultSecurity is what most of our sites should send: no framing, no sniffing and one year of HSTS
+var DefaultSecurity = SecurityConfig{
+	HSTS:                  365 * 24 * time.Hour,
+	HSTSIncludeSubdomains: true,
+	NoSniff:               true,
+	FrameOptions:          "DENY",
+	FrameAncestors:        "'none'",
+	ReferrerPolicy:        "strict-origin-when-cross-origin",
+	PermissionsPolicy:     "camera=(), microphone=(), geolocation=()",
+}
+
+type securityKey struct{}
+
+// SecurityHeaders sets the headers of cfg on every response.
+// Routes that need different values (like an embeddable widget) can change them with SecurityOverride.
+func SecurityHeaders(cfg SecurityConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg.apply(w.Header())
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), securityKey{}, cfg)))
+		})
+	}
+}
+
+// SecurityOverride changes the config of the SecurityHeaders in front of it for the wrapped route
+//
+//	mux.Handle("/embed", middleware.SecurityOverride(func(c *middleware.SecurityConfig) {
+//		c.FrameOptions = ""
+//		c.FrameAncestors = "https://partner.example"
+//	})(embedHandler))
+func SecurityOverride(fn func(*SecurityConfig)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg, ok := r.Context().Value(securityKey{}).(SecurityConfig)
+			if !ok {
+				cfg = DefaultSecurity
+			}
+			fn(&cfg)
+			cfg.apply(w.Header())
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), securityKey{}, cfg)))
+		})
+	}
+}
+
+func (cfg SecurityConfig) apply(h http.Header) {
+	hsts := ""
+	if cfg.HSTS > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTS/time.Second), 10)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	setOrDel(h, "Strict-Transport-Security", hsts)
+
+	nosniff := ""
+	if cfg.NoSniff {
+		nosniff = "nosniff"
+	}
+	setOrDel(h, "X-Content-Type-Options", nosniff)
+
+	setOrDel(h, "X-Frame-Options", cfg.FrameOptions)
+
+	csp := ""
+	if cfg.FrameAncestors != "" {
+		csp = "frame-ancestors " + cfg.FrameAncestors
+	}
+	setOrDel(h, "Content-Security-Policy", csp)
+
+	setOrDel(h, "Referrer-Policy", cfg.ReferrerPolicy)
+	setOrDel(h, "Permissions-Policy", cfg.PermissionsPolicy)
+}
+
+func setOrDel(h http.Header, k, v string) {
+	if v == "" {
+		h.Del(k)
+		return
+	}
+	h.Set(k, v)
+}
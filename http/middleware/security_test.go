@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	a := assert.New(t)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mux := http.NewServeMux()
+	mux.Handle("/", ok)
+	mux.Handle("/embed", SecurityOverride(func(c *SecurityConfig) {
+		c.FrameOptions = ""
+		c.FrameAncestors = "https://partner.example"
+		c.HSTSPreload = true
+	})(ok))
+	h := SecurityHeaders(DefaultSecurity)(mux)
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	hdr := rw.Header()
+	a.Equal("max-age=31536000; includeSubDomains", hdr.Get("Strict-Transport-Security"))
+	a.Equal("nosniff", hdr.Get("X-Content-Type-Options"))
+	a.Equal("DENY", hdr.Get("X-Frame-Options"))
+	a.Equal("frame-ancestors 'none'", hdr.Get("Content-Security-Policy"))
+	a.Equal("strict-origin-when-cross-origin", hdr.Get("Referrer-Policy"))
+	a.Equal("camera=(), microphone=(), geolocation=()", hdr.Get("Permissions-Policy"))
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/embed", nil))
+	hdr = rw.Header()
+	a.Equal("max-age=31536000; includeSubDomains; preload", hdr.Get("Strict-Transport-Security"))
+	_, has := hdr["X-Frame-Options"]
+	a.False(has)
+	a.Equal("frame-ancestors https://partner.example", hdr.Get("Content-Security-Policy"))
+	a.Equal("nosniff", hdr.Get("X-Content-Type-Options"))
+
+	rw = httptest.NewRecorder()
+	SecurityHeaders(SecurityConfig{HSTS: time.Hour})(ok).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal(http.Header{"Strict-Transport-Security": []string{"max-age=3600"}}, rw.Header())
+}
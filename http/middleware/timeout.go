@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.mindeco.de/http/render"
+)
+
+// ErrTimeout is passed to the error handler of Timeout
+var ErrTimeout = errors.New("the request took too long")
+
+// TimeoutOption changes how Timeout works
+type TimeoutOption func(*timeouter)
+
+// TimeoutErrorHandler sets how requests that timed out are answered, with a plain text 503 by default.
+// render.Renderer.Error fits, to show the error template.
+func TimeoutErrorHandler(fn render.ErrorHandlerFunc) TimeoutOption {
+	return func(t *timeouter) {
+		t.errHandler = fn
+	}
+}
+
+// OnTimeout is called for every request that timed out, for instance to count them in a metric
+func OnTimeout(fn func(r *http.Request, after time.Duration)) TimeoutOption {
+	return func(t *timeouter) {
+		t.observe = fn
+	}
+}
+
+type timeouter struct {
+	errHandler render.ErrorHandlerFunc
+	observe    func(*http.Request, time.Duration)
+}
+
+// Timeout cancels the context of requests that take longer than d and answers them through the error handler,
+// with 503 Service Unavailable. Unlike http.TimeoutHandler the response isn't buffered, so streaming still works,
+// but once a handler started its response it can only be cut short, not replaced by the error.
+// Writes after the timeout return http.ErrHandlerTimeout.
+//
+// Handlers should pass the context of the request on to whatever they are waiting for,
+// otherwise they keep running in the background after the response was sent.
+func Timeout(d time.Duration, opts ...TimeoutOption) func(http.Handler) http.Handler {
+	t := timeouter{
+		errHandler: func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			http.Error(w, err.Error(), status)
+		},
+	}
+	for _, o := range opts {
+		o(&t)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{w: w, h: make(http.Header), ctx: ctx}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						if p != http.ErrAbortHandler {
+							// the stack of this goroutine is the interesting one, the re-panic below has a different one
+							p = &PanicError{Value: p, Stack: debug.Stack()}
+						}
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				// re-panic here, so that Recover in front of us sees it
+				panic(p)
+
+			case <-done:
+
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.Canceled) {
+					// the client went away, there is no one to answer
+					tw.stop()
+					return
+				}
+
+				logger(ctx).Log("event", "timeout", "method", r.Method, "path", r.URL.Path, "after", d)
+				if t.observe != nil {
+					t.observe(r, d)
+				}
+				if tw.stop() {
+					t.errHandler(w, r, http.StatusServiceUnavailable, ErrTimeout)
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter gives the handler its own header map and stops forwarding writes once ctx is done
+type timeoutWriter struct {
+	w   http.ResponseWriter
+	h   http.Header
+	ctx context.Context
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeader(code)
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	if tw.done() || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	dst := tw.w.Header()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.done() {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeader(http.StatusOK)
+	return tw.w.Write(b)
+}
+
+// Flush implements http.Flusher, for streaming responses
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.done() {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		tw.writeHeader(http.StatusOK)
+		f.Flush()
+	}
+}
+
+// done reports whether writes should be refused. Checking the context as well makes sure
+// the handler can't start the response once the deadline passed but before stop was called.
+func (tw *timeoutWriter) done() bool {
+	return tw.timedOut || tw.ctx.Err() != nil
+}
+
+// stop blocks further writes and reports whether the response wasn't started yet
+func (tw *timeoutWriter) stop() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = true
+	return !tw.wroteHeader
+}
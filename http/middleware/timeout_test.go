@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+func TestTimeout(t *testing.T) {
+	a := assert.New(t)
+
+	var (
+		buf      bytes.Buffer
+		observed []string
+		writeErr = make(chan error, 1)
+	)
+	h := Timeout(10*time.Millisecond,
+		OnTimeout(func(r *http.Request, after time.Duration) {
+			observed = append(observed, r.URL.Path+" "+after.String())
+		}),
+		TimeoutErrorHandler(func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			w.WriteHeader(status)
+			w.Write([]byte("custom: " + err.Error()))
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fast" {
+			w.Header().Set("X-Fast", "yes")
+			w.Write([]byte("done"))
+			return
+		}
+		<-r.Context().Done()
+		w.Header().Set("X-Late", "yes")
+		_, err := w.Write([]byte("too late"))
+		writeErr <- err
+	}))
+	h = logging.InjectHandler(kitlog.NewLogfmtLogger(&buf))(h)
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/fast", nil))
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("yes", rw.Header().Get("X-Fast"))
+	a.Equal("done", rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/slow", nil))
+	a.Equal(http.StatusServiceUnavailable, rw.Code)
+	a.Equal("custom: the request took too long", rw.Body.String())
+	a.Equal(http.ErrHandlerTimeout, <-writeErr)
+	a.Empty(rw.Header().Get("X-Late"))
+	a.Equal([]string{"/slow 10ms"}, observed)
+	a.Contains(buf.String(), "event=timeout method=GET path=/slow after=10ms")
+}
+
+func TestTimeoutStarted(t *testing.T) {
+	a := assert.New(t)
+
+	h := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		<-r.Context().Done()
+	}))
+	h = logging.InjectHandler(kitlog.NewNopLogger())(h)
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("partial", rw.Body.String(), "the started response is cut short")
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	a := assert.New(t)
+
+	h := Timeout(time.Second)(http.HandlerFunc(panics))
+	func() {
+		defer func() {
+			pe, ok := recover().(*PanicError)
+			if a.True(ok, "re-panics with the stack of the handler") {
+				a.Equal("boom", pe.Value)
+				a.Contains(string(pe.Stack), "middleware.panics")
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+
+	// Recover logs the original value and stack
+	var buf bytes.Buffer
+	rw := httptest.NewRecorder()
+	logging.InjectHandler(kitlog.NewLogfmtLogger(&buf))(Recover(nil)(h)).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal(http.StatusInternalServerError, rw.Code)
+	a.Contains(buf.String(), "panic=boom")
+	a.Contains(buf.String(), "middleware.panics")
+
+	a.PanicsWithValue(http.ErrAbortHandler, func() {
+		Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+
+	rw = httptest.NewRecorder()
+	Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusTeapot)
+	})).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	a.Equal(http.StatusTeapot, rw.Code)
+	a.Equal("nope", strings.TrimSpace(rw.Body.String()))
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.mindeco.de/http/auth"
+	"go.mindeco.de/http/clientip"
+	"go.mindeco.de/http/render"
+)
+
+const tracerName = "go.mindeco.de/http/middleware"
+
+// TraceOption changes how Trace and the instrumenters create spans
+type TraceOption func(*tracer)
+
+// TraceProvider sets where spans are sent to, otel.GetTracerProvider() by default
+func TraceProvider(tp trace.TracerProvider) TraceOption {
+	return func(t *tracer) {
+		t.provider = tp
+	}
+}
+
+// TracePropagator sets how the trace is read from incoming requests, W3C tracecontext and baggage by default
+func TracePropagator(p propagation.TextMapPropagator) TraceOption {
+	return func(t *tracer) {
+		t.propagator = p
+	}
+}
+
+type tracer struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	tracer     trace.Tracer
+}
+
+func newTracer(opts []TraceOption) *tracer {
+	t := tracer{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	for _, o := range opts {
+		o(&t)
+	}
+	t.tracer = t.provider.Tracer(tracerName)
+	return &t
+}
+
+// Trace starts a server span for every request, continuing the trace of the caller if its headers have one.
+// The span is in the request context, so trace.SpanFromContext returns it and spans of the handlers become its children.
+//
+// Used with mux.Router.Use it runs after the route was matched and names the spans after its template, like "GET /posts/{id}".
+// Otherwise they are named after the method.
+func Trace(opts ...TraceOption) func(http.Handler) http.Handler {
+	t := newTracer(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			name := r.Method
+			attrs := []attribute.KeyValue{
+				semconv.HTTPMethod(r.Method),
+				semconv.HTTPTarget(r.URL.RequestURI()),
+				semconv.NetHostName(r.Host),
+				semconv.HTTPClientIP(clientip.FromRequest(r)),
+			}
+			if ua := r.UserAgent(); ua != "" {
+				attrs = append(attrs, semconv.HTTPUserAgent(ua))
+			}
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					name += " " + tpl
+					attrs = append(attrs, semconv.HTTPRoute(tpl))
+				}
+			}
+
+			ctx, span := t.tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			sw := &StatusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			status := sw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPStatusCode(status), semconv.HTTPResponseContentLength(int(sw.size)))
+			if status >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// TraceRender returns a render.Instrumenter that creates a span for every template execution, as a child of the request span
+func TraceRender(opts ...TraceOption) render.Instrumenter {
+	t := newTracer(opts)
+	return func(ctx context.Context, template string) func(error) {
+		_, span := t.tracer.Start(ctx, "render "+template, trace.WithAttributes(attribute.String("render.template", template)))
+		return func(err error) {
+			endSpan(span, err)
+		}
+	}
+}
+
+// TraceAuth returns an auth.Instrumenter that creates a span for every check of the credentials, like logins
+func TraceAuth(opts ...TraceOption) auth.Instrumenter {
+	t := newTracer(opts)
+	return func(ctx context.Context, check string) (context.Context, func(error)) {
+		ctx, span := t.tracer.Start(ctx, "auth "+check, trace.WithAttributes(attribute.String("auth.check", check)))
+		return ctx, func(err error) {
+			endSpan(span, err)
+		}
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.mindeco.de/http/auth"
+	"go.mindeco.de/http/render"
+	kitlog "go.mindeco.de/log"
+)
+
+type tracedAuther struct{}
+
+func (tracedAuther) Check(user, pass string) (interface{}, error) {
+	if user == "alice" && pass == "secret" {
+		return "alice", nil
+	}
+	return nil, auth.ErrBadLogin
+}
+
+func TestTrace(t *testing.T) {
+	a := assert.New(t)
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	r, err := render.New(http.Dir("testdata"),
+		render.AddTemplates("/error.tmpl"),
+		render.SetLogger(kitlog.NewNopLogger()),
+		render.SetInstrumenter(TraceRender(TraceProvider(tp))),
+	)
+	require.NoError(t, err)
+
+	ah, err := auth.NewHandler(tracedAuther{},
+		auth.SetStore(sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))),
+		auth.SetInstrumenter(TraceAuth(TraceProvider(tp))),
+	)
+	require.NoError(t, err)
+
+	m := mux.NewRouter()
+	m.Use(Trace(TraceProvider(tp)))
+	m.HandleFunc("/posts/{id}", func(w http.ResponseWriter, req *http.Request) {
+		a.True(trace.SpanFromContext(req.Context()).SpanContext().IsValid(), "the span is in the context")
+		r.Render(w, req, "/error.tmpl", http.StatusOK, map[string]interface{}{"StatusCode": 200})
+	})
+	m.HandleFunc("/login", ah.Authorize)
+	m.HandleFunc("/fail", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "broken", http.StatusBadGateway)
+	})
+
+	req := httptest.NewRequest("GET", "/posts/23", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rw := httptest.NewRecorder()
+	m.ServeHTTP(rw, req)
+	a.Equal(http.StatusOK, rw.Code)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+	tpl, srv := spans[0], spans[1]
+	a.Equal("GET /posts/{id}", srv.Name())
+	a.Equal(trace.SpanKindServer, srv.SpanKind())
+	a.Equal("4bf92f3577b34da6a3ce929d0e0e4736", srv.SpanContext().TraceID().String(), "continues the trace of the caller")
+	a.Equal("00f067aa0ba902b7", srv.Parent().SpanID().String())
+	a.Contains(srv.Attributes(), semconv.HTTPRoute("/posts/{id}"))
+	a.Contains(srv.Attributes(), semconv.HTTPStatusCode(200))
+	a.Equal("render /error.tmpl", tpl.Name())
+	a.Equal(srv.SpanContext().SpanID(), tpl.Parent().SpanID())
+
+	form := url.Values{"user": {"alice"}, "pass": {"wrong"}}
+	req = httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans = rec.Ended()[2:]
+	require.Len(t, spans, 2)
+	check, srv := spans[0], spans[1]
+	a.Equal("POST /login", srv.Name())
+	a.Equal("auth login", check.Name())
+	a.Equal(srv.SpanContext().SpanID(), check.Parent().SpanID())
+	a.Equal(codes.Error, check.Status().Code)
+	a.Equal(codes.Unset, srv.Status().Code, "a bad login isn't a server error")
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+	srv = rec.Ended()[4]
+	a.Equal(codes.Error, srv.Status().Code)
+	a.Contains(srv.Attributes(), semconv.HTTPStatusCode(http.StatusBadGateway))
+}
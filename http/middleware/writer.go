@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// StatusWriter records the status and the size of a response for the middlewares which need them afterwards.
+// It passes Flush and Hijack through, so that streaming responses and websockets keep working.
+type StatusWriter struct {
+	http.ResponseWriter
+
+	status int
+	size   int64
+}
+
+// NewStatusWriter wraps w
+func NewStatusWriter(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w}
+}
+
+func (sw *StatusWriter) WriteHeader(code int) {
+	if sw.status == 0 {
+		sw.status = code
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *StatusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.size += int64(n)
+	return n, err
+}
+
+// Status returns the code that was sent, 200 if only the body was written and 0 if nothing was written
+func (sw *StatusWriter) Status() int {
+	return sw.status
+}
+
+// Size returns the number of body bytes that were written
+func (sw *StatusWriter) Size() int64 {
+	return sw.size
+}
+
+// Flush implements http.Flusher, for streaming responses
+func (sw *StatusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, for websockets
+func (sw *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("middleware: the ResponseWriter doesn't support hijacking")
+	}
+	if sw.status == 0 {
+		sw.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (sw *StatusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
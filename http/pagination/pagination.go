@@ -0,0 +1,266 @@
+// Package pagination reads the page (or cursor) and limit of list requests and builds the links to the other pages.
+//
+//	p, err := pagination.Parse(r)
+//	if err != nil {
+//		http.Error(w, err.Error(), http.StatusBadRequest)
+//		return
+//	}
+//	posts, total, err := db.ListPosts(ctx, p.Offset(), p.Limit)
+//	p.Total = total
+//	p.SetLinks(w) // Link and X-Total-Count, for APIs
+//	renderer.Render(w, r, "/posts.tmpl", http.StatusOK, map[string]interface{}{"Posts": posts, "Page": p})
+//
+// The methods of Page are the template helpers:
+//
+//	{{if .Page.HasPrev}}<a href="{{.Page.URL .Page.Prev}}" rel="prev">Previous</a>{{end}}
+//	{{range .Page.Window 2}}{{if eq . 0}}…{{else}}<a href="{{$.Page.URL .}}">{{.}}</a>{{end}}{{end}}
+//	{{if .Page.HasNext}}<a href="{{.Page.URL .Page.Next}}" rel="next">Next</a>{{end}}
+//
+// For cursor pagination, set NextCursor after the query instead of Total and use NextURL.
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Config are the names of the query parameters and the limits
+type Config struct {
+	PageParam   string
+	LimitParam  string
+	CursorParam string
+
+	DefaultLimit int
+	MaxLimit     int // larger limits are capped to it
+	MaxPage      int // larger page numbers are rejected, 0 only rejects those whose Offset would overflow
+}
+
+// Default is used by Parse: page, limit and cursor, with 20 items and at most 100
+var Default = Config{
+	PageParam:    "page",
+	LimitParam:   "limit",
+	CursorParam:  "cursor",
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// ErrBadParam is returned for page and limit values that aren't numbers
+var ErrBadParam = errors.New("pagination: invalid parameter")
+
+// Page is the requested part of a list, see Parse
+type Page struct {
+	Number int // starting at 1, always 1 with a cursor
+	Limit  int
+
+	Cursor     string // as sent by the client, empty without
+	NextCursor string // set by the handler if there are more items after a cursor page
+
+	Total int // of all items, set by the handler. -1 if it isn't known
+
+	cfg Config
+	u   *url.URL
+}
+
+// Parse reads r with the Default config
+func Parse(r *http.Request) (Page, error) {
+	return Default.Parse(r)
+}
+
+// Parse reads the parameters of r. Limits are capped to MaxLimit and page numbers below 1 become 1.
+// Page numbers above MaxPage, or so large that Offset would overflow, return ErrBadParam.
+func (c Config) Parse(r *http.Request) (Page, error) {
+	q := r.URL.Query()
+	p := Page{Number: 1, Limit: c.DefaultLimit, Total: -1, cfg: c, u: r.URL}
+
+	if v := q.Get(c.LimitParam); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("%w: %s is not a number", ErrBadParam, c.LimitParam)
+		}
+		p.Limit = n
+	}
+	if p.Limit < 1 {
+		p.Limit = 1
+	}
+	if c.MaxLimit > 0 && p.Limit > c.MaxLimit {
+		p.Limit = c.MaxLimit
+	}
+
+	if c.CursorParam != "" {
+		if cur := q.Get(c.CursorParam); cur != "" {
+			p.Cursor = cur
+			return p, nil
+		}
+	}
+
+	if v := q.Get(c.PageParam); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("%w: %s is not a number", ErrBadParam, c.PageParam)
+		}
+		if (c.MaxPage > 0 && n > c.MaxPage) || n-1 > math.MaxInt/p.Limit {
+			return p, fmt.Errorf("%w: %s is too large", ErrBadParam, c.PageParam)
+		}
+		if n > 1 {
+			p.Number = n
+		}
+	}
+	return p, nil
+}
+
+// Offset is the number of items before this page, for LIMIT/OFFSET queries
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Limit
+}
+
+// Pages returns the number of pages, 0 if Total isn't known
+func (p Page) Pages() int {
+	if p.Total < 0 {
+		return 0
+	}
+	if p.Total == 0 {
+		return 1
+	}
+	return (p.Total + p.Limit - 1) / p.Limit
+}
+
+// HasPrev reports whether there is a page before this one
+func (p Page) HasPrev() bool {
+	return p.Cursor == "" && p.Number > 1
+}
+
+// HasNext reports whether there is a page after this one, by Total or NextCursor
+func (p Page) HasNext() bool {
+	if p.NextCursor != "" {
+		return true
+	}
+	return p.Total >= 0 && p.Number < p.Pages()
+}
+
+// Prev is the number of the page before this one
+func (p Page) Prev() int {
+	if p.Number <= 1 {
+		return 1
+	}
+	return p.Number - 1
+}
+
+// Next is the number of the page after this one
+func (p Page) Next() int {
+	return p.Number + 1
+}
+
+// Window returns the numbers of the pages up to size before and after this one, and the first and the last one.
+// A 0 stands for the pages that are left out in between. It is empty if Total isn't known.
+func (p Page) Window(size int) []int {
+	last := p.Pages()
+	if last == 0 {
+		return nil
+	}
+
+	from, to := p.Number-size, p.Number+size
+	if from < 1 {
+		from = 1
+	}
+	if to > last {
+		to = last
+	}
+
+	var w []int
+	if from > 1 {
+		w = append(w, 1)
+		if from > 2 {
+			w = append(w, 0)
+		}
+	}
+	for n := from; n <= to; n++ {
+		w = append(w, n)
+	}
+	if to < last {
+		if to < last-1 {
+			w = append(w, 0)
+		}
+		w = append(w, last)
+	}
+	return w
+}
+
+// URL returns the URL of the page with number n, with the other parameters of the request
+func (p Page) URL(n int) string {
+	q := p.query()
+	if n > 1 {
+		q.Set(p.cfg.PageParam, strconv.Itoa(n))
+	}
+	return p.encode(q)
+}
+
+// NextURL returns the URL of the page after NextCursor
+func (p Page) NextURL() string {
+	q := p.query()
+	q.Set(p.cfg.CursorParam, p.NextCursor)
+	return p.encode(q)
+}
+
+func (p Page) query() url.Values {
+	var q url.Values
+	if p.u != nil {
+		q = p.u.Query()
+	} else {
+		q = make(url.Values)
+	}
+	q.Del(p.cfg.PageParam)
+	q.Del(p.cfg.CursorParam)
+	return q
+}
+
+func (p Page) encode(q url.Values) string {
+	path := ""
+	if p.u != nil {
+		path = p.u.Path
+	}
+	if len(q) == 0 {
+		return path
+	}
+	return path + "?" + q.Encode()
+}
+
+// Links returns the value for the Link header (RFC 8288) with first, prev, next and last, as far as they are known
+func (p Page) Links() string {
+	var links []string
+	add := func(u, rel string) {
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", u, rel))
+	}
+
+	if p.Cursor != "" || p.NextCursor != "" {
+		add(p.URL(1), "first")
+		if p.NextCursor != "" {
+			add(p.NextURL(), "next")
+		}
+		return strings.Join(links, ", ")
+	}
+
+	add(p.URL(1), "first")
+	if p.HasPrev() {
+		add(p.URL(p.Prev()), "prev")
+	}
+	if p.HasNext() {
+		add(p.URL(p.Next()), "next")
+	}
+	if last := p.Pages(); last > 0 {
+		add(p.URL(last), "last")
+	}
+	return strings.Join(links, ", ")
+}
+
+// SetLinks sets the Link header and X-Total-Count, if Total is known
+func (p Page) SetLinks(w http.ResponseWriter) {
+	w.Header().Set("Link", p.Links())
+	if p.Total >= 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(p.Total))
+	}
+}
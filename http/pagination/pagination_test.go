@@ -0,0 +1,119 @@
+package pagination
+
+import (
+	"errors"
+	"html/template"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	a := assert.New(t)
+
+	p, err := Parse(httptest.NewRequest("GET", "/posts", nil))
+	require.NoError(t, err)
+	a.Equal(1, p.Number)
+	a.Equal(20, p.Limit)
+	a.Equal(0, p.Offset())
+
+	p, err = Parse(httptest.NewRequest("GET", "/posts?page=3&limit=500", nil))
+	require.NoError(t, err)
+	a.Equal(3, p.Number)
+	a.Equal(100, p.Limit, "capped")
+	a.Equal(200, p.Offset())
+
+	p, err = Parse(httptest.NewRequest("GET", "/posts?page=-2&limit=0", nil))
+	require.NoError(t, err)
+	a.Equal(1, p.Number)
+	a.Equal(1, p.Limit)
+
+	_, err = Parse(httptest.NewRequest("GET", "/posts?page=two", nil))
+	a.True(errors.Is(err, ErrBadParam))
+	a.EqualError(err, "pagination: invalid parameter: page is not a number")
+
+	// the offset would overflow into a negative number
+	_, err = Parse(httptest.NewRequest("GET", "/posts?page=9223372036854775807", nil))
+	a.True(errors.Is(err, ErrBadParam))
+	_, err = Parse(httptest.NewRequest("GET", "/posts?page=461168601842738792&limit=20", nil))
+	a.True(errors.Is(err, ErrBadParam))
+
+	capped := Default
+	capped.MaxPage = 50
+	_, err = capped.Parse(httptest.NewRequest("GET", "/posts?page=51", nil))
+	a.EqualError(err, "pagination: invalid parameter: page is too large")
+	p, err = capped.Parse(httptest.NewRequest("GET", "/posts?page=50", nil))
+	require.NoError(t, err)
+	a.Equal(980, p.Offset())
+
+	p, err = Parse(httptest.NewRequest("GET", "/posts?page=4&cursor=abc", nil))
+	require.NoError(t, err)
+	a.Equal("abc", p.Cursor)
+	a.Equal(1, p.Number, "the cursor wins")
+}
+
+func TestLinksAndWindow(t *testing.T) {
+	a := assert.New(t)
+
+	p, err := Parse(httptest.NewRequest("GET", "/posts?tag=go&page=5&limit=10", nil))
+	require.NoError(t, err)
+	a.Nil(p.Window(2), "total unknown")
+	a.False(p.HasNext())
+
+	p.Total = 95
+	a.Equal(10, p.Pages())
+	a.True(p.HasPrev())
+	a.True(p.HasNext())
+	a.Equal([]int{1, 0, 3, 4, 5, 6, 7, 0, 10}, p.Window(2))
+	a.Equal("/posts?limit=10&page=6&tag=go", p.URL(p.Next()))
+	a.Equal("/posts?limit=10&tag=go", p.URL(1))
+
+	rec := httptest.NewRecorder()
+	p.SetLinks(rec)
+	a.Equal(`</posts?limit=10&tag=go>; rel="first", </posts?limit=10&page=4&tag=go>; rel="prev", </posts?limit=10&page=6&tag=go>; rel="next", </posts?limit=10&page=10&tag=go>; rel="last"`, rec.Header().Get("Link"))
+	a.Equal("95", rec.Header().Get("X-Total-Count"))
+
+	p.Number = 2
+	a.Equal([]int{1, 2, 3, 4, 0, 10}, p.Window(2), "no gap between 1 and 2")
+	p.Number = 10
+	a.False(p.HasNext())
+	a.Equal([]int{1, 0, 8, 9, 10}, p.Window(2))
+
+	p.Total = 0
+	a.Equal(1, p.Pages())
+}
+
+func TestCursor(t *testing.T) {
+	a := assert.New(t)
+
+	p, err := Parse(httptest.NewRequest("GET", "/api/events?cursor=abc&limit=50", nil))
+	require.NoError(t, err)
+	a.False(p.HasNext())
+	a.False(p.HasPrev())
+
+	p.NextCursor = "def"
+	a.True(p.HasNext())
+	a.Equal("/api/events?cursor=def&limit=50", p.NextURL())
+
+	rec := httptest.NewRecorder()
+	p.SetLinks(rec)
+	a.Equal(`</api/events?limit=50>; rel="first", </api/events?cursor=def&limit=50>; rel="next"`, rec.Header().Get("Link"))
+	a.Empty(rec.Header().Get("X-Total-Count"))
+}
+
+func TestTemplate(t *testing.T) {
+	tpl := template.Must(template.New("").Parse(`{{if .Page.HasPrev}}<a href="{{.Page.URL .Page.Prev}}" rel="prev">Previous</a>{{end}}
+{{range .Page.Window 1}}{{if eq . 0}}…{{else}}<a href="{{$.Page.URL .}}">{{.}}</a>{{end}}{{end}}`))
+
+	p, err := Parse(httptest.NewRequest("GET", "/posts?page=3&tag=a%26b", nil))
+	require.NoError(t, err)
+	p.Total = 200
+
+	var buf strings.Builder
+	require.NoError(t, tpl.Execute(&buf, map[string]interface{}{"Page": p}))
+	assert.Equal(t, `<a href="/posts?page=2&amp;tag=a%26b" rel="prev">Previous</a>
+<a href="/posts?tag=a%26b">1</a><a href="/posts?page=2&amp;tag=a%26b">2</a><a href="/posts?page=3&amp;tag=a%26b">3</a><a href="/posts?page=4&amp;tag=a%26b">4</a>…<a href="/posts?page=10&amp;tag=a%26b">10</a>`, buf.String())
+}
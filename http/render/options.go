@@ -1,12 +1,13 @@
 package render
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
 
-	"go.mindeco.de/log"
+	"go.mindeco.de/logging/adapter"
 )
 
 type Option func(*Renderer) error
@@ -60,7 +61,9 @@ func InjectTemplateFunc(name string, fn FuncInjector) Option {
 	}
 }
 
-func SetLogger(l log.Logger) Option {
+// SetLogger sets where failed renders are logged, logging.Logger("render") by default.
+// A slog.Logger can be passed with adapter.FromSlog.
+func SetLogger(l adapter.Logger) Option {
 	return func(r *Renderer) error {
 		if l == nil {
 			return errors.New("render: nil logger passed")
@@ -83,3 +86,29 @@ func SetErrorHandler(fn ErrorHandlerFunc) Option {
 		return nil
 	}
 }
+
+// Instrumenter is called before a template is executed and returns a function that is called with the result, for tracing or metrics
+type Instrumenter func(ctx context.Context, template string) func(err error)
+
+// SetInstrumenter sets the Instrumenter, see middleware.TraceRender for one that creates spans and metrics.Metrics.Render for one that records durations.
+// If it is used more than once, all of them are called.
+func SetInstrumenter(fn Instrumenter) Option {
+	return func(r *Renderer) error {
+		if fn == nil {
+			return errors.New("render: nil Instrumenter passed")
+		}
+		prev := r.instrument
+		if prev == nil {
+			r.instrument = fn
+			return nil
+		}
+		r.instrument = func(ctx context.Context, template string) func(error) {
+			donePrev, done := prev(ctx, template), fn(ctx, template)
+			return func(err error) {
+				done(err)
+				donePrev(err)
+			}
+		}
+		return nil
+	}
+}
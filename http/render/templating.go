@@ -11,14 +11,14 @@ import (
 
 	"github.com/oxtoacart/bpool"
 	"github.com/shurcooL/httpfs/html/vfstemplate"
-	"go.mindeco.de/log"
 	"go.mindeco.de/log/level"
 	"go.mindeco.de/logging"
+	"go.mindeco.de/logging/adapter"
 )
 
 type Renderer struct {
 	assets http.FileSystem
-	log    log.Logger
+	log    adapter.Logger
 
 	// files
 	templateFiles []string
@@ -31,6 +31,8 @@ type Renderer struct {
 
 	tplFuncInjectors map[string]FuncInjector
 
+	instrument Instrumenter
+
 	// bufpool is shared between all render() calls
 	bufpool *bpool.BufferPool
 
@@ -38,6 +40,7 @@ type Renderer struct {
 
 	mu        sync.RWMutex // protect concurrent map access
 	reloading bool
+	parseErr  error // of the last parse, see ParseError
 	templates map[string]*template.Template
 }
 
@@ -134,7 +137,12 @@ func (r *Renderer) StaticHTML(name string) http.Handler {
 	})
 }
 
-func (r *Renderer) Render(w http.ResponseWriter, req *http.Request, name string, status int, data interface{}) error {
+func (r *Renderer) Render(w http.ResponseWriter, req *http.Request, name string, status int, data interface{}) (err error) {
+	if r.instrument != nil {
+		done := r.instrument(req.Context(), name)
+		defer func() { done(err) }()
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -202,10 +210,28 @@ func (r *Renderer) defaultErrhandler(w http.ResponseWriter, req *http.Request, s
 	}
 }
 
-func (r *Renderer) parseHTMLTemplates() error {
+// Reparse parses all templates again, even if GetReloader isn't used. This is for production, like on SIGHUP.
+// If one of them fails to parse, the current templates are kept.
+func (r *Renderer) Reparse() error {
+	return r.parseHTMLTemplates()
+}
+
+// ParseError returns the error of the last Reload or Reparse, nil if it went fine.
+// The templates from before are still used in that case, so this is for health checks.
+func (r *Renderer) ParseError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.parseErr
+}
+
+func (r *Renderer) parseHTMLTemplates() (err error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.reloading = true
+	defer func() {
+		r.reloading = false
+		r.parseErr = err
+	}()
 
 	parseFuncs := make(template.FuncMap, len(r.funcMap)+len(r.tplFuncInjectors))
 	for k, v := range r.funcMap {
@@ -220,6 +246,7 @@ func (r *Renderer) parseHTMLTemplates() error {
 
 	funcTpl := template.New("").Funcs(parseFuncs)
 
+	parsed := make(map[string]*template.Template, len(r.templateFiles))
 	for _, tf := range r.templateFiles {
 		ftc, err := funcTpl.Clone()
 		if err != nil {
@@ -229,9 +256,11 @@ func (r *Renderer) parseHTMLTemplates() error {
 		if err != nil {
 			return fmt.Errorf("render: failed to parse template %s: %w", tf, err)
 		}
+		parsed[tf] = t
+	}
+	for tf, t := range parsed {
 		r.templates[tf] = t
 	}
-	r.reloading = false
 	return nil
 }
 
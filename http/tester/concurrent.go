@@ -0,0 +1,82 @@
+package tester
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Client is the Tester of one goroutine of Concurrently
+type Client struct {
+	*Tester
+}
+
+// Concurrently calls fn from n goroutines at the same time and waits for all of them.
+// Each gets its own Client with a separate cookie jar (starting with a copy of the headers of t),
+// so that it looks like n different users. Failures of the clients are collected and reported on t once all are done,
+// Fatal only stops the goroutine that called it. Recording (see Record) is disabled for the clients.
+// Run the tests with -race to make use of this.
+func (t *Tester) Concurrently(n int, fn func(i int, c *Client)) {
+	t.t.Helper()
+
+	collectors := make([]*collector, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		col := &collector{parent: t.t}
+		collectors[i] = col
+
+		cpy := *t
+		cpy.t = col
+		cpy.jar = newJar()
+		cpy.extraHeaders = t.extraHeaders.Clone()
+		cpy.chain, cpy.hops = nil, nil
+		cpy.rec = nil
+		c := &Client{Tester: &cpy}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			fn(i, c)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, col := range collectors {
+		for _, msg := range col.failures {
+			t.t.Errorf("client %d: %s", i, msg)
+		}
+	}
+}
+
+// collector records the failures of a Client instead of failing the test from another goroutine
+type collector struct {
+	parent   tb
+	failures []string
+}
+
+func (c *collector) Helper() {}
+
+func (c *collector) Log(args ...interface{}) { c.parent.Log(args...) }
+
+func (c *collector) Error(args ...interface{}) {
+	c.failures = append(c.failures, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func (c *collector) Errorf(format string, args ...interface{}) {
+	c.failures = append(c.failures, fmt.Sprintf(format, args...))
+}
+
+// Fatal records the failure and stops the goroutine, like testing.T does
+func (c *collector) Fatal(args ...interface{}) {
+	c.Error(args...)
+	runtime.Goexit()
+}
+
+func (c *collector) Fatalf(format string, args ...interface{}) {
+	c.Errorf(format, args...)
+	runtime.Goexit()
+}
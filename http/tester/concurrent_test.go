@@ -0,0 +1,64 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrently(t *testing.T) {
+	a := assert.New(t)
+
+	var (
+		mu   sync.Mutex
+		hits = make(map[string]int)
+	)
+	mux := newLoginMux()
+	mux.HandleFunc("/hit", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("session")
+		if err != nil {
+			http.Error(w, "no session", http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		hits[c.Value]++
+		mu.Unlock()
+	})
+	tc := New(mux, t)
+
+	const n = 8
+	tc.Concurrently(n, func(i int, c *Client) {
+		user := fmt.Sprintf("user%d", i)
+		c.PostForm(&url.URL{Path: "/login"}, url.Values{"user": {user}})
+		for j := 0; j < 5; j++ {
+			c.Wrap(c.GetBody(&url.URL{Path: "/hit"})).ExpectStatus(http.StatusOK)
+		}
+	})
+
+	a.Len(hits, n, "every client should have its own cookie")
+	for user, count := range hits {
+		a.Equal(5, count, user)
+	}
+
+	// the jar of the parent is untouched
+	a.Len(tc.Cookies(&url.URL{Path: "/"}), 0)
+}
+
+func TestCollector(t *testing.T) {
+	a := assert.New(t)
+
+	col := &collector{parent: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		col.Errorf("first %d", 1)
+		col.Fatal("stop")
+		col.Error("not reached")
+	}()
+	<-done
+	a.Equal([]string{"first 1", "stop"}, col.failures)
+}
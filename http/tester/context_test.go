@@ -0,0 +1,42 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxKey struct{}
+
+func TestWithContext(t *testing.T) {
+	a := assert.New(t)
+
+	tc := New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v, ok := r.Context().Value(ctxKey{}).(string); ok {
+			fmt.Fprint(w, v)
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			http.Error(w, r.Context().Err().Error(), http.StatusServiceUnavailable)
+		case <-time.After(time.Second):
+			fmt.Fprint(w, "slow")
+		}
+	}), t)
+	root := &url.URL{Path: "/"}
+
+	seeded := context.WithValue(context.Background(), ctxKey{}, "seeded")
+	a.Equal("seeded", tc.WithContext(seeded).GetBody(root).Body.String())
+	a.Equal("seeded", tc.Request("GET", root).Context(seeded).Do().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	resp := tc.WithContext(ctx).GetBody(root)
+	a.Equal(http.StatusServiceUnavailable, resp.Code)
+	a.Contains(resp.Body.String(), "deadline exceeded")
+}
@@ -0,0 +1,117 @@
+package tester
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DeadLink is a link found by Crawl which was answered with 404 or a server error
+type DeadLink struct {
+	URL    *url.URL
+	From   *url.URL // the page that had the link
+	Status int
+}
+
+// Crawl fetches start and follows the links (a[href]) and the actions of GET forms of the HTML pages it finds, up to maxDepth links away.
+// Only links to the handler itself are followed, each one once. Since all of them are fetched with GET, they shouldn't change state.
+// Every link that results in a 404 or a 5xx response is reported as an error and returned.
+func (t *Tester) Crawl(start *url.URL, maxDepth int) []DeadLink {
+	t.t.Helper()
+
+	type link struct {
+		u, from *url.URL
+		depth   int
+	}
+
+	var (
+		dead  []DeadLink
+		queue = []link{{u: start}}
+		seen  = map[string]bool{crawlKey(start): true}
+	)
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+
+		rw := t.do("GET", l.u, nil, "", nil)
+		if rw.Code == http.StatusNotFound || rw.Code >= 500 {
+			from := "start"
+			if l.from != nil {
+				from = l.from.String()
+			}
+			t.t.Errorf("tester: dead link %s (from %s): status %d", l.u, from, rw.Code)
+			dead = append(dead, DeadLink{URL: l.u, From: l.from, Status: rw.Code})
+			continue
+		}
+
+		if l.depth >= maxDepth || !isHTML(rw.Header().Get("Content-Type")) {
+			continue
+		}
+
+		doc, err := goquery.NewDocumentFromReader(rw.Body)
+		if err != nil {
+			t.t.Errorf("tester: failed to parse %s: %s", l.u, err)
+			continue
+		}
+
+		// relative links are resolved against the page after redirects
+		page := t.hops[len(t.hops)-1].URL
+		for _, ref := range crawlRefs(doc) {
+			next, ok := t.crawlTarget(page, ref)
+			if !ok || seen[crawlKey(next)] {
+				continue
+			}
+			seen[crawlKey(next)] = true
+			queue = append(queue, link{u: next, from: page, depth: l.depth + 1})
+		}
+	}
+	return dead
+}
+
+// crawlRefs returns the hrefs of the anchors and the actions of GET forms
+func crawlRefs(doc *goquery.Document) []string {
+	var refs []string
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		refs = append(refs, s.AttrOr("href", ""))
+	})
+	doc.Find("form").Each(func(_ int, s *goquery.Selection) {
+		if strings.EqualFold(s.AttrOr("method", "GET"), "GET") {
+			refs = append(refs, s.AttrOr("action", ""))
+		}
+	})
+	return refs
+}
+
+// crawlTarget resolves ref against page and returns false if it doesn't point to the handler
+func (t *Tester) crawlTarget(page *url.URL, ref string) (*url.URL, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, false
+	}
+	next := page.ResolveReference(u)
+	if next.Scheme != "" && next.Scheme != "http" && next.Scheme != "https" {
+		// mailto:, javascript: and the like
+		return nil, false
+	}
+	if next.IsAbs() && next.Host != jarURL(page).Host {
+		return nil, false
+	}
+	next.Fragment = ""
+	return next, true
+}
+
+func crawlKey(u *url.URL) string {
+	return u.Path + "?" + u.RawQuery
+}
+
+func isHTML(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mt == "text/html"
+}
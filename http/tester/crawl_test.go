@@ -0,0 +1,56 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrawl(t *testing.T) {
+	a := assert.New(t)
+
+	pages := map[string]string{
+		"/":       `<a href="/about#team">about</a> <a href="docs/">docs</a> <a href="mailto:me@example.com">mail</a> <a href="http://example.com/">ext</a>`,
+		"/about":  `<a href="/">home</a> <a href="/missing">old</a>`,
+		"/docs/":  `<form action="/search"><input name="q"></form> <form method="post" action="/nope"></form> <a href="deep">deep</a>`,
+		"/search": `<a href="/search?page=2">next</a>`,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/broken":
+			http.Error(w, "oops", http.StatusInternalServerError)
+			return
+		case "/docs/deep":
+			fmt.Fprint(w, `<a href="/broken">too deep</a>`)
+			return
+		}
+		p, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, p)
+	})
+
+	col := &collector{parent: t}
+	tc := New(mux, t)
+	tc.t = col
+
+	dead := tc.Crawl(&url.URL{Path: "/"}, 2)
+	if a.Len(dead, 1) {
+		a.Equal("/missing", dead[0].URL.Path)
+		a.Equal("/about", dead[0].From.Path)
+		a.Equal(http.StatusNotFound, dead[0].Status)
+	}
+	a.Equal([]string{"tester: dead link /missing (from /about): status 404"}, col.failures)
+
+	// one level deeper reaches /broken
+	col.failures = nil
+	dead = tc.Crawl(&url.URL{Path: "/"}, 3)
+	a.Len(dead, 2)
+}
@@ -0,0 +1,52 @@
+package tester
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// AutoCSRF makes the Tester pick up the CSRF token of the HTML pages it gets, from a hidden input or a meta tag named field,
+// and send it in header with every request that isn't GET, HEAD or OPTIONS (like csrf.FieldName and csrf.HeaderName).
+// Calls that set the header themselves (WithHeader) win.
+func AutoCSRF(field, header string) Option {
+	return func(t *Tester) {
+		t.csrfField, t.csrfHeader = field, header
+	}
+}
+
+// CSRFToken returns the last token that AutoCSRF picked up
+func (t *Tester) CSRFToken() string {
+	return t.csrfToken
+}
+
+func (t *Tester) addCSRF(req *http.Request) {
+	if t.csrfHeader == "" || t.csrfToken == "" {
+		return
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return
+	}
+	req.Header.Set(t.csrfHeader, t.csrfToken)
+}
+
+func (t *Tester) pickCSRF(rw *httptest.ResponseRecorder) {
+	if t.csrfField == "" || !strings.HasPrefix(rw.Header().Get("Content-Type"), "text/html") {
+		return
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(rw.Body.Bytes()))
+	if err != nil {
+		return
+	}
+	if v, ok := doc.Find(`input[name="` + t.csrfField + `"]`).First().Attr("value"); ok && v != "" {
+		t.csrfToken = v
+		return
+	}
+	if v, ok := doc.Find(`meta[name="` + t.csrfField + `"]`).First().Attr("content"); ok && v != "" {
+		t.csrfToken = v
+	}
+}
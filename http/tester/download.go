@@ -0,0 +1,49 @@
+package tester
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"mime"
+	"path/filepath"
+)
+
+// Filename returns the filename of the Content-Disposition header and false if there is none
+func (r *Response) Filename() (string, bool) {
+	cd := r.Header().Get("Content-Disposition")
+	if cd == "" {
+		return "", false
+	}
+	_, params, err := mime.ParseMediaType(cd)
+	if err != nil {
+		return "", false
+	}
+	name, ok := params["filename"]
+	return name, ok && name != ""
+}
+
+// BodySHA256 returns the hex encoded SHA-256 checksum of the body
+func (r *Response) BodySHA256() string {
+	sum := sha256.Sum256(r.Body.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveBody writes the body to dir, using the filename of the Content-Disposition (without any directories) or "body" if there is none.
+// It returns the path of the file and fails the test if it can't be written.
+func (r *Response) SaveBody(dir string) string {
+	r.t.t.Helper()
+
+	name, ok := r.Filename()
+	if ok {
+		name = filepath.Base(filepath.Clean("/" + name))
+	}
+	if !ok || name == "/" || name == "." {
+		name = "body"
+	}
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, r.Body.Bytes(), 0600); err != nil {
+		r.t.t.Fatal("tester: failed to save body:", err)
+	}
+	return path
+}
@@ -0,0 +1,53 @@
+package tester
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownload(t *testing.T) {
+	a := assert.New(t)
+
+	const csv = "id,name\n1,alice\n"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="../../users.csv"`)
+		fmt.Fprint(w, csv)
+	})
+	mux.HandleFunc("/inline", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data")
+	})
+	tc := New(mux, t)
+
+	dir, err := ioutil.TempDir("", "tester-download")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	resp := tc.Request("GET", &url.URL{Path: "/export"}).Do()
+	name, ok := resp.Filename()
+	a.True(ok)
+	a.Equal("../../users.csv", name)
+
+	sum := sha256.Sum256([]byte(csv))
+	a.Equal(hex.EncodeToString(sum[:]), resp.BodySHA256())
+
+	path := resp.SaveBody(dir)
+	a.Equal(filepath.Join(dir, "users.csv"), path, "should not escape dir")
+	saved, err := ioutil.ReadFile(path)
+	a.NoError(err)
+	a.Equal(csv, string(saved))
+
+	inline := tc.Request("GET", &url.URL{Path: "/inline"}).Do()
+	_, ok = inline.Filename()
+	a.False(ok)
+	a.Equal(filepath.Join(dir, "body"), inline.SaveBody(dir))
+}
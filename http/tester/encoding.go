@@ -0,0 +1,59 @@
+package tester
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+)
+
+// decodeBody replaces a gzip or deflate encoded body of rw with the decoded one and returns the raw bytes.
+// The headers are left as they are, so that tests can still check the Content-Encoding. It returns nil if the body wasn't encoded.
+func decodeBody(rw *httptest.ResponseRecorder) ([]byte, error) {
+	enc := strings.ToLower(strings.TrimSpace(rw.Header().Get("Content-Encoding")))
+	if enc == "" || enc == "identity" || rw.Body.Len() == 0 {
+		return nil, nil
+	}
+
+	raw := rw.Body.Bytes()
+	var rd io.Reader
+	switch enc {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("tester: invalid gzip body: %w", err)
+		}
+		rd = gz
+	case "deflate":
+		// HTTP deflate is zlib, but some servers send a raw deflate stream
+		if zr, err := zlib.NewReader(bytes.NewReader(raw)); err == nil {
+			rd = zr
+		} else {
+			rd = flate.NewReader(bytes.NewReader(raw))
+		}
+	default:
+		return nil, nil
+	}
+
+	decoded, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return nil, fmt.Errorf("tester: failed to decode %s body: %w", enc, err)
+	}
+
+	rawCopy := append([]byte(nil), raw...)
+	rw.Body = bytes.NewBuffer(decoded)
+	return rawCopy, nil
+}
+
+// Raw returns the body as it was written by the handler, before a gzip or deflate Content-Encoding was decoded
+func (r *Response) Raw() []byte {
+	if r.info.raw != nil {
+		return r.info.raw
+	}
+	return r.Body.Bytes()
+}
@@ -0,0 +1,48 @@
+package tester
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressedBodies(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "application/json")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`{"id": 42}`))
+		gz.Close()
+	})
+	mux.HandleFunc("/deflate", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "deflate")
+		zw := zlib.NewWriter(w)
+		zw.Write([]byte(`<p id="hello">Hello</p>`))
+		zw.Close()
+	})
+	tc := New(mux, t)
+
+	var v struct{ ID int }
+	resp := tc.GetJSON(&url.URL{Path: "/gzip"}, &v)
+	a.Equal(42, v.ID)
+	a.Equal("gzip", resp.Header().Get("Content-Encoding"))
+
+	wrapped := tc.Wrap(resp)
+	gz, err := gzip.NewReader(bytes.NewReader(wrapped.Raw()))
+	a.NoError(err)
+	a.NotNil(gz)
+
+	doc, _ := tc.GetHTML(&url.URL{Path: "/deflate"})
+	a.Equal("Hello", doc.Find("#hello").Text())
+
+	plain := tc.Request("GET", &url.URL{Path: "/missing"}).Do()
+	a.Equal(plain.Body.Bytes(), plain.Raw())
+}
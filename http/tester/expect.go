@@ -0,0 +1,84 @@
+package tester
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Wrap turns a recorder returned by the other helpers (like GetBody) into a Response, to use the Expect methods on it.
+// The Response takes over the timing and raw body the Tester kept for rw, so only the first Wrap of a recorder has them.
+func (t *Tester) Wrap(rw *httptest.ResponseRecorder) *Response {
+	r := &Response{ResponseRecorder: rw, t: t}
+	r.info, r.hasInfo = t.meta.take(rw)
+	if n := len(t.hops); n > 0 && t.hops[n-1].rw == rw {
+		r.hops = t.hops
+	}
+	return r
+}
+
+// ExpectStatus reports an error if the response doesn't have the status code
+func (r *Response) ExpectStatus(code int) *Response {
+	r.t.t.Helper()
+	assert.Equal(r.t.t, code, r.Code, "unexpected status code. Body: %s", r.Body.String())
+	return r
+}
+
+// ExpectHeader reports an error if the header isn't set to value
+func (r *Response) ExpectHeader(key, value string) *Response {
+	r.t.t.Helper()
+	assert.Equal(r.t.t, value, r.Header().Get(key), "unexpected value of header %s", key)
+	return r
+}
+
+// ExpectBodyContains reports an error if the body doesn't contain s
+func (r *Response) ExpectBodyContains(s string) *Response {
+	r.t.t.Helper()
+	assert.Contains(r.t.t, r.Body.String(), s)
+	return r
+}
+
+// ExpectJSONEqual reports an error if the body isn't the same JSON as the encoding of v, ignoring formatting and key order.
+// v can also be a string or []byte with JSON.
+func (r *Response) ExpectJSONEqual(v interface{}) *Response {
+	r.t.t.Helper()
+
+	var want []byte
+	switch tv := v.(type) {
+	case string:
+		want = []byte(tv)
+	case []byte:
+		want = tv
+	default:
+		var err error
+		want, err = json.Marshal(v)
+		if err != nil {
+			r.t.t.Errorf("failed to encode the expected value: %s", err)
+			return r
+		}
+	}
+
+	assert.JSONEq(r.t.t, string(want), r.Body.String())
+	return r
+}
+
+// ExpectSelectorText reports an error if the text of the elements matching the CSS selector isn't text (ignoring surrounding whitespace)
+func (r *Response) ExpectSelectorText(selector, text string) *Response {
+	r.t.t.Helper()
+	sel := r.HTML().Find(selector)
+	if sel.Length() == 0 {
+		r.t.t.Errorf("tester: nothing matches %q", selector)
+		return r
+	}
+	assert.Equal(r.t.t, text, strings.TrimSpace(sel.Text()), "text of %q", selector)
+	return r
+}
+
+// ExpectSelectorCount reports an error if the CSS selector doesn't match n elements
+func (r *Response) ExpectSelectorCount(selector string, n int) *Response {
+	r.t.t.Helper()
+	assert.Equal(r.t.t, n, r.HTML().Find(selector).Length(), "number of elements matching %q", selector)
+	return r
+}
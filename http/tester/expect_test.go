@@ -0,0 +1,43 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestExpect(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/next")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 42, "tags": ["a", "b"]}`)
+	})
+	tc := New(handler, t)
+
+	tc.Wrap(tc.GetBody(&url.URL{Path: "/"})).
+		ExpectStatus(http.StatusCreated).
+		ExpectHeader("Location", "/next").
+		ExpectBodyContains(`"id": 42`).
+		ExpectJSONEqual(map[string]interface{}{"tags": []string{"a", "b"}, "id": 42}).
+		ExpectJSONEqual(`{"tags":["a","b"],"id":42}`)
+}
+
+func TestExpectSelector(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><h1 id="hello">
+			Hello
+		</h1><ul class="items"><li>a</li><li>b</li><li>c</li></ul></body></html>`)
+	})
+	tc := New(handler, t)
+
+	resp := tc.Request("GET", &url.URL{Path: "/"}).Do()
+	resp.ExpectSelectorText("#hello", "Hello").
+		ExpectSelectorCount("ul.items li", 3).
+		ExpectSelectorCount(".missing", 0)
+
+	if resp.HTML() != resp.HTML() {
+		t.Error("document should be parsed once")
+	}
+}
@@ -0,0 +1,122 @@
+package tester
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SubmitForm finds the form matching selector in doc (fetched from page, for instance with GetHTML) and submits it.
+// The fields of the form (including hidden ones like CSRF tokens) are sent with their current values,
+// unless values has an entry for them. Method, action and enctype are taken from the form.
+func (t *Tester) SubmitForm(page *url.URL, doc *goquery.Document, selector string, values url.Values, opts ...RequestOption) *httptest.ResponseRecorder {
+	t.t.Helper()
+
+	form := doc.Find(selector).First()
+	if form.Length() == 0 {
+		t.t.Fatalf("tester: no form matching %q", selector)
+	}
+	if !form.Is("form") {
+		form = form.Closest("form")
+		if form.Length() == 0 {
+			t.t.Fatalf("tester: %q is not (in) a form", selector)
+		}
+	}
+
+	fields := formValues(form)
+	for k, vals := range values {
+		fields[k] = vals
+	}
+
+	action := page
+	if a, ok := form.Attr("action"); ok && a != "" {
+		au, err := url.Parse(a)
+		if err != nil {
+			t.t.Fatalf("tester: invalid form action %q: %s", a, err)
+		}
+		action = page.ResolveReference(au)
+	}
+
+	method := strings.ToUpper(form.AttrOr("method", "GET"))
+	if method != "POST" {
+		u := *action
+		u.RawQuery = fields.Encode()
+		return t.do("GET", &u, nil, "", opts)
+	}
+
+	if strings.EqualFold(form.AttrOr("enctype", ""), "multipart/form-data") {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for k, vals := range fields {
+			for _, v := range vals {
+				if err := mw.WriteField(k, v); err != nil {
+					t.t.Fatal(err)
+				}
+			}
+		}
+		if err := mw.Close(); err != nil {
+			t.t.Fatal(err)
+		}
+		return t.do("POST", action, buf.Bytes(), mw.FormDataContentType(), opts)
+	}
+
+	return t.do("POST", action, []byte(fields.Encode()), "application/x-www-form-urlencoded", opts)
+}
+
+// formValues collects what a browser would send for the form without user interaction
+func formValues(form *goquery.Selection) url.Values {
+	vals := make(url.Values)
+
+	form.Find("input").Each(func(_ int, in *goquery.Selection) {
+		name, ok := in.Attr("name")
+		if !ok || name == "" {
+			return
+		}
+		if _, disabled := in.Attr("disabled"); disabled {
+			return
+		}
+
+		switch strings.ToLower(in.AttrOr("type", "text")) {
+		case "submit", "button", "image", "reset", "file":
+			return
+		case "checkbox", "radio":
+			if _, checked := in.Attr("checked"); !checked {
+				return
+			}
+			vals.Add(name, in.AttrOr("value", "on"))
+		default:
+			vals.Add(name, in.AttrOr("value", ""))
+		}
+	})
+
+	form.Find("textarea").Each(func(_ int, ta *goquery.Selection) {
+		if name, ok := ta.Attr("name"); ok && name != "" {
+			vals.Add(name, ta.Text())
+		}
+	})
+
+	form.Find("select").Each(func(_ int, sel *goquery.Selection) {
+		name, ok := sel.Attr("name")
+		if !ok || name == "" {
+			return
+		}
+		opt := sel.Find("option[selected]").First()
+		if opt.Length() == 0 {
+			opt = sel.Find("option").First()
+		}
+		if opt.Length() == 0 {
+			return
+		}
+		v, ok := opt.Attr("value")
+		if !ok {
+			v = strings.TrimSpace(opt.Text())
+		}
+		vals.Add(name, v)
+	})
+
+	return vals
+}
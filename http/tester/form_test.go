@@ -0,0 +1,65 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testForms = `<html><body>
+<form id="login" method="post" action="submit">
+	<input type="hidden" name="csrf" value="tok123">
+	<input type="text" name="user" value="">
+	<input type="password" name="pass">
+	<input type="checkbox" name="remember" checked>
+	<input type="checkbox" name="spam" value="yes">
+	<select name="lang"><option value="en">English</option><option value="de" selected>Deutsch</option></select>
+	<textarea name="note">hi</textarea>
+	<input type="submit" name="go" value="Login">
+</form>
+<form id="upload" method="POST" enctype="multipart/form-data" action="/forms/submit">
+	<input type="hidden" name="csrf" value="tok456">
+	<input type="text" name="title">
+</form>
+<form id="search" action="/forms/submit"><input name="q" value="default"></form>
+</body></html>`
+
+func TestSubmitForm(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/forms/page", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testForms)
+	})
+	mux.HandleFunc("/forms/submit", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var pairs []string
+		for k, vals := range r.Form {
+			pairs = append(pairs, k+"="+strings.Join(vals, ","))
+		}
+		sort.Strings(pairs)
+		fmt.Fprint(w, r.Method+" "+strings.Join(pairs, "&"))
+	})
+	tc := New(mux, t)
+
+	page := &url.URL{Path: "/forms/page"}
+	doc, resp := tc.GetHTML(page)
+	a.Equal(http.StatusOK, resp.Code)
+
+	resp = tc.SubmitForm(page, doc, "#login", url.Values{"user": {"alice"}, "pass": {"secret"}})
+	a.Equal("POST csrf=tok123&lang=de&note=hi&pass=secret&remember=on&user=alice", resp.Body.String())
+
+	resp = tc.SubmitForm(page, doc, "#upload input[name=title]", url.Values{"title": {"cat"}})
+	a.Equal("POST csrf=tok456&title=cat", resp.Body.String())
+
+	resp = tc.SubmitForm(page, doc, "#search", nil)
+	a.Equal("GET q=default", resp.Body.String())
+}
@@ -0,0 +1,38 @@
+package tester
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+)
+
+// GetJSONAs is like GetJSON but returns the decoded value.
+// The body is only decoded for 2xx responses, otherwise the zero value is returned.
+// Decode errors fail the test and name the expected type.
+func GetJSONAs[T any](t *Tester, u *url.URL, opts ...RequestOption) (T, *httptest.ResponseRecorder) {
+	t.t.Helper()
+
+	var v T
+	rw := t.do("GET", u, nil, "", opts)
+	if rw.Code >= 200 && rw.Code < 300 {
+		v = decodeAs[T](t, rw.Body.Bytes())
+	}
+	return v, rw
+}
+
+// JSONAs decodes the body of the response as T and fails the test if that isn't possible
+func JSONAs[T any](r *Response) T {
+	r.t.t.Helper()
+	return decodeAs[T](r.t, r.Body.Bytes())
+}
+
+func decodeAs[T any](t *Tester, body []byte) T {
+	t.t.Helper()
+
+	var v T
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.t.Log("Body:", string(body))
+		t.t.Fatalf("tester: failed to decode JSON as %T: %s", v, err)
+	}
+	return v
+}
@@ -0,0 +1,39 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+func TestGetJSONAs(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"ID": 1, "Name": "one"}, {"ID": 2, "Name": "two"}]`)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	tc := New(mux, t)
+
+	items, resp := GetJSONAs[[]item](tc, &url.URL{Path: "/items"})
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal([]item{{1, "one"}, {2, "two"}}, items)
+
+	gone, resp := GetJSONAs[item](tc, &url.URL{Path: "/gone"})
+	a.Equal(http.StatusGone, resp.Code)
+	a.Equal(item{}, gone)
+
+	first := JSONAs[[]map[string]interface{}](tc.Request("GET", &url.URL{Path: "/items"}).Do())
+	a.Equal("one", first[0]["Name"])
+}
@@ -0,0 +1,88 @@
+package tester
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// namespaced, so that test packages can still define their own -update flag
+var updateGolden = flag.Bool("tester.update", false, "tester: write the golden files instead of comparing against them")
+
+// shouldUpdate reports whether -tester.update was passed, or -update if the test package defines it as a bool flag
+func shouldUpdate() bool {
+	if *updateGolden {
+		return true
+	}
+	if f := flag.Lookup("update"); f != nil {
+		if g, ok := f.Value.(flag.Getter); ok {
+			b, _ := g.Get().(bool)
+			return b
+		}
+	}
+	return false
+}
+
+// Normalizer rewrites parts of a body that change on every run (like timestamps or CSRF tokens) before it is compared to a golden file
+type Normalizer func([]byte) []byte
+
+// ReplacePattern returns a Normalizer which replaces all matches of the regular expression with repl (see regexp.ReplaceAll)
+func ReplacePattern(expr, repl string) Normalizer {
+	re := regexp.MustCompile(expr)
+	return func(b []byte) []byte {
+		return re.ReplaceAll(b, []byte(repl))
+	}
+}
+
+var valueAttr = regexp.MustCompile(`\bvalue="[^"]*"`)
+
+// StripInputValue returns a Normalizer which replaces the value of the HTML inputs with the name, like hidden CSRF token fields
+func StripInputValue(name string) Normalizer {
+	re := regexp.MustCompile(`<input[^>]*\bname="` + regexp.QuoteMeta(name) + `"[^>]*>`)
+	return func(b []byte) []byte {
+		return re.ReplaceAllFunc(b, func(input []byte) []byte {
+			return valueAttr.ReplaceAll(input, []byte(`value="--stripped--"`))
+		})
+	}
+}
+
+// NormalizeGolden sets Normalizers which are applied to all bodies compared with MatchGolden, before the ones passed to it
+func NormalizeGolden(n ...Normalizer) Option {
+	return func(t *Tester) {
+		t.goldenNorm = append(t.goldenNorm, n...)
+	}
+}
+
+// MatchGolden compares the normalized body against the golden file at path.
+// If the test is run with -tester.update (or -update, if the test package defines that flag) the file is written instead.
+func (r *Response) MatchGolden(t testing.TB, path string, norm ...Normalizer) *Response {
+	t.Helper()
+
+	got := r.Body.Bytes()
+	for _, n := range append(r.t.goldenNorm, norm...) {
+		got = n(got)
+	}
+
+	if shouldUpdate() {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, got, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -tester.update to create it): %s", err)
+	}
+
+	assert.Equal(t, string(want), string(got), "body differs from %s (run with -tester.update to accept the changes)", path)
+	return r
+}
@@ -0,0 +1,48 @@
+package tester
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// like a test package that has golden files of its own, this used to panic with "flag redefined"
+var ownUpdate = flag.Bool("update", false, "update the golden files of this package")
+
+func TestUpdateFlags(t *testing.T) {
+	a := assert.New(t)
+
+	a.False(shouldUpdate())
+	*ownUpdate = true
+	a.True(shouldUpdate(), "the -update of the test package is followed")
+	*ownUpdate = false
+
+	*updateGolden = true
+	a.True(shouldUpdate())
+	*updateGolden = false
+}
+
+func TestMatchGolden(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<p>Hello at %s</p><form><input type="hidden" name="csrf" value="%d"></form>`,
+			time.Now().Format(time.RFC3339Nano), time.Now().UnixNano())
+	})
+	tc := New(handler, t, NormalizeGolden(StripInputValue("csrf")))
+
+	tc.Wrap(tc.GetBody(&url.URL{Path: "/"})).
+		MatchGolden(t, "testdata/hello.html", ReplacePattern(`at [^<]+`, "at TIME"))
+}
+
+func TestNormalizers(t *testing.T) {
+	a := assert.New(t)
+
+	in := []byte(`<input name="other" value="keep"><input value="x" name="csrf" type="hidden">`)
+	a.Equal(`<input name="other" value="keep"><input value="--stripped--" name="csrf" type="hidden">`, string(StripInputValue("csrf")(in)))
+
+	a.Equal("id=#", string(ReplacePattern(`\d+`, "#")([]byte("id=1234"))))
+}
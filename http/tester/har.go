@@ -0,0 +1,188 @@
+package tester
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the subset of HAR 1.2 (http://www.softwareishard.com/blog/har-12-spec/) that is written by Record
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // milliseconds
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+}
+
+type harRequest struct {
+	Method   string       `json:"method"`
+	URL      string       `json:"url"`
+	Headers  []harNameVal `json:"headers"`
+	PostData *harPostData `json:"postData,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status     int          `json:"status"`
+	StatusText string       `json:"statusText"`
+	Headers    []harNameVal `json:"headers"`
+	Content    harContent   `json:"content"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func harHeaders(h http.Header) []harNameVal {
+	var out []harNameVal
+	for k, vals := range h {
+		for _, v := range vals {
+			out = append(out, harNameVal{Name: k, Value: v})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Record writes every exchange of the Tester to a HAR file at path.
+// The file is rewritten after each request, so it is complete even if the test fails halfway.
+func Record(path string) Option {
+	return func(t *Tester) {
+		t.rec = &recording{path: path}
+	}
+}
+
+// recording is shared by the copies of WithContext
+type recording struct {
+	path    string
+	entries []harEntry
+}
+
+func (t *Tester) record(req *http.Request, body []byte, rw *httptest.ResponseRecorder, started time.Time, took time.Duration) {
+	if t.rec == nil {
+		return
+	}
+
+	e := harEntry{
+		StartedDateTime: started,
+		Time:            float64(took) / float64(time.Millisecond),
+		Request: harRequest{
+			Method:  req.Method,
+			URL:     jarURL(req.URL).String(),
+			Headers: harHeaders(req.Header),
+		},
+		Response: harResponse{
+			Status:     rw.Code,
+			StatusText: http.StatusText(rw.Code),
+			Headers:    harHeaders(rw.Header()),
+			Content: harContent{
+				Size:     rw.Body.Len(),
+				MimeType: rw.Header().Get("Content-Type"),
+				Text:     rw.Body.String(),
+			},
+		},
+	}
+	if body != nil {
+		e.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+	}
+	t.rec.entries = append(t.rec.entries, e)
+
+	f := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "go.mindeco.de/http/tester", Version: "1"},
+		Entries: t.rec.entries,
+	}}
+	blob, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		t.t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(t.rec.path, blob, 0600); err != nil {
+		t.t.Fatal("tester: failed to write recording:", err)
+	}
+}
+
+// Replay sends the requests of a HAR file (for instance one written by Record) to the handler again
+// and reports an error for every response that differs in status or (normalized) body from the recorded one.
+// Cookies and headers are taken from the recording, not from the Tester.
+func (t *Tester) Replay(path string, norm ...Normalizer) {
+	t.t.Helper()
+
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.t.Fatal("tester: failed to read recording:", err)
+	}
+	var f harFile
+	if err := json.Unmarshal(blob, &f); err != nil {
+		t.t.Fatal("tester: invalid recording:", err)
+	}
+
+	norm = append(t.goldenNorm, norm...)
+	normalize := func(b []byte) string {
+		for _, n := range norm {
+			b = n(b)
+		}
+		return string(b)
+	}
+
+	for i, e := range f.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			t.t.Errorf("entry %d: invalid url: %s", i, err)
+			continue
+		}
+
+		var body []byte
+		if e.Request.PostData != nil {
+			body = []byte(e.Request.PostData.Text)
+		}
+		req, err := http.NewRequest(e.Request.Method, u.RequestURI(), bytes.NewReader(body))
+		if err != nil {
+			t.t.Errorf("entry %d: %s", i, err)
+			continue
+		}
+		req = req.WithContext(t.context())
+		req.Host = u.Host
+		for _, h := range e.Request.Headers {
+			req.Header.Add(h.Name, h.Value)
+		}
+
+		rw := t.serve(req)
+
+		what := e.Request.Method + " " + e.Request.URL
+		assert.Equal(t.t, e.Response.Status, rw.Code, "entry %d (%s): status differs", i, what)
+		assert.Equal(t.t, normalize([]byte(e.Response.Content.Text)), normalize(rw.Body.Bytes()), "entry %d (%s): body differs", i, what)
+	}
+}
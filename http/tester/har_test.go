@@ -0,0 +1,46 @@
+package tester
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "tester-har")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "exchanges.har")
+
+	tc := New(newLoginMux(), t, Record(path), FollowRedirects(5))
+	tc.PostForm(&url.URL{Path: "/login"}, url.Values{"user": {"carol"}})
+	tc.GetBody(&url.URL{Path: "/missing"})
+
+	blob, err := ioutil.ReadFile(path)
+	a.NoError(err)
+	var f harFile
+	a.NoError(json.Unmarshal(blob, &f))
+	a.Equal("1.2", f.Log.Version)
+	if a.Len(f.Log.Entries, 4) {
+		login := f.Log.Entries[0]
+		a.Equal("POST", login.Request.Method)
+		a.Equal("http://localhost/login", login.Request.URL)
+		a.Equal("user=carol", login.Request.PostData.Text)
+		a.Equal(http.StatusSeeOther, login.Response.Status)
+
+		a.Equal("GET carol", f.Log.Entries[2].Response.Content.Text)
+		a.Equal(http.StatusNotFound, f.Log.Entries[3].Response.Status)
+	}
+
+	// the same handler gives the same answers
+	fresh := New(newLoginMux(), t)
+	fresh.Replay(path, ReplacePattern(`\d+`, "N"))
+}
@@ -0,0 +1,21 @@
+package tester
+
+import "net/http"
+
+// RequestOption changes the headers of a single call, without touching the ones set with SetHeaders.
+// They are applied after the Content-Type of the helper is set (so they can replace it) but before the OnRequest hooks run.
+type RequestOption func(http.Header)
+
+// WithHeader sets the header k to v for this call, replacing the value from SetHeaders, SetBasicAuth or SetBearer
+func WithHeader(k, v string) RequestOption {
+	return func(h http.Header) {
+		h.Set(k, v)
+	}
+}
+
+// WithoutHeader removes the header k for this call, for instance to send one unauthenticated request
+func WithoutHeader(k string) RequestOption {
+	return func(h http.Header) {
+		h.Del(k)
+	}
+}
@@ -0,0 +1,31 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestOptions(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "lang=%s auth=%s ct=%s", r.Header.Get("Accept-Language"), r.Header.Get("Authorization"), r.Header.Get("Content-Type"))
+	})
+	tc := New(mux, t)
+	tc.SetHeaders(http.Header{"Accept-Language": []string{"en"}})
+	tc.SetBearer("tok")
+
+	u := &url.URL{Path: "/"}
+	a.Equal("lang=de auth=Bearer tok ct=", tc.GetBody(u, WithHeader("Accept-Language", "de")).Body.String())
+	a.Equal("lang=en auth= ct=", tc.GetBody(u, WithoutHeader("Authorization")).Body.String())
+	a.Equal("lang=en auth=Bearer tok ct=application/merge-patch+json",
+		tc.PatchJSON(u, map[string]int{"a": 1}, WithHeader("Content-Type", "application/merge-patch+json")).Body.String())
+
+	// the shared headers are untouched
+	a.Equal("lang=en auth=Bearer tok ct=", tc.GetBody(u).Body.String())
+}
@@ -0,0 +1,43 @@
+package tester
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Hop is one request of a redirect chain, see FollowRedirects
+type Hop struct {
+	Method string
+	URL    *url.URL
+	Status int
+
+	rw *httptest.ResponseRecorder
+}
+
+// String returns "METHOD url status", like "POST /login 303"
+func (h Hop) String() string {
+	return fmt.Sprintf("%s %s %d", h.Method, h.URL, h.Status)
+}
+
+// Hops returns all the requests that led to this response, including the final one.
+// Without FollowRedirects (or if the response was wrapped from an older request) it only has the final one, if known.
+func (r *Response) Hops() []Hop {
+	return r.hops
+}
+
+// ExpectHops reports an error if the chain of requests differs, each hop is given in the format of Hop.String.
+//
+//	resp.ExpectHops("POST /login 303", "GET / 200")
+func (r *Response) ExpectHops(want ...string) *Response {
+	r.t.t.Helper()
+
+	got := make([]string, len(r.hops))
+	for i, h := range r.hops {
+		got[i] = h.String()
+	}
+	assert.Equal(r.t.t, want, got, "unexpected redirect chain")
+	return r
+}
@@ -0,0 +1,172 @@
+package tester
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jar is a small cookie jar which, unlike net/http/cookiejar, keeps all the attributes of the cookies so that tests can inspect them
+type jar struct {
+	mu      sync.Mutex
+	entries map[string]jarEntry
+	seq     uint64
+}
+
+type jarEntry struct {
+	c        *http.Cookie
+	hostOnly bool
+	expires  time.Time // zero for session cookies
+	seq      uint64    // creation order
+}
+
+func newJar() *jar {
+	return &jar{entries: make(map[string]jarEntry)}
+}
+
+func jarKey(domain, path, name string) string {
+	return domain + ";" + path + ";" + name
+}
+
+func (j *jar) setCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := canonicalHost(u)
+	now := time.Now()
+
+	for _, c := range cookies {
+		cc := *c
+		e := jarEntry{c: &cc}
+
+		if cc.Domain == "" {
+			e.hostOnly = true
+			cc.Domain = host
+		} else {
+			cc.Domain = strings.ToLower(strings.TrimPrefix(cc.Domain, "."))
+			if !domainMatch(host, cc.Domain) {
+				continue
+			}
+		}
+
+		if cc.Path == "" || cc.Path[0] != '/' {
+			cc.Path = defaultPath(u.Path)
+		}
+
+		key := jarKey(cc.Domain, cc.Path, cc.Name)
+
+		switch {
+		case cc.MaxAge < 0:
+			delete(j.entries, key)
+			continue
+		case cc.MaxAge > 0:
+			e.expires = now.Add(time.Duration(cc.MaxAge) * time.Second)
+			cc.Expires = e.expires
+		case !cc.Expires.IsZero():
+			if !cc.Expires.After(now) {
+				delete(j.entries, key)
+				continue
+			}
+			e.expires = cc.Expires
+		}
+
+		if old, has := j.entries[key]; has {
+			e.seq = old.seq
+		} else {
+			j.seq++
+			e.seq = j.seq
+		}
+		j.entries[key] = e
+	}
+}
+
+// cookies returns copies of the cookies which would be sent to u
+func (j *jar) cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := canonicalHost(u)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	secure := u.Scheme == "https" || host == "localhost" // browsers treat localhost as a secure context
+	now := time.Now()
+
+	var selected []jarEntry
+	for key, e := range j.entries {
+		if !e.expires.IsZero() && !e.expires.After(now) {
+			delete(j.entries, key)
+			continue
+		}
+		if e.hostOnly && host != e.c.Domain {
+			continue
+		}
+		if !e.hostOnly && !domainMatch(host, e.c.Domain) {
+			continue
+		}
+		if !pathMatch(path, e.c.Path) {
+			continue
+		}
+		if e.c.Secure && !secure {
+			continue
+		}
+		selected = append(selected, e)
+	}
+
+	// longer paths first, then the older ones (RFC 6265, 5.4)
+	sort.Slice(selected, func(a, b int) bool {
+		if la, lb := len(selected[a].c.Path), len(selected[b].c.Path); la != lb {
+			return la > lb
+		}
+		return selected[a].seq < selected[b].seq
+	})
+
+	out := make([]*http.Cookie, len(selected))
+	for i, e := range selected {
+		cc := *e.c
+		out[i] = &cc
+	}
+	return out
+}
+
+// remove deletes all cookies with the name, regardless of domain and path
+func (j *jar) remove(name string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for key, e := range j.entries {
+		if e.c.Name == name {
+			delete(j.entries, key)
+		}
+	}
+}
+
+func canonicalHost(u *url.URL) string {
+	return strings.ToLower(jarURL(u).Hostname())
+}
+
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func pathMatch(reqPath, cookiePath string) bool {
+	if reqPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(reqPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+}
+
+// defaultPath is the directory of the request path (RFC 6265, 5.1.4)
+func defaultPath(p string) string {
+	i := strings.LastIndex(p, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return p[:i]
+}
@@ -0,0 +1,57 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookieAPI(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/set", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 60, HttpOnly: true, SameSite: http.SameSiteLaxMode})
+		http.SetCookie(w, &http.Cookie{Name: "admin", Value: "1", Path: "/admin"})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		for _, c := range r.Cookies() {
+			fmt.Fprintf(w, "%s=%s;", c.Name, c.Value)
+		}
+	})
+	tc := New(mux, t)
+
+	tc.GetBody(&url.URL{Path: "/set"})
+
+	root := &url.URL{Path: "/"}
+	cookies := tc.Cookies(root)
+	if a.Len(cookies, 1) {
+		c := cookies[0]
+		a.Equal("session", c.Name)
+		a.True(c.HttpOnly)
+		a.Equal(http.SameSiteLaxMode, c.SameSite)
+		a.WithinDuration(time.Now().Add(time.Minute), c.Expires, 2*time.Second)
+	}
+	a.Len(tc.Cookies(&url.URL{Path: "/admin/users"}), 2)
+	a.Len(tc.Cookies(&url.URL{Path: "/administrator"}), 1)
+
+	// tampering
+	tc.SetCookie(root, &http.Cookie{Name: "session", Value: "forged", Path: "/"})
+	a.Equal("session=forged;", tc.GetBody(root).Body.String())
+
+	tc.RemoveCookie("session")
+	a.Equal("", tc.GetBody(root).Body.String())
+
+	// expired cookies are dropped
+	tc.SetCookie(root, &http.Cookie{Name: "old", Value: "x", Expires: time.Now().Add(-time.Hour)})
+	a.Len(tc.Cookies(root), 0)
+
+	// other hosts don't get them
+	tc.SetCookie(root, &http.Cookie{Name: "local", Value: "x"})
+	a.Len(tc.Cookies(&url.URL{Scheme: "http", Host: "example.com", Path: "/"}), 0)
+	a.Len(tc.Cookies(&url.URL{Scheme: "http", Host: "localhost", Path: "/"}), 1)
+}
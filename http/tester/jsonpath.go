@@ -0,0 +1,116 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// JSONPath returns the part of the JSON body selected by path.
+// Only a small subset of JSONPath is supported: $ for the root, .key or ['key'] for object fields and [n] for array elements.
+// For example $.items[0].id or $['odd key'][2].
+func (r *Response) JSONPath(path string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(r.Body.Bytes(), &v); err != nil {
+		return nil, fmt.Errorf("tester: body is not JSON: %w", err)
+	}
+	return evalJSONPath(v, path)
+}
+
+// ExpectJSONPath reports an error if the value selected by path doesn't have the same JSON encoding as want
+func (r *Response) ExpectJSONPath(path string, want interface{}) *Response {
+	r.t.t.Helper()
+
+	got, err := r.JSONPath(path)
+	if err != nil {
+		r.t.t.Errorf("%s: %s", path, err)
+		return r
+	}
+
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		r.t.t.Errorf("failed to encode the expected value: %s", err)
+		return r
+	}
+	gotJSON, _ := json.Marshal(got)
+
+	assert.JSONEq(r.t.t, string(wantJSON), string(gotJSON), "at %s", path)
+	return r
+}
+
+func evalJSONPath(v interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("tester: json path needs to start with $")
+	}
+	rest := path[1:]
+	walked := "$"
+
+	for rest != "" {
+		var key string
+		index := -1
+
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key = rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("tester: empty key after %s", walked)
+			}
+			rest = rest[end+1:]
+
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end == -1 {
+				return nil, fmt.Errorf("tester: unterminated key after %s", walked)
+			}
+			key = rest[2:end]
+			rest = rest[end+2:]
+
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("tester: unterminated index after %s", walked)
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("tester: invalid index %q after %s", rest[1:end], walked)
+			}
+			index = n
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("tester: unexpected %q after %s", rest, walked)
+		}
+
+		if index >= 0 {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("tester: %s is not an array", walked)
+			}
+			if index >= len(arr) {
+				return nil, fmt.Errorf("tester: %s has only %d elements", walked, len(arr))
+			}
+			v = arr[index]
+			walked += fmt.Sprintf("[%d]", index)
+			continue
+		}
+
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("tester: %s is not an object", walked)
+		}
+		v, ok = obj[key]
+		if !ok {
+			return nil, fmt.Errorf("tester: %s has no key %q", walked, key)
+		}
+		walked += "." + key
+	}
+
+	return v, nil
+}
@@ -0,0 +1,34 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPath(t *testing.T) {
+	a := assert.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": [{"id": 42, "tags": ["x"]}, {"id": 23}], "odd key": {"n": null}}`)
+	})
+	tc := New(handler, t)
+
+	resp := tc.Wrap(tc.GetBody(&url.URL{Path: "/"}))
+	resp.ExpectJSONPath("$.items[0].id", 42).
+		ExpectJSONPath("$.items[1]", map[string]int{"id": 23}).
+		ExpectJSONPath("$.items[0].tags", []string{"x"}).
+		ExpectJSONPath("$['odd key'].n", nil)
+
+	v, err := resp.JSONPath("$.items")
+	a.NoError(err)
+	a.Len(v, 2)
+
+	for _, bad := range []string{"items", "$.items[2]", "$.items.id", "$.nope", "$.items[x]", "$['odd key'"} {
+		_, err = resp.JSONPath(bad)
+		a.Error(err, bad)
+	}
+}
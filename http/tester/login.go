@@ -0,0 +1,60 @@
+package tester
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+)
+
+// the default session name of the auth package
+const defaultSessionCookie = "AuthSession"
+
+// SessionCookie sets the name of the cookie LoginForm expects, if the auth.Handler uses SetSessionName
+func SessionCookie(name string) Option {
+	return func(t *Tester) {
+		t.sessionCookie = name
+	}
+}
+
+// LoginForm posts user and pass to the login handler at u (like auth.Handler.Authorize expects them) and follows the redirect.
+// It fails the test if the login wasn't answered with a redirect that sets the session cookie.
+// The cookie is kept in the jar, so the following requests are authenticated.
+func (t *Tester) LoginForm(u *url.URL, user, pass string) *httptest.ResponseRecorder {
+	t.t.Helper()
+	rw, _ := t.login(u, user, pass)
+	return rw
+}
+
+// LoginCookie is like LoginForm but returns the session cookie,
+// to prime other Testers (with SetCookie) or clients that should use the same session.
+func (t *Tester) LoginCookie(u *url.URL, user, pass string) *http.Cookie {
+	t.t.Helper()
+	_, c := t.login(u, user, pass)
+	return c
+}
+
+func (t *Tester) login(u *url.URL, user, pass string) (*httptest.ResponseRecorder, *http.Cookie) {
+	t.t.Helper()
+
+	if prev := t.maxRedirects; prev < 1 {
+		t.maxRedirects = 1
+		defer func() { t.maxRedirects = prev }()
+	}
+
+	vals := url.Values{"user": []string{user}, "pass": []string{pass}}
+	rw := t.PostForm(u, vals)
+
+	first := t.hops[0].rw
+	if !isRedirect(first.Code) {
+		t.t.Fatalf("tester: login as %q failed with status %d: %s", user, first.Code, strings.TrimSpace(first.Body.String()))
+	}
+
+	for _, c := range first.Result().Cookies() {
+		if c.Name == t.sessionCookie && c.Value != "" && c.MaxAge >= 0 {
+			return rw, c
+		}
+	}
+	t.t.Fatalf("tester: login as %q didn't set the %s cookie", user, t.sessionCookie)
+	return nil, nil
+}
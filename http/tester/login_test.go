@@ -0,0 +1,84 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mindeco.de/http/auth"
+)
+
+type staticAuther struct{ user, pass string }
+
+func (sa staticAuther) Check(user, pass string) (interface{}, error) {
+	if user != sa.user || pass != sa.pass {
+		return nil, auth.ErrBadLogin
+	}
+	return user, nil
+}
+
+func newAuthMux(t *testing.T, opts ...auth.Option) *http.ServeMux {
+	store := &sessions.CookieStore{
+		Codecs:  securecookie.CodecsFromPairs(securecookie.GenerateRandomKey(32)),
+		Options: &sessions.Options{Path: "/", MaxAge: 30},
+	}
+	ah, err := auth.NewHandler(staticAuther{"alice", "secret"}, append([]auth.Option{auth.SetStore(store)}, opts...)...)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", ah.Authorize)
+	mux.Handle("/", ah.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.FromContext(r.Context())
+		fmt.Fprint(w, "hello ", user)
+	})))
+	return mux
+}
+
+func TestLoginForm(t *testing.T) {
+	a := assert.New(t)
+
+	tc := New(newAuthMux(t), t)
+	rw := tc.LoginForm(&url.URL{Path: "/login"}, "alice", "secret")
+	a.Equal(http.StatusOK, rw.Code)
+	a.Equal("hello alice", rw.Body.String())
+
+	a.Equal("hello alice", tc.GetBody(&url.URL{Path: "/"}).Body.String())
+	a.Equal(0, tc.maxRedirects, "should restore the redirect setting")
+}
+
+func TestLoginCookie(t *testing.T) {
+	a := assert.New(t)
+
+	mux := newAuthMux(t, auth.SetSessionName("custom"))
+	tc := New(mux, t, SessionCookie("custom"))
+	c := tc.LoginCookie(&url.URL{Path: "/login"}, "alice", "secret")
+	a.Equal("custom", c.Name)
+
+	other := New(mux, t)
+	other.SetCookie(&url.URL{Path: "/"}, c)
+	a.Equal("hello alice", other.GetBody(&url.URL{Path: "/"}).Body.String())
+}
+
+func TestLoginFormFails(t *testing.T) {
+	a := assert.New(t)
+
+	tc := New(newAuthMux(t), t)
+	col := &collector{parent: t}
+	tc.t = col
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tc.LoginForm(&url.URL{Path: "/login"}, "alice", "wrong")
+	}()
+	<-done
+
+	if a.Len(col.failures, 1) {
+		a.Contains(col.failures[0], `login as "alice" failed with status 400`)
+	}
+}
@@ -0,0 +1,52 @@
+package tester
+
+import (
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// maxResponseMeta is how many recorders responseMeta remembers, in case they are never wrapped
+const maxResponseMeta = 64
+
+// responseMeta keeps what the Tester knows about its responses beyond the recorder, until a Response takes it (see take)
+type responseMeta struct {
+	mu    sync.Mutex
+	infos map[*httptest.ResponseRecorder]responseInfo
+	order []*httptest.ResponseRecorder // oldest first
+}
+
+type responseInfo struct {
+	took time.Duration
+	raw  []byte // the body before it was decompressed, nil if it wasn't
+}
+
+func newResponseMeta() *responseMeta {
+	return &responseMeta{infos: make(map[*httptest.ResponseRecorder]responseInfo)}
+}
+
+func (m *responseMeta) set(rw *httptest.ResponseRecorder, info responseInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.infos[rw] = info
+	m.order = append(m.order, rw)
+	if len(m.order) > maxResponseMeta {
+		delete(m.infos, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+// take returns the info of rw and forgets it, so the Tester doesn't keep every recorder of a long test alive
+func (m *responseMeta) take(rw *httptest.ResponseRecorder) (responseInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info, ok := m.infos[rw]
+	delete(m.infos, rw)
+	for i, o := range m.order {
+		if o == rw {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	return info, ok
+}
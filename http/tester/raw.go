@@ -0,0 +1,22 @@
+package tester
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// Do sends req as it is, for the cases the other helpers can't express (trailers, unusual methods, malformed bodies).
+// Only the cookies of the jar are added (unless req already has one with the same name) and the cookies of the response are stored.
+// The headers of the Tester are not applied and redirects are not followed.
+func (t *Tester) Do(req *http.Request) *httptest.ResponseRecorder {
+	t.t.Helper()
+
+	for _, c := range t.jar.cookies(req.URL) {
+		if _, err := req.Cookie(c.Name); err == http.ErrNoCookie {
+			req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+	}
+
+	t.chain, t.hops = nil, nil
+	return t.roundTrip(req, nil)
+}
@@ -0,0 +1,51 @@
+package tester
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		c, err := r.Cookie("session")
+		if err != nil {
+			http.Error(w, "no session", http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "seen", Value: r.Method})
+		fmt.Fprintf(w, "%s %s %s trailer=%s", r.Method, c.Value, body, r.Trailer.Get("X-Checksum"))
+	})
+	tc := New(mux, t)
+	tc.SetHeaders(http.Header{"X-Shared": []string{"no"}})
+	tc.SetCookie(&url.URL{Path: "/"}, &http.Cookie{Name: "session", Value: "jar"})
+
+	req := httptest.NewRequest("PURGE", "/cache", strings.NewReader("raw"))
+	req.Trailer = http.Header{"X-Checksum": []string{"abc"}}
+	rw := tc.Do(req)
+	a.Equal("PURGE jar raw trailer=abc", rw.Body.String())
+	a.Empty(req.Header.Get("X-Shared"), "should not add the shared headers")
+
+	var seen string
+	for _, c := range tc.Cookies(&url.URL{Path: "/"}) {
+		if c.Name == "seen" {
+			seen = c.Value
+		}
+	}
+	a.Equal("PURGE", seen, "should store response cookies")
+
+	// an explicit cookie wins over the jar
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "mine"})
+	a.Equal("GET mine  trailer=", tc.Do(req).Body.String())
+}
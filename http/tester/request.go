@@ -0,0 +1,158 @@
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// RequestBuilder collects the parts of a request until Do sends it, see (*Tester).Request
+type RequestBuilder struct {
+	t *Tester
+
+	method string
+	u      url.URL
+	query  url.Values
+	header http.Header
+
+	body        []byte
+	stream      io.Reader
+	size        int64
+	contentType string
+
+	ctx context.Context
+}
+
+// Request starts building a request with an arbitrary method, for example:
+//
+//	resp := tc.Request("PATCH", u).Header("X-Token", tok).Query("force", "1").JSON(body).Do()
+func (t *Tester) Request(method string, u *url.URL) *RequestBuilder {
+	rb := &RequestBuilder{
+		t:      t,
+		method: method,
+		u:      *u,
+		query:  u.Query(),
+		header: make(http.Header),
+	}
+	return rb
+}
+
+// Context sets the context of the request, see (*Tester).WithContext
+func (rb *RequestBuilder) Context(ctx context.Context) *RequestBuilder {
+	rb.ctx = ctx
+	return rb
+}
+
+// Header adds a header to the request
+func (rb *RequestBuilder) Header(key, value string) *RequestBuilder {
+	rb.header.Add(key, value)
+	return rb
+}
+
+// Query adds a query parameter to the request, on top of the ones already in the URL
+func (rb *RequestBuilder) Query(key, value string) *RequestBuilder {
+	rb.query.Add(key, value)
+	return rb
+}
+
+// Body sets the raw body and its content type
+func (rb *RequestBuilder) Body(contentType string, body []byte) *RequestBuilder {
+	rb.contentType = contentType
+	rb.body = body
+	rb.stream = nil
+	return rb
+}
+
+// Stream sets a body that is passed to the handler as it is read, see PostStream for size
+func (rb *RequestBuilder) Stream(contentType string, body io.Reader, size int64) *RequestBuilder {
+	rb.contentType = contentType
+	rb.body = nil
+	rb.stream = body
+	rb.size = size
+	return rb
+}
+
+// JSON sets the body to the JSON encoding of v
+func (rb *RequestBuilder) JSON(v interface{}) *RequestBuilder {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		rb.t.t.Fatal(err)
+	}
+	return rb.Body("application/json", blob)
+}
+
+// Form sets the body to the url encoded form values
+func (rb *RequestBuilder) Form(v url.Values) *RequestBuilder {
+	return rb.Body("application/x-www-form-urlencoded", []byte(v.Encode()))
+}
+
+// Do sends the request, with the headers and cookies of the Tester
+func (rb *RequestBuilder) Do() *Response {
+	u := rb.u
+	u.RawQuery = rb.query.Encode()
+
+	t := rb.t
+	if rb.ctx != nil {
+		t = t.WithContext(rb.ctx)
+	}
+	body := requestBody{data: rb.body, stream: rb.stream, size: rb.size, contentType: rb.contentType}
+	rw := t.send(rb.method, &u, body, rb.header, nil)
+	r := &Response{ResponseRecorder: rw, t: rb.t, hops: t.hops}
+	r.info, r.hasInfo = t.meta.take(rw)
+	return r
+}
+
+// Response wraps the recorded response with helpers to decode it
+type Response struct {
+	*httptest.ResponseRecorder
+
+	t    *Tester
+	hops []Hop
+	doc  *goquery.Document // parsed by HTML, once
+
+	info    responseInfo // timing and raw body, taken from the Tester
+	hasInfo bool
+}
+
+// String returns the body as a string
+func (r *Response) String() string {
+	return r.Body.String()
+}
+
+// JSON decodes the body into v and fails the test if that isn't possible
+func (r *Response) JSON(v interface{}) {
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.t.Log("Body:", r.Body.String())
+		r.t.t.Fatal(err)
+	}
+}
+
+// HTML parses the body and fails the test if that isn't possible.
+// The document is only parsed once per Response.
+func (r *Response) HTML() *goquery.Document {
+	if r.doc != nil {
+		return r.doc
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(r.Body.String()))
+	if err != nil {
+		r.t.t.Fatal(err)
+	}
+	r.doc = doc
+	return doc
+}
+
+// Cookies returns the cookies set by the response
+func (r *Response) Cookies() []*http.Cookie {
+	return r.Result().Cookies()
+}
+
+// Location returns the Location header, for redirects
+func (r *Response) Location() string {
+	return r.Header().Get("Location")
+}
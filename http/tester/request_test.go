@@ -0,0 +1,93 @@
+package tester
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type echo struct {
+	Method string
+	Query  url.Values
+	Token  string
+	Body   map[string]string
+}
+
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	e := echo{
+		Method: r.Method,
+		Query:  r.URL.Query(),
+		Token:  r.Header.Get("X-Token"),
+	}
+	if r.Header.Get("Content-Type") == "application/json" {
+		json.NewDecoder(r.Body).Decode(&e.Body)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+func TestRequestBuilder(t *testing.T) {
+	a := assert.New(t)
+
+	tc := New(http.HandlerFunc(echoHandler), t)
+
+	resp := tc.Request("PATCH", &url.URL{Path: "/things/1", RawQuery: "a=1"}).
+		Header("X-Token", "secret").
+		Query("force", "yes").
+		JSON(map[string]string{"name": "new"}).
+		Do()
+	a.Equal(http.StatusOK, resp.Code)
+
+	var e echo
+	resp.JSON(&e)
+	a.Equal("PATCH", e.Method)
+	a.Equal("1", e.Query.Get("a"))
+	a.Equal("yes", e.Query.Get("force"))
+	a.Equal("secret", e.Token)
+	a.Equal("new", e.Body["name"])
+
+	// headers of the builder don't stick to the Tester
+	resp = tc.Request("GET", &url.URL{Path: "/"}).Do()
+	e = echo{}
+	resp.JSON(&e)
+	a.Equal("", e.Token)
+}
+
+func TestVerbHelpers(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", echoHandler)
+	mux.HandleFunc("/form", func(w http.ResponseWriter, r *http.Request) {
+		// ParseForm ignores the body of DELETE requests
+		body, _ := ioutil.ReadAll(r.Body)
+		vals, _ := url.ParseQuery(string(body))
+		w.Write([]byte(r.Method + " " + vals.Get("id")))
+	})
+	tc := New(mux, t)
+	u := &url.URL{Path: "/echo"}
+	body := map[string]string{"name": "x"}
+
+	for method, send := range map[string]func() *Response{
+		"PUT":    func() *Response { return tc.Wrap(tc.PutJSON(u, body)) },
+		"PATCH":  func() *Response { return tc.Wrap(tc.PatchJSON(u, body)) },
+		"DELETE": func() *Response { return tc.Wrap(tc.Delete(u)) },
+	} {
+		var e echo
+		send().JSON(&e)
+		a.Equal(method, e.Method)
+		if method != "DELETE" {
+			a.Equal("x", e.Body["name"], method)
+		}
+	}
+
+	resp := tc.DeleteForm(&url.URL{Path: "/form"}, url.Values{"id": {"23"}})
+	a.Equal("DELETE 23", resp.Body.String())
+
+	resp = tc.PutForm(&url.URL{Path: "/form"}, url.Values{"id": {"42"}})
+	a.Equal("PUT 42", resp.Body.String())
+}
@@ -0,0 +1,36 @@
+package tester
+
+import (
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+// Router sets the gorilla/mux router used by URLFor.
+// It is only needed if the handler passed to New isn't the router itself (for instance because it is wrapped in middleware).
+func Router(r *mux.Router) Option {
+	return func(t *Tester) {
+		t.router = r
+	}
+}
+
+// URLFor builds the URL of the named route of the router under test, with the variables as key/value pairs.
+// Tests that use it survive changes of the paths. It fails the test if the route doesn't exist or the variables don't fit.
+func (t *Tester) URLFor(name string, pairs ...string) *url.URL {
+	t.t.Helper()
+
+	if t.router == nil {
+		t.t.Fatal("tester: URLFor needs a *mux.Router, pass it to New or use the Router option")
+	}
+
+	route := t.router.Get(name)
+	if route == nil {
+		t.t.Fatalf("tester: no route named %q", name)
+	}
+
+	u, err := route.URL(pairs...)
+	if err != nil {
+		t.t.Fatalf("tester: failed to build the URL of %q: %s", name, err)
+	}
+	return u
+}
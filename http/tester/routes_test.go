@@ -0,0 +1,28 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLFor(t *testing.T) {
+	a := assert.New(t)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/users/{name}/posts/{id:[0-9]+}", func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		fmt.Fprintf(w, "%s %s", vars["name"], vars["id"])
+	}).Name("post")
+
+	tc := New(r, t)
+	u := tc.URLFor("post", "name", "alice", "id", "23")
+	a.Equal("/users/alice/posts/23", u.Path)
+	a.Equal("alice 23", tc.GetBody(u).Body.String())
+
+	wrapped := New(http.StripPrefix("", r), t, Router(r))
+	a.Equal("/users/bob/posts/1", wrapped.URLFor("post", "name", "bob", "id", "1").Path)
+}
@@ -0,0 +1,132 @@
+package tester
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ServeTLS makes NewServer use TLS with HTTP/2 enabled. The client trusts the certificate of the test server.
+func ServeTLS() Option {
+	return func(t *Tester) {
+		t.serveTLS = true
+	}
+}
+
+// ServeUnix makes NewServer listen on a unix socket in a temporary directory, like services that are behind a local proxy.
+// The requests of the Tester are dialed there, URL returns http://localhost then.
+func ServeUnix() Option {
+	return func(t *Tester) {
+		t.serveUnix = true
+	}
+}
+
+// NewServer is like New but serves h on a real listener (see httptest.Server) and sends the requests with a http.Client.
+// This is needed for handlers that depend on real connections, like hijacking, streaming or HTTP/2.
+// All the helpers work the same way, call Close once the test is done.
+func NewServer(h http.Handler, t *testing.T, opts ...Option) *Tester {
+	tester := New(h, t, opts...)
+
+	srv := httptest.NewUnstartedServer(tester.mux)
+	if tester.serveUnix {
+		tester.socket = filepath.Join(t.TempDir(), "http.sock")
+		l, err := net.Listen("unix", tester.socket)
+		if err != nil {
+			t.Fatal("tester: failed to listen on unix socket:", err)
+		}
+		srv.Listener.Close()
+		srv.Listener = l
+	}
+	if tester.serveTLS {
+		srv.EnableHTTP2 = true
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+
+	tester.srv = srv
+	tester.client = srv.Client()
+	if tester.socket != "" {
+		srv.URL = strings.Replace(srv.URL, "://"+tester.socket, "://localhost", 1)
+		tr := tester.client.Transport.(*http.Transport).Clone()
+		tr.DialContext = tester.dialUnix
+		tester.client.Transport = tr
+	}
+	tester.client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		// do follows them, if FollowRedirects was set
+		return http.ErrUseLastResponse
+	}
+
+	return tester
+}
+
+func (t *Tester) dialUnix(ctx context.Context, _, _ string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", t.socket)
+}
+
+// URL returns the base URL of the server started by NewServer, it is empty for in-process Testers
+func (t *Tester) URL() string {
+	if t.srv == nil {
+		return ""
+	}
+	return t.srv.URL
+}
+
+// Close stops the server started by NewServer, it does nothing for in-process Testers
+func (t *Tester) Close() {
+	if t.srv != nil {
+		t.srv.Close()
+	}
+}
+
+// serve sends the request to the handler, in-process or over the network
+func (t *Tester) serve(req *http.Request) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+	if t.srv == nil {
+		t.mux.ServeHTTP(rw, req)
+		return rw
+	}
+
+	resp, err := t.client.Do(t.toServer(req))
+	if err != nil {
+		t.t.Fatal("tester: request failed:", err)
+	}
+	defer resp.Body.Close()
+
+	for k, vals := range resp.Header {
+		rw.Header()[k] = vals
+	}
+	rw.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(rw, resp.Body); err != nil {
+		t.t.Fatal("tester: failed to read response body:", err)
+	}
+	return rw
+}
+
+// toServer points the request to the listener of the server, keeping an explicit host as the Host header
+func (t *Tester) toServer(req *http.Request) *http.Request {
+	base, err := url.Parse(t.srv.URL)
+	if err != nil {
+		t.t.Fatal(err)
+	}
+
+	out := req.Clone(req.Context())
+	target := *req.URL
+	target.Scheme = base.Scheme
+	target.Host = base.Host
+	out.URL = &target
+	if req.URL.Host != "" {
+		out.Host = req.URL.Host
+	} else {
+		out.Host = ""
+	}
+	out.RequestURI = ""
+	return out
+}
@@ -0,0 +1,94 @@
+package tester
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewServer(t *testing.T) {
+	a := assert.New(t)
+
+	mux := newLoginMux()
+	mux.HandleFunc("/proto", func(w http.ResponseWriter, r *http.Request) {
+		_, hijackable := w.(http.Hijacker)
+		fmt.Fprintf(w, "%s %v", r.Proto, hijackable)
+	})
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: one\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	var upgrader websocket.Upgrader
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte("hi"))
+		conn.Close()
+	})
+
+	tc := NewServer(mux, t, FollowRedirects(5))
+	defer tc.Close()
+	a.NotEqual("", tc.URL())
+
+	resp := tc.PostForm(&url.URL{Path: "/login"}, url.Values{"user": {"bob"}})
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal("GET bob", resp.Body.String())
+	a.Len(tc.Chain(), 2)
+
+	a.Equal("HTTP/1.1 true", tc.GetBody(&url.URL{Path: "/proto"}).Body.String())
+
+	var got []string
+	tc.GetSSE(&url.URL{Path: "/events"}, func(e Event) bool {
+		got = append(got, e.Data)
+		return false
+	})
+	a.Equal([]string{"one"}, got)
+
+	ws := tc.DialWebSocket(&url.URL{Path: "/ws"})
+	ws.ExpectText("hi")
+	ws.Close()
+
+	tls := NewServer(mux, t, ServeTLS())
+	defer tls.Close()
+	a.Equal("HTTP/2.0 false", tls.GetBody(&url.URL{Path: "/proto"}).Body.String())
+}
+
+func TestNewServerUnix(t *testing.T) {
+	a := assert.New(t)
+
+	mux := newLoginMux()
+	var upgrader websocket.Upgrader
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte("hi"))
+		conn.Close()
+	})
+	mux.HandleFunc("/addr", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Context().Value(http.LocalAddrContextKey).(net.Addr).Network())
+	})
+
+	tc := NewServer(mux, t, ServeUnix(), FollowRedirects(5))
+	defer tc.Close()
+	a.Equal("http://localhost", tc.URL())
+
+	a.Equal("unix", tc.GetBody(&url.URL{Path: "/addr"}).Body.String())
+
+	resp := tc.PostForm(&url.URL{Path: "/login"}, url.Values{"user": {"bob"}})
+	a.Equal("GET bob", resp.Body.String())
+
+	ws := tc.DialWebSocket(&url.URL{Path: "/ws"})
+	ws.ExpectText("hi")
+	ws.Close()
+}
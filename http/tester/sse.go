@@ -0,0 +1,235 @@
+package tester
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is a server-sent event, see GetSSE
+type Event struct {
+	ID    string
+	Event string // the type of the event, empty for the default "message"
+	Data  string // multiple data lines are joined with newlines
+	Retry int    // reconnection time in milliseconds, if the server sent one
+}
+
+// StreamTimeout sets how long GetSSE waits for the stream before it gives up (default 5 seconds)
+func StreamTimeout(d time.Duration) Option {
+	return func(t *Tester) {
+		t.streamTimeout = d
+	}
+}
+
+// GetSSE sends a GET request for an text/event-stream and calls fn for every event as it arrives.
+// Reading stops (and the request context is canceled) once fn returns false, the handler ends the stream or the StreamTimeout passed.
+// It returns the status and headers of the response. The callback is not called for responses other than 200.
+// A timeout fails the test.
+func (t *Tester) GetSSE(u *url.URL, fn func(Event) bool) *http.Response {
+	t.t.Helper()
+
+	timeout := t.streamTimeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(t.context(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		t.t.Fatal(err)
+	}
+	req = req.WithContext(ctx)
+	t.constructHeader(&req.Header, u)
+	req.Header.Set("Accept", "text/event-stream")
+	for _, hook := range t.onRequest {
+		hook(req)
+	}
+
+	resp, wait := t.openStream(ctx, req)
+	if resp == nil {
+		t.t.Fatal("tester: timeout waiting for the event stream to start")
+	}
+	t.jar.setCookies(u, resp.Cookies())
+
+	if resp.StatusCode != http.StatusOK {
+		cancel()
+		resp.Body.Close()
+		wait()
+		return resp
+	}
+
+	events := make(chan Event)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- parseEvents(resp.Body, events, ctx.Done())
+		close(events)
+	}()
+
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				wait()
+				if err := <-readErr; err != nil && err != io.ErrClosedPipe && ctx.Err() == nil {
+					t.t.Error("tester: reading event stream failed:", err)
+				}
+				return resp
+			}
+			if !fn(evt) {
+				cancel()
+				resp.Body.Close()
+				wait()
+				return resp
+			}
+		case <-ctx.Done():
+			resp.Body.Close()
+			wait()
+			if ctx.Err() == context.DeadlineExceeded {
+				t.t.Fatal("tester: timeout waiting for events")
+			}
+			return resp
+		}
+	}
+}
+
+// streamStopTimeout is how long a streaming handler gets to return after its request was canceled
+var streamStopTimeout = 5 * time.Second
+
+// openStream starts the request and returns the response once the header is there, with a body that can be read as it is written.
+// wait blocks until the handler returned and fails the test if that takes longer than streamStopTimeout. It returns a nil response if ctx is done before the response started.
+func (t *Tester) openStream(ctx context.Context, req *http.Request) (resp *http.Response, wait func()) {
+	if t.srv != nil {
+		resp, err := t.client.Do(t.toServer(req))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, func() {}
+			}
+			t.t.Fatal("tester: request failed:", err)
+		}
+		return resp, func() {}
+	}
+
+	pr, pw := io.Pipe()
+	sw := &streamWriter{
+		header:  make(http.Header),
+		pw:      pw,
+		started: make(chan struct{}),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t.mux.ServeHTTP(sw, req)
+		sw.start(http.StatusOK)
+		pw.Close()
+	}()
+	wait = func() {
+		t.t.Helper()
+		select {
+		case <-done:
+		case <-time.After(streamStopTimeout):
+			t.t.Fatal("tester: the streaming handler didn't return after the request was canceled, it needs to stop once r.Context() is done")
+		}
+	}
+
+	select {
+	case <-sw.started:
+	case <-ctx.Done():
+		pr.Close()
+		return nil, wait
+	}
+
+	resp = &http.Response{
+		StatusCode: sw.status,
+		Status:     http.StatusText(sw.status),
+		Header:     sw.sent,
+		Body:       pr,
+		Request:    req,
+	}
+	return resp, wait
+}
+
+// parseEvents reads text/event-stream frames from r until it ends
+func parseEvents(r io.Reader, events chan<- Event, stop <-chan struct{}) error {
+	scanner := bufio.NewScanner(r)
+	var (
+		evt     Event
+		data    []string
+		hasData bool
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if hasData {
+				evt.Data = strings.Join(data, "\n")
+				select {
+				case events <- evt:
+				case <-stop:
+					return nil
+				}
+			}
+			evt, data, hasData = Event{ID: evt.ID}, nil, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "id":
+			evt.ID = value
+		case "event":
+			evt.Event = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil {
+				evt.Retry = n
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// streamWriter is a http.ResponseWriter which passes the body on as it is written, instead of buffering it like httptest.ResponseRecorder
+type streamWriter struct {
+	header http.Header
+	pw     *io.PipeWriter
+
+	once    sync.Once
+	status  int
+	sent    http.Header // the header at the time the response started
+	started chan struct{}
+}
+
+func (sw *streamWriter) start(status int) {
+	sw.once.Do(func() {
+		sw.status = status
+		sw.sent = sw.header.Clone()
+		close(sw.started)
+	})
+}
+
+func (sw *streamWriter) Header() http.Header { return sw.header }
+
+func (sw *streamWriter) WriteHeader(status int) { sw.start(status) }
+
+func (sw *streamWriter) Write(b []byte) (int, error) {
+	sw.start(http.StatusOK)
+	return sw.pw.Write(b)
+}
+
+// Flush implements http.Flusher, writes are passed on right away anyway
+func (sw *streamWriter) Flush() { sw.start(http.StatusOK) }
@@ -0,0 +1,110 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSSE(t *testing.T) {
+	a := assert.New(t)
+
+	stopped := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		f := w.(http.Flusher)
+		fmt.Fprint(w, ": hello\n\nretry: 1000\nid: 1\nevent: greeting\ndata: hello\ndata: world\n\n")
+		f.Flush()
+		for i := 2; ; i++ {
+			select {
+			case <-r.Context().Done():
+				close(stopped)
+				return
+			case <-time.After(time.Millisecond):
+			}
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			f.Flush()
+		}
+	})
+	mux.HandleFunc("/short", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: only\n\n")
+	})
+	mux.HandleFunc("/denied", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	})
+	tc := New(mux, t, StreamTimeout(2*time.Second))
+
+	var got []Event
+	resp := tc.GetSSE(&url.URL{Path: "/events"}, func(e Event) bool {
+		got = append(got, e)
+		return len(got) < 3
+	})
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+	if a.Len(got, 3) {
+		a.Equal(Event{ID: "1", Event: "greeting", Data: "hello\nworld", Retry: 1000}, got[0])
+		a.Equal(Event{ID: "1", Data: "2"}, got[1])
+		a.Equal("3", got[2].Data)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not canceled")
+	}
+
+	got = nil
+	tc.GetSSE(&url.URL{Path: "/short"}, func(e Event) bool {
+		got = append(got, e)
+		return true
+	})
+	a.Len(got, 1)
+
+	resp = tc.GetSSE(&url.URL{Path: "/denied"}, func(e Event) bool {
+		t.Error("should not be called")
+		return false
+	})
+	a.Equal(http.StatusForbidden, resp.StatusCode)
+}
+
+func TestGetSSEStuckHandler(t *testing.T) {
+	a := assert.New(t)
+
+	old := streamStopTimeout
+	streamStopTimeout = 10 * time.Millisecond
+	defer func() { streamStopTimeout = old }()
+
+	release := make(chan struct{})
+	defer close(release)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-release // doesn't look at r.Context()
+	})
+
+	tc := New(mux, t)
+	col := &collector{parent: t}
+	tc.t = col
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tc.GetSSE(&url.URL{Path: "/events"}, func(Event) bool { return false })
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("GetSSE hangs")
+	}
+	if a.Len(col.failures, 1) {
+		a.Contains(col.failures[0], "didn't return after the request was canceled")
+	}
+}
@@ -0,0 +1,71 @@
+package tester
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func uploadMux(gotFirst chan<- struct{}) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		first := make([]byte, 5)
+		if _, err := io.ReadFull(r.Body, first); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		close(gotFirst)
+		rest, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "%s %v %d %s%s", r.Header.Get("Content-Type"), r.TransferEncoding, r.ContentLength, first, rest)
+	})
+	return mux
+}
+
+func TestPostStream(t *testing.T) {
+	for _, mode := range []string{"in-process", "server"} {
+		t.Run(mode, func(t *testing.T) {
+			a := assert.New(t)
+
+			gotFirst := make(chan struct{})
+			tc := New(uploadMux(gotFirst), t)
+			if mode == "server" {
+				tc = NewServer(uploadMux(gotFirst), t)
+				defer tc.Close()
+			}
+
+			pr, pw := io.Pipe()
+			go func() {
+				pw.Write([]byte("first"))
+				// the rest only comes once the handler read the beginning
+				select {
+				case <-gotFirst:
+				case <-time.After(2 * time.Second):
+					pw.CloseWithError(fmt.Errorf("handler buffered the body"))
+					return
+				}
+				pw.Write([]byte(" second"))
+				pw.Close()
+			}()
+
+			resp := tc.PostStream(&url.URL{Path: "/upload"}, "text/plain", pr, -1)
+			a.Equal(http.StatusOK, resp.Code, resp.Body.String())
+			a.Equal("text/plain [chunked] -1 first second", resp.Body.String())
+		})
+	}
+
+	a := assert.New(t)
+	tc := New(uploadMux(make(chan struct{})), t)
+	resp := tc.Request("PUT", &url.URL{Path: "/upload"}).Stream("application/octet-stream", strings.NewReader("12345"), 5).Do()
+	a.Equal("application/octet-stream [] 5 12345", resp.String())
+}
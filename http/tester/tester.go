@@ -2,41 +2,122 @@ package tester
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
-	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/gorilla/mux"
 	"go.mindeco.de/logging"
+	"go.mindeco.de/logging/adapter"
 	"go.mindeco.de/logging/logtest"
 )
 
+// tb is the part of testing.TB used by the Tester, so that Concurrently can collect the failures of its clients
+type tb interface {
+	Helper()
+	Log(args ...interface{})
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// relative request URLs are resolved against this for the cookie jar
+var defaultBase = &url.URL{Scheme: "http", Host: "localhost"}
+
 type Tester struct {
 	mux http.Handler
-	t   *testing.T
+	t   tb
 
-	jar *cookiejar.Jar
+	jar *jar
 
 	extraHeaders http.Header
+
+	maxRedirects int
+	chain        []*httptest.ResponseRecorder
+	hops         []Hop // of the last request, including the final response
+
+	goldenNorm []Normalizer
+
+	streamTimeout time.Duration
+
+	ctx context.Context // see WithContext
+
+	router *mux.Router // see URLFor
+
+	sessionCookie string // see LoginForm
+
+	log adapter.Logger // injected into the request contexts, see SetLogger
+
+	csrfField, csrfHeader string // see AutoCSRF
+	csrfToken             string
+
+	onRequest  []func(*http.Request)
+	onResponse []func(*httptest.ResponseRecorder)
+
+	rec  *recording // see Record
+	meta *responseMeta
+
+	// set by NewServer
+	serveTLS  bool
+	serveUnix bool
+	socket    string // the path, if serveUnix
+	srv       *httptest.Server
+	client    *http.Client
 }
 
-func New(mux *http.ServeMux, t *testing.T) *Tester {
-	l, _ := logtest.KitLogger("http/tester", t)
+// Option is a function that changes a Tester during initialization
+type Option func(*Tester)
+
+// FollowRedirects makes all requests follow up to n redirects through the same handler, carrying the cookies along.
+// The response of the last followed request is returned, the ones before it are available from Chain.
+func FollowRedirects(n int) Option {
+	return func(t *Tester) {
+		t.maxRedirects = n
+	}
+}
+
+// SetLogger sets the logger that is injected into the context of the requests (see logging.InjectHandler).
+// By default the lines are passed to t.Log. A slog.Logger can be passed with adapter.FromSlog.
+func SetLogger(l adapter.Logger) Option {
+	return func(t *Tester) {
+		t.log = l
+	}
+}
+
+// New returns a Tester which sends its requests to h, which can be any router (or a single handler).
+func New(h http.Handler, t *testing.T, opts ...Option) *Tester {
 	tester := Tester{
-		mux: logging.InjectHandler(l)(mux),
-		t:   t,
+		t:    t,
+		meta: newResponseMeta(),
+
+		sessionCookie: defaultSessionCookie,
 	}
 
-	var err error
-	tester.jar, err = cookiejar.New(nil)
-	if err != nil {
-		t.Fatal(err)
+	if r, ok := h.(*mux.Router); ok {
+		tester.router = r
 	}
 
+	for _, o := range opts {
+		o(&tester)
+	}
+
+	if tester.log == nil {
+		tester.log, _ = logtest.KitLogger("http/tester", t)
+	}
+	tester.mux = logging.InjectHandler(tester.log)(h)
+
+	tester.jar = newJar()
 	tester.ClearHeaders()
 
 	return &tester
@@ -54,72 +135,221 @@ func (t *Tester) SetHeaders(h http.Header) {
 	}
 }
 
-func (t *Tester) ClearCookies() {
-	var err error
-	t.jar, err = cookiejar.New(nil)
-	if err != nil {
-		t.t.Fatal("failed to clear cookies:", err)
+// OnRequest registers a function which is called with every request before it is sent, for instance to add tracing headers
+func (t *Tester) OnRequest(fn func(*http.Request)) {
+	t.onRequest = append(t.onRequest, fn)
+}
+
+// OnResponse registers a function which is called with every response (including redirects that are followed), to log them or check invariants like security headers
+func (t *Tester) OnResponse(fn func(*httptest.ResponseRecorder)) {
+	t.onResponse = append(t.onResponse, fn)
+}
+
+// WithContext returns a Tester which sends its requests with ctx, to test cancellation and deadlines or to pre-seed request-scoped values.
+// It shares cookies and headers with t. Context values only reach the handler of in-process Testers, not over the network of NewServer.
+func (t *Tester) WithContext(ctx context.Context) *Tester {
+	cpy := *t
+	cpy.ctx = ctx
+	cpy.chain, cpy.hops = nil, nil
+	return &cpy
+}
+
+func (t *Tester) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
 	}
+	return t.ctx
+}
+
+// SetBasicAuth sends the credentials with all following requests, until ClearHeaders is called
+func (t *Tester) SetBasicAuth(user, pass string) {
+	r := http.Request{Header: make(http.Header)}
+	r.SetBasicAuth(user, pass)
+	t.extraHeaders.Set("Authorization", r.Header.Get("Authorization"))
+}
+
+// SetBearer sends the token as an Authorization: Bearer header with all following requests, until ClearHeaders is called
+func (t *Tester) SetBearer(token string) {
+	t.extraHeaders.Set("Authorization", "Bearer "+token)
+}
+
+func (t *Tester) ClearCookies() {
+	t.jar = newJar()
+}
+
+// Cookies returns the cookies that would be sent with a request to u, including their attributes (like Expires, Secure and HttpOnly)
+func (t *Tester) Cookies(u *url.URL) []*http.Cookie {
+	return t.jar.cookies(u)
+}
+
+// SetCookie stores c as if it was set by a response to u, to simulate tampered or foreign cookies
+func (t *Tester) SetCookie(u *url.URL, c *http.Cookie) {
+	t.jar.setCookies(u, []*http.Cookie{c})
+}
+
+// RemoveCookie deletes all cookies with that name
+func (t *Tester) RemoveCookie(name string) {
+	t.jar.remove(name)
+}
+
+// Chain returns the redirect responses which were followed by the last request, oldest first (see FollowRedirects)
+func (t *Tester) Chain() []*httptest.ResponseRecorder {
+	return t.chain
 }
 
 func (t *Tester) constructHeader(h *http.Header, u *url.URL) {
 	*h = t.extraHeaders.Clone()
 
-	cookies := t.jar.Cookies(u)
-	for _, c := range cookies {
-		cstr := c.String()
-		h.Add("Cookie", cstr)
+	for _, c := range t.jar.cookies(u) {
+		// only name and value, the attributes are not part of the request
+		plain := http.Cookie{Name: c.Name, Value: c.Value}
+		h.Add("Cookie", plain.String())
 	}
 }
 
-func (t *Tester) GetHTML(u *url.URL) (*goquery.Document, *httptest.ResponseRecorder) {
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		t.t.Fatal(err)
+// jarURL makes relative URLs absolute so that the cookie jar handles them
+func jarURL(u *url.URL) *url.URL {
+	if u.IsAbs() {
+		return u
 	}
-	t.constructHeader(&req.Header, u)
+	return defaultBase.ResolveReference(u)
+}
 
-	rw := httptest.NewRecorder()
-	t.mux.ServeHTTP(rw, req)
+// do sends the request to the handler, stores the cookies of the response and follows redirects if configured.
+// opts are applied to the headers of each request.
+func (t *Tester) do(method string, u *url.URL, body []byte, contentType string, opts []RequestOption) *httptest.ResponseRecorder {
+	return t.send(method, u, requestBody{data: body, contentType: contentType}, nil, opts)
+}
 
-	t.jar.SetCookies(u, rw.Result().Cookies())
+// requestBody is either data or a stream, which can only be sent once
+type requestBody struct {
+	data []byte
 
-	doc, err := goquery.NewDocumentFromReader(rw.Body)
-	if err != nil {
-		t.t.Fatal(err)
-	}
+	stream io.Reader
+	size   int64 // of the stream, -1 for chunked transfer
 
-	return doc, rw
+	contentType string
 }
 
-func (t *Tester) GetBody(u *url.URL) (rw *httptest.ResponseRecorder) {
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		t.t.Fatal(err)
+// send is like do, hdr is added to the headers of the Tester and can be nil.
+func (t *Tester) send(method string, u *url.URL, body requestBody, hdr http.Header, opts []RequestOption) *httptest.ResponseRecorder {
+	t.chain, t.hops = nil, nil
+	streamed := false
+	for {
+		var rd io.Reader
+		if body.data != nil {
+			rd = bytes.NewReader(body.data)
+		}
+		req, err := http.NewRequest(method, u.String(), rd)
+		if err != nil {
+			t.t.Fatal(err)
+		}
+		if body.stream != nil {
+			req.Body = ioutil.NopCloser(body.stream)
+			req.ContentLength = body.size
+			if body.size < 0 {
+				req.TransferEncoding = []string{"chunked"}
+			}
+			streamed = true
+		}
+		req = req.WithContext(t.context())
+		t.constructHeader(&req.Header, u)
+		t.addCSRF(req)
+		for k, vals := range hdr {
+			req.Header[k] = append(req.Header[k], vals...)
+		}
+		if body.contentType != "" {
+			req.Header.Set("Content-Type", body.contentType)
+		}
+		for _, o := range opts {
+			o(req.Header)
+		}
+
+		rw := t.roundTrip(req, body.data)
+
+		if len(t.chain) >= t.maxRedirects || !isRedirect(rw.Code) {
+			return rw
+		}
+
+		loc, err := url.Parse(rw.Header().Get("Location"))
+		if err != nil || rw.Header().Get("Location") == "" {
+			return rw
+		}
+		next := u.ResolveReference(loc)
+		if next.IsAbs() && jarURL(u).Host != next.Host {
+			// not for our handler
+			return rw
+		}
+
+		keepBody := rw.Code == http.StatusTemporaryRedirect || rw.Code == http.StatusPermanentRedirect || method == "HEAD"
+		if keepBody && streamed {
+			// the stream is used up and can't be sent again
+			return rw
+		}
+
+		t.chain = append(t.chain, rw)
+		u = next
+		if !keepBody {
+			method, body = "GET", requestBody{}
+		}
 	}
-	t.constructHeader(&req.Header, u)
+}
 
-	rw = httptest.NewRecorder()
-	t.mux.ServeHTTP(rw, req)
+// roundTrip runs the hooks around serving req, records the response and stores its cookies.
+// body is only used for the HAR recording.
+func (t *Tester) roundTrip(req *http.Request, body []byte) *httptest.ResponseRecorder {
+	for _, hook := range t.onRequest {
+		hook(req)
+	}
 
-	t.jar.SetCookies(u, rw.Result().Cookies())
-	return
+	started := time.Now()
+	rw := t.serve(req)
+	took := time.Since(started)
+	raw, err := decodeBody(rw)
+	if err != nil {
+		t.t.Error(err)
+	}
+	t.meta.set(rw, responseInfo{took: took, raw: raw})
+	for _, hook := range t.onResponse {
+		hook(rw)
+	}
+	t.pickCSRF(rw)
+	t.record(req, body, rw, started, took)
+	t.jar.setCookies(req.URL, rw.Result().Cookies())
+	t.hops = append(t.hops, Hop{Method: req.Method, URL: req.URL, Status: rw.Code, rw: rw})
+	return rw
 }
 
-func (t *Tester) GetJSON(u *url.URL, v interface{}) (rw *httptest.ResponseRecorder) {
-	req, err := http.NewRequest("GET", u.String(), nil)
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+func (t *Tester) GetHTML(u *url.URL, opts ...RequestOption) (*goquery.Document, *httptest.ResponseRecorder) {
+	rw := t.do("GET", u, nil, "", opts)
+
+	doc, err := goquery.NewDocumentFromReader(rw.Body)
 	if err != nil {
 		t.t.Fatal(err)
 	}
-	t.constructHeader(&req.Header, u)
 
-	rw = httptest.NewRecorder()
-	t.mux.ServeHTTP(rw, req)
-	t.jar.SetCookies(u, rw.Result().Cookies())
+	return doc, rw
+}
+
+func (t *Tester) GetBody(u *url.URL, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.do("GET", u, nil, "", opts)
+}
+
+func (t *Tester) GetJSON(u *url.URL, v interface{}, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	rw = t.do("GET", u, nil, "", opts)
 
 	body := rw.Body.Bytes()
 	if rw.Code == 200 {
-		if err = json.Unmarshal(body, v); err != nil {
+		if err := json.Unmarshal(body, v); err != nil {
 			t.t.Log("Body:", string(body))
 			t.t.Fatal(err)
 		}
@@ -128,36 +358,80 @@ func (t *Tester) GetJSON(u *url.URL, v interface{}) (rw *httptest.ResponseRecord
 	return
 }
 
-func (t *Tester) SendJSON(u *url.URL, v interface{}) (rw *httptest.ResponseRecorder) {
-	blob, err := json.Marshal(v)
-	if err != nil {
-		t.t.Fatal(err)
+// GetXML is like GetJSON for XML responses (feeds, sitemaps and the like).
+// For 200 responses it also checks that the Content-Type is XML.
+func (t *Tester) GetXML(u *url.URL, v interface{}, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	t.t.Helper()
+	rw = t.do("GET", u, nil, "", opts)
+
+	if rw.Code == 200 {
+		body := rw.Body.Bytes()
+		if ct := rw.Header().Get("Content-Type"); !isXML(ct) {
+			t.t.Log("Body:", string(body))
+			t.t.Fatalf("tester: expected an XML content type but got %q", ct)
+		}
+		if err := xml.Unmarshal(body, v); err != nil {
+			t.t.Log("Body:", string(body))
+			t.t.Fatalf("tester: failed to decode XML as %T: %s", v, err)
+		}
 	}
 
-	req, err := http.NewRequest("POST", u.String(), bytes.NewReader(blob))
+	return
+}
+
+func isXML(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		t.t.Fatal(err)
+		return false
 	}
-	t.constructHeader(&req.Header, u)
+	return mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
 
-	req.Header.Set("Content-Type", "application/json")
+func (t *Tester) SendJSON(u *url.URL, v interface{}, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.sendJSON("POST", u, v, opts)
+}
 
-	rw = httptest.NewRecorder()
-	t.mux.ServeHTTP(rw, req)
-	t.jar.SetCookies(u, rw.Result().Cookies())
-	return
+func (t *Tester) PostForm(u *url.URL, v url.Values, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.do("POST", u, []byte(v.Encode()), "application/x-www-form-urlencoded", opts)
+}
+
+// PutJSON is like SendJSON but uses PUT
+func (t *Tester) PutJSON(u *url.URL, v interface{}, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.sendJSON("PUT", u, v, opts)
+}
+
+// PatchJSON is like SendJSON but uses PATCH
+func (t *Tester) PatchJSON(u *url.URL, v interface{}, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.sendJSON("PATCH", u, v, opts)
+}
+
+// PutForm is like PostForm but uses PUT
+func (t *Tester) PutForm(u *url.URL, v url.Values, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.do("PUT", u, []byte(v.Encode()), "application/x-www-form-urlencoded", opts)
 }
 
-func (t *Tester) PostForm(u *url.URL, v url.Values) (rw *httptest.ResponseRecorder) {
-	req, err := http.NewRequest("POST", u.String(), strings.NewReader(v.Encode()))
+// Delete sends a DELETE request without a body
+func (t *Tester) Delete(u *url.URL, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.do("DELETE", u, nil, "", opts)
+}
+
+// DeleteForm sends a DELETE request with url encoded form values as the body
+func (t *Tester) DeleteForm(u *url.URL, v url.Values, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.do("DELETE", u, []byte(v.Encode()), "application/x-www-form-urlencoded", opts)
+}
+
+func (t *Tester) sendJSON(method string, u *url.URL, v interface{}, opts []RequestOption) *httptest.ResponseRecorder {
+	blob, err := json.Marshal(v)
 	if err != nil {
 		t.t.Fatal(err)
 	}
-	t.constructHeader(&req.Header, u)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	rw = httptest.NewRecorder()
-	t.mux.ServeHTTP(rw, req)
-	t.jar.SetCookies(u, rw.Result().Cookies())
-	return
+	return t.do(method, u, blob, "application/json", opts)
+}
+
+// PostStream sends body as it is read, without buffering it, for testing upload handlers.
+// If size is negative the request uses chunked transfer encoding, otherwise it is sent as the Content-Length.
+// Redirects that would need the body again (307 and 308) are not followed.
+func (t *Tester) PostStream(u *url.URL, contentType string, body io.Reader, size int64, opts ...RequestOption) (rw *httptest.ResponseRecorder) {
+	return t.send("POST", u, requestBody{stream: body, size: size, contentType: contentType}, nil, opts)
 }
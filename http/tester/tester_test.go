@@ -0,0 +1,113 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLoginMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: r.PostFormValue("user"), Path: "/"})
+		http.Redirect(w, r, "/landing", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/landing", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "profile", http.StatusFound)
+	})
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("session")
+		if err != nil {
+			http.Error(w, "no session", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, "%s %s", r.Method, c.Value)
+	})
+	mux.HandleFunc("/away", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com/", http.StatusFound)
+	})
+	return mux
+}
+
+func TestFollowRedirects(t *testing.T) {
+	a := assert.New(t)
+
+	tc := New(newLoginMux(), t, FollowRedirects(5))
+
+	resp := tc.PostForm(&url.URL{Path: "/login"}, url.Values{"user": {"alice"}})
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal("GET alice", resp.Body.String())
+
+	chain := tc.Chain()
+	if a.Len(chain, 2) {
+		a.Equal(http.StatusSeeOther, chain[0].Code)
+		a.Equal(http.StatusFound, chain[1].Code)
+	}
+
+	// other hosts are not followed
+	resp = tc.GetBody(&url.URL{Path: "/away"})
+	a.Equal(http.StatusFound, resp.Code)
+	a.Len(tc.Chain(), 0)
+}
+
+func TestFollowRedirectsLimit(t *testing.T) {
+	a := assert.New(t)
+
+	tc := New(newLoginMux(), t, FollowRedirects(1))
+	resp := tc.PostForm(&url.URL{Path: "/login"}, url.Values{"user": {"alice"}})
+	a.Equal(http.StatusFound, resp.Code)
+	a.Len(tc.Chain(), 1)
+
+	// without the option the first response is returned
+	tc = New(newLoginMux(), t)
+	resp = tc.PostForm(&url.URL{Path: "/login"}, url.Values{"user": {"alice"}})
+	a.Equal(http.StatusSeeOther, resp.Code)
+
+	// but the cookie is kept
+	resp = tc.GetBody(&url.URL{Path: "/profile"})
+	a.Equal("GET alice", resp.Body.String())
+}
+
+func TestAuthHeaders(t *testing.T) {
+	a := assert.New(t)
+
+	tc := New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); ok {
+			fmt.Fprintf(w, "basic %s %s", u, p)
+			return
+		}
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}), t)
+	root := &url.URL{Path: "/"}
+
+	tc.SetBasicAuth("alice", "secret")
+	a.Equal("basic alice secret", tc.GetBody(root).Body.String())
+
+	tc.SetBearer("tok")
+	a.Equal("Bearer tok", tc.GetBody(root).Body.String(), "should replace basic auth")
+
+	tc.ClearHeaders()
+	a.Equal("", tc.GetBody(root).Body.String())
+}
+
+func TestHops(t *testing.T) {
+	a := assert.New(t)
+
+	tc := New(newLoginMux(), t, FollowRedirects(5))
+
+	resp := tc.Request("POST", &url.URL{Path: "/login"}).Form(url.Values{"user": {"dave"}}).Do()
+	resp.ExpectHops("POST /login 303", "GET /landing 302", "GET /profile 200")
+	a.Equal("/profile", resp.Hops()[2].URL.Path)
+
+	tc.Wrap(tc.GetBody(&url.URL{Path: "/landing"})).
+		ExpectStatus(http.StatusOK).
+		ExpectHops("GET /landing 302", "GET /profile 200")
+
+	// the hops belong to the latest request only
+	old := tc.GetBody(&url.URL{Path: "/profile"})
+	tc.GetBody(&url.URL{Path: "/landing"})
+	a.Len(tc.Wrap(old).Hops(), 0)
+}
@@ -0,0 +1,26 @@
+package tester
+
+import (
+	"time"
+)
+
+// Duration returns the wall-clock time the handler needed for this response.
+// For redirects that were followed it is the time of the last request only.
+func (r *Response) Duration() time.Duration {
+	return r.info.took
+}
+
+// ExpectFasterThan reports an error if the handler took d or longer.
+// Keep the budgets generous, CI machines are slow and noisy.
+func (r *Response) ExpectFasterThan(d time.Duration) *Response {
+	r.t.t.Helper()
+
+	if !r.hasInfo {
+		r.t.t.Errorf("tester: no timing for this response, it wasn't sent by this Tester or was wrapped before")
+		return r
+	}
+	if r.info.took >= d {
+		r.t.t.Errorf("tester: request took %s, the budget is %s", r.info.took, d)
+	}
+	return r
+}
@@ -0,0 +1,34 @@
+package tester
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuration(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	})
+	mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {})
+	tc := New(mux, t)
+
+	slow := tc.Request("GET", &url.URL{Path: "/slow"}).Do()
+	a.True(slow.Duration() >= 20*time.Millisecond, "took %s", slow.Duration())
+
+	tc.Wrap(tc.GetBody(&url.URL{Path: "/fast"})).ExpectFasterThan(time.Second)
+
+	// the Tester only keeps the timings until they are wrapped, and a bounded number of unwrapped ones
+	a.Len(tc.meta.infos, 0)
+	for i := 0; i < 2*maxResponseMeta; i++ {
+		tc.GetBody(&url.URL{Path: "/fast"})
+	}
+	a.Len(tc.meta.infos, maxResponseMeta)
+	a.Len(tc.meta.order, maxResponseMeta)
+}
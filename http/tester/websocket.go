@@ -0,0 +1,156 @@
+package tester
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// WSConn is a websocket connection to the handler of a Tester, see DialWebSocket
+type WSConn struct {
+	t *Tester
+
+	*websocket.Conn
+	srv *httptest.Server // only set if the server was started for this connection
+
+	// Timeout is the deadline for each read and write, it defaults to 5 seconds
+	Timeout time.Duration
+}
+
+// DialWebSocket upgrades a connection to u against the handler of the Tester.
+// Websockets need a real connection, so unless the Tester was created with NewServer a httptest.Server is started for it which is stopped by Close.
+// The headers and cookies of the Tester are sent with the upgrade request.
+func (t *Tester) DialWebSocket(u *url.URL) *WSConn {
+	t.t.Helper()
+
+	srv, own := t.srv, false
+	if srv == nil {
+		srv, own = httptest.NewServer(t.mux), true
+	}
+	closeOwn := func() {
+		if own {
+			srv.Close()
+		}
+	}
+
+	base, err := url.Parse(srv.URL)
+	if err != nil {
+		closeOwn()
+		t.t.Fatal(err)
+	}
+	target := *u
+	target.Scheme = "ws"
+	if base.Scheme == "https" {
+		target.Scheme = "wss"
+	}
+	target.Host = base.Host
+
+	dialer := *websocket.DefaultDialer
+	if !own && t.socket != "" {
+		dialer.NetDialContext = t.dialUnix
+	}
+	if tr, ok := srv.Client().Transport.(*http.Transport); ok && tr.TLSClientConfig != nil {
+		dialer.TLSClientConfig = tr.TLSClientConfig.Clone()
+		dialer.TLSClientConfig.NextProtos = nil // websockets need HTTP/1.1
+	}
+
+	var hdr http.Header
+	t.constructHeader(&hdr, u)
+
+	conn, resp, err := dialer.Dial(target.String(), hdr)
+	if resp != nil {
+		t.jar.setCookies(u, resp.Cookies())
+	}
+	if err != nil {
+		closeOwn()
+		if resp != nil {
+			t.t.Fatalf("tester: websocket upgrade failed with status %d: %s", resp.StatusCode, err)
+		}
+		t.t.Fatalf("tester: websocket dial failed: %s", err)
+	}
+
+	ws := &WSConn{
+		t:       t,
+		Conn:    conn,
+		Timeout: 5 * time.Second,
+	}
+	if own {
+		ws.srv = srv
+	}
+	return ws
+}
+
+// Close closes the connection and stops the server if it was started for it
+func (c *WSConn) Close() error {
+	err := c.Conn.Close()
+	if c.srv != nil {
+		c.srv.Close()
+	}
+	return err
+}
+
+// SendText writes a text message and fails the test if that isn't possible
+func (c *WSConn) SendText(msg string) {
+	c.t.t.Helper()
+	c.Conn.SetWriteDeadline(time.Now().Add(c.Timeout))
+	if err := c.Conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		c.t.t.Fatal("tester: websocket write failed:", err)
+	}
+}
+
+// SendJSON writes v as a JSON message and fails the test if that isn't possible
+func (c *WSConn) SendJSON(v interface{}) {
+	c.t.t.Helper()
+	c.Conn.SetWriteDeadline(time.Now().Add(c.Timeout))
+	if err := c.Conn.WriteJSON(v); err != nil {
+		c.t.t.Fatal("tester: websocket write failed:", err)
+	}
+}
+
+// Read returns the next message and fails the test if none arrives in time
+func (c *WSConn) Read() (messageType int, data []byte) {
+	c.t.t.Helper()
+	c.Conn.SetReadDeadline(time.Now().Add(c.Timeout))
+	messageType, data, err := c.Conn.ReadMessage()
+	if err != nil {
+		c.t.t.Fatal("tester: websocket read failed:", err)
+	}
+	return messageType, data
+}
+
+// ReadJSON decodes the next message into v
+func (c *WSConn) ReadJSON(v interface{}) {
+	c.t.t.Helper()
+	_, data := c.Read()
+	if err := json.Unmarshal(data, v); err != nil {
+		c.t.t.Log("Message:", string(data))
+		c.t.t.Fatal(err)
+	}
+}
+
+// ExpectText reports an error if the next message isn't the text msg
+func (c *WSConn) ExpectText(msg string) *WSConn {
+	c.t.t.Helper()
+	mt, data := c.Read()
+	assert.Equal(c.t.t, websocket.TextMessage, mt, "not a text message")
+	assert.Equal(c.t.t, msg, string(data))
+	return c
+}
+
+// ExpectJSON reports an error if the next message isn't the same JSON as the encoding of v
+func (c *WSConn) ExpectJSON(v interface{}) *WSConn {
+	c.t.t.Helper()
+	want, err := json.Marshal(v)
+	if err != nil {
+		c.t.t.Errorf("failed to encode the expected value: %s", err)
+		return c
+	}
+	_, data := c.Read()
+	assert.JSONEq(c.t.t, string(want), string(data))
+	return c
+}
@@ -0,0 +1,61 @@
+package tester
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebSocket(t *testing.T) {
+	a := assert.New(t)
+
+	var upgrader websocket.Upgrader
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "alice", Path: "/"})
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("session")
+		if err != nil {
+			http.Error(w, "no session", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte("hello "+c.Value))
+		for {
+			var msg map[string]interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			msg["echo"] = true
+			conn.WriteJSON(msg)
+		}
+	})
+	tc := New(mux, t)
+
+	tc.GetBody(&url.URL{Path: "/login"})
+
+	ws := tc.DialWebSocket(&url.URL{Path: "/ws"})
+	defer ws.Close()
+
+	ws.ExpectText("hello alice")
+	ws.SendJSON(map[string]int{"n": 1})
+	ws.ExpectJSON(map[string]interface{}{"n": 1, "echo": true})
+
+	ws.SendJSON(map[string]int{"n": 2})
+	var got struct {
+		N    int
+		Echo bool
+	}
+	ws.ReadJSON(&got)
+	a.Equal(2, got.N)
+	a.True(got.Echo)
+}
@@ -0,0 +1,37 @@
+package tester
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type urlset struct {
+	XMLName xml.Name `xml:"urlset"`
+	URLs    []string `xml:"url>loc"`
+}
+
+func TestGetXML(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		fmt.Fprint(w, `<?xml version="1.0"?><urlset><url><loc>/a</loc></url><url><loc>/b</loc></url></urlset>`)
+	})
+	tc := New(mux, t)
+
+	var sm urlset
+	resp := tc.GetXML(&url.URL{Path: "/sitemap.xml"}, &sm)
+	a.Equal(http.StatusOK, resp.Code)
+	a.Equal([]string{"/a", "/b"}, sm.URLs)
+
+	a.True(isXML("application/atom+xml"))
+	a.True(isXML("text/xml"))
+	a.False(isXML("text/html; charset=utf-8"))
+	a.False(isXML(""))
+}
@@ -0,0 +1,221 @@
+// Package validation checks decoded form values and collects messages per field, for the submit, validate and redisplay loop.
+//
+//	var pf postForm
+//	v := validation.New()
+//	if err := forms.DecodeRequest(r, &pf); err != nil {
+//		var ferrs forms.Errors
+//		if !errors.As(err, &ferrs) {
+//			return err
+//		}
+//		v.Merge(ferrs)
+//	}
+//	v.Required("title", pf.Title, validation.Length(3, 100))
+//	v.Optional("website", pf.Website, validation.URL())
+//	v.Range("stars", pf.Stars, 1, 5)
+//	if v.Any() {
+//		return validation.Redisplay(renderer, w, r, "/posts/new.tmpl", v.Errors, nil)
+//	}
+//
+// And in the template:
+//
+//	<input name="title" value="{{.Form.Value "title"}}"{{if .Form.Invalid "title"}} aria-invalid="true"{{end}}>
+//	{{range .Form.Errors.Of "title"}}<p class="error">{{.}}</p>{{end}}
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.mindeco.de/http/forms"
+	"go.mindeco.de/http/render"
+)
+
+// Validator runs the checks and collects their messages in the embedded forms.Errors.
+// The messages of decoding can be merged in, so a form has one set of errors.
+type Validator struct {
+	forms.Errors
+}
+
+// New returns a Validator without messages
+func New() Validator {
+	return Validator{Errors: make(forms.Errors)}
+}
+
+// Rule checks a value and returns a message if it is invalid, or an empty string
+type Rule func(value string) string
+
+// Required adds "is required" if value is empty (or only whitespace) and checks the rules otherwise.
+// Only the first failing rule adds a message.
+func (v Validator) Required(field, value string, rules ...Rule) {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, "is required")
+		return
+	}
+	v.check(field, value, rules)
+}
+
+// Optional is like Required but accepts empty values
+func (v Validator) Optional(field, value string, rules ...Rule) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	v.check(field, value, rules)
+}
+
+func (v Validator) check(field, value string, rules []Rule) {
+	for _, rule := range rules {
+		if msg := rule(value); msg != "" {
+			v.Add(field, msg)
+			return
+		}
+	}
+}
+
+// Range adds a message if n is outside of min and max (inclusive)
+func (v Validator) Range(field string, n, min, max int) {
+	if n < min || n > max {
+		v.Add(field, fmt.Sprintf("must be between %d and %d", min, max))
+	}
+}
+
+// FloatRange is like Range for floating point numbers
+func (v Validator) FloatRange(field string, n, min, max float64) {
+	if n < min || n > max {
+		v.Add(field, fmt.Sprintf("must be between %g and %g", min, max))
+	}
+}
+
+// Check adds msg if ok is false, for conditions that involve more than one value
+func (v Validator) Check(field string, ok bool, msg string) {
+	if !ok {
+		v.Add(field, msg)
+	}
+}
+
+// Length checks the number of characters (not bytes), a max of 0 means no limit
+func Length(min, max int) Rule {
+	return func(v string) string {
+		n := utf8.RuneCountInString(v)
+		switch {
+		case n < min && max > 0:
+			return fmt.Sprintf("must be between %d and %d characters long", min, max)
+		case n < min:
+			return fmt.Sprintf("must be at least %d characters long", min)
+		case max > 0 && n > max:
+			return fmt.Sprintf("must be at most %d characters long", max)
+		}
+		return ""
+	}
+}
+
+// Email checks for a plain address like alice@example.com, without a display name
+func Email() Rule {
+	return func(v string) string {
+		addr, err := mail.ParseAddress(v)
+		if err != nil || addr.Address != v || addr.Name != "" {
+			return "must be an email address"
+		}
+		return ""
+	}
+}
+
+// URL checks for an absolute http or https URL
+func URL() Rule {
+	return func(v string) string {
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be a http or https URL"
+		}
+		return ""
+	}
+}
+
+// Match checks v against re and returns msg if it doesn't match
+func Match(re *regexp.Regexp, msg string) Rule {
+	return func(v string) string {
+		if !re.MatchString(v) {
+			return msg
+		}
+		return ""
+	}
+}
+
+// OneOf checks that v is one of the options, like for selects
+func OneOf(options ...string) Rule {
+	return func(v string) string {
+		for _, o := range options {
+			if v == o {
+				return ""
+			}
+		}
+		return "is not one of the options"
+	}
+}
+
+// Func turns a function that returns an error into a Rule, the message is the text of the error
+func Func(fn func(string) error) Rule {
+	return func(v string) string {
+		if err := fn(v); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+}
+
+// Form is passed to the template by Redisplay, with the submitted values and the messages for them
+type Form struct {
+	Values url.Values
+	Errors forms.Errors
+}
+
+// Value returns the submitted value of field
+func (f Form) Value(field string) string {
+	return f.Values.Get(field)
+}
+
+// Checked reports whether value was submitted for field, for checkboxes and selects
+func (f Form) Checked(field, value string) bool {
+	for _, v := range f.Values[field] {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Invalid reports whether there are messages for field
+func (f Form) Invalid(field string) bool {
+	return f.Errors.Has(field)
+}
+
+// Error returns the first message for field
+func (f Form) Error(field string) string {
+	return f.Errors.First(field)
+}
+
+// NotRedisplayed are the fields Redisplay doesn't put back into the form, secrets and tokens that are generated again
+var NotRedisplayed = []string{"pass", "password", "password_confirm", "csrf_token"}
+
+// Redisplay renders tpl again with status 422, for forms that didn't validate.
+// data is passed to the template with Form added, which has the submitted values (without NotRedisplayed) and errs.
+// The form of r has to be parsed already, which forms.DecodeRequest does.
+func Redisplay(rend *render.Renderer, w http.ResponseWriter, r *http.Request, tpl string, errs forms.Errors, data map[string]interface{}) error {
+	vals := make(url.Values, len(r.Form))
+	for k, v := range r.Form {
+		vals[k] = v
+	}
+	for _, k := range NotRedisplayed {
+		delete(vals, k)
+	}
+
+	if data == nil {
+		data = make(map[string]interface{}, 1)
+	}
+	data["Form"] = Form{Values: vals, Errors: errs}
+	return rend.Render(w, r, tpl, http.StatusUnprocessableEntity, data)
+}
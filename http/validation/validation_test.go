@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mindeco.de/http/forms"
+	"go.mindeco.de/http/render"
+	kitlog "go.mindeco.de/log"
+)
+
+func TestRules(t *testing.T) {
+	a := assert.New(t)
+
+	errs := New()
+	errs.Merge(forms.Errors{"stars": {"must be a whole number"}})
+	errs.Required("title", "  ")
+	errs.Required("name", "zoë", Length(4, 10))
+	errs.Required("bio", "ok", Length(1, 0))
+	errs.Optional("website", "")
+	errs.Optional("homepage", "ftp://example.com", URL())
+	errs.Required("email", "Alice <alice@example.com>", Email())
+	errs.Required("contact", "alice@example.com", Email())
+	errs.Required("slug", "Not A Slug", Match(regexp.MustCompile(`^[a-z-]+$`), "may only contain a-z and -"), Length(100, 0))
+	errs.Required("lang", "fr", OneOf("en", "de"))
+	errs.Required("nick", "root", Func(func(v string) error {
+		if v == "root" {
+			return errors.New("is taken")
+		}
+		return nil
+	}))
+	errs.Range("age", 200, 0, 150)
+	errs.FloatRange("ratio", 0.5, 0, 1)
+	errs.Check("confirm", false, "doesn't match the password")
+
+	a.Equal(forms.Errors{
+		"stars":    {"must be a whole number"},
+		"title":    {"is required"},
+		"name":     {"must be between 4 and 10 characters long"},
+		"homepage": {"must be a http or https URL"},
+		"email":    {"must be an email address"},
+		"slug":     {"may only contain a-z and -"},
+		"lang":     {"is not one of the options"},
+		"nick":     {"is taken"},
+		"age":      {"must be between 0 and 150"},
+		"confirm":  {"doesn't match the password"},
+	}, errs.Errors)
+	a.True(errs.Any())
+	a.Equal("is required", errs.First("title"))
+	a.Equal("", errs.First("contact"))
+	a.True(strings.HasPrefix(errs.Error(), "forms: age: must be between 0 and 150; confirm: "))
+	a.Error(errs.Err())
+	a.NoError(New().Err())
+}
+
+func TestRedisplay(t *testing.T) {
+	a := assert.New(t)
+
+	rend, err := render.New(http.Dir("testdata"),
+		render.AddTemplates("/new.tmpl"),
+		render.SetLogger(kitlog.NewNopLogger()),
+	)
+	require.NoError(t, err)
+
+	form := url.Values{"title": {"<hi>"}, "password": {"hunter2"}, "notify": {"yes"}}
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	require.NoError(t, req.ParseForm())
+
+	errs := New()
+	errs.Required("title", req.Form.Get("title"), Length(5, 0))
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, Redisplay(rend, rec, req, "/new.tmpl", errs.Errors, map[string]interface{}{"User": "alice"}))
+	a.Equal(http.StatusUnprocessableEntity, rec.Code)
+
+	doc, err := goquery.NewDocumentFromReader(rec.Body)
+	require.NoError(t, err)
+	title := doc.Find("input[name=title]")
+	a.Equal("<hi>", title.AttrOr("value", ""))
+	a.Equal("true", title.AttrOr("aria-invalid", ""))
+	a.Equal("must be at least 5 characters long", doc.Find("p.error").Text())
+	a.Equal("", doc.Find("input[name=password]").AttrOr("value", "missing"), "not redisplayed")
+	_, checked := doc.Find("input[name=notify]").Attr("checked")
+	a.True(checked)
+	a.Equal("alice", doc.Find("#user").Text())
+}
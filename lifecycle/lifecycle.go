@@ -0,0 +1,246 @@
+// Package lifecycle runs the long-running parts of a service (HTTP servers, template watchers, job workers)
+// and stops them again in reverse order, once the process gets a signal or one of them fails.
+//
+//	sv := lifecycle.New()
+//	sv.Add("worker", jobs.Run, 30*time.Second)
+//	sv.Add("http", srv.Run, 35*time.Second) // with httpserver.SetSignals(), the supervisor handles them
+//	logging.CheckFatal(sv.Run(context.Background()))
+//
+// On SIGHUP the reload hooks are called while everything keeps running, so open connections aren't dropped:
+//
+//	sv.OnReload("templates", func(context.Context) error { return renderer.Reparse() })
+//	sv.OnReload("logfile", func(context.Context) error { return logFile.Reopen() }) // see logging.OpenFile
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/logging"
+)
+
+// RunFunc runs a component until ctx is done. Returning an error before that stops all the other components.
+type RunFunc func(ctx context.Context) error
+
+// ReloadFunc re-reads configuration, templates or files without stopping anything
+type ReloadFunc func(ctx context.Context) error
+
+// DefaultStopTimeout is used for components that were added without one
+const DefaultStopTimeout = 10 * time.Second
+
+// StopTimeoutError is returned by Run if a component didn't return in time after its context was canceled
+type StopTimeoutError struct {
+	Name    string
+	Timeout time.Duration
+}
+
+func (e StopTimeoutError) Error() string {
+	return fmt.Sprintf("lifecycle: %s didn't stop within %s", e.Name, e.Timeout)
+}
+
+// ComponentError wraps the error a component failed with
+type ComponentError struct {
+	Name string
+	Err  error
+}
+
+func (e ComponentError) Error() string {
+	return fmt.Sprintf("lifecycle: %s failed: %s", e.Name, e.Err)
+}
+
+func (e ComponentError) Unwrap() error { return e.Err }
+
+// ReloadError wraps the errors of reload hooks
+type ReloadError struct {
+	Name string
+	Err  error
+}
+
+func (e ReloadError) Error() string {
+	return fmt.Sprintf("lifecycle: reloading %s failed: %s", e.Name, e.Err)
+}
+
+func (e ReloadError) Unwrap() error { return e.Err }
+
+type reloader struct {
+	name string
+	fn   ReloadFunc
+}
+
+type component struct {
+	name    string
+	run     RunFunc
+	timeout time.Duration
+
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// Supervisor starts and stops components, see New
+type Supervisor struct {
+	comps   []*component
+	signals []os.Signal
+	log     kitlog.Logger
+
+	reloadSignals []os.Signal
+	reloadMu      sync.Mutex // one reload at a time
+	reloaders     []reloader
+}
+
+// Option is a function that changes a Supervisor during initialization
+type Option func(*Supervisor)
+
+// SetSignals sets the signals that stop all components, SIGINT and SIGTERM by default
+func SetSignals(sigs ...os.Signal) Option {
+	return func(sv *Supervisor) {
+		sv.signals = sigs
+	}
+}
+
+// SetReloadSignals sets the signals that call the reload hooks, SIGHUP by default.
+// Without any, Reload has to be called by the application.
+func SetReloadSignals(sigs ...os.Signal) Option {
+	return func(sv *Supervisor) {
+		sv.reloadSignals = sigs
+	}
+}
+
+// SetLogger sets the logger for the start and stop of components, logging.Logger("lifecycle") by default
+func SetLogger(l kitlog.Logger) Option {
+	return func(sv *Supervisor) {
+		sv.log = l
+	}
+}
+
+// New returns a Supervisor without components
+func New(opts ...Option) *Supervisor {
+	sv := &Supervisor{
+		signals:       []os.Signal{os.Interrupt, syscall.SIGTERM},
+		reloadSignals: []os.Signal{syscall.SIGHUP},
+		log:           logging.Logger("lifecycle"),
+	}
+	for _, o := range opts {
+		o(sv)
+	}
+	return sv
+}
+
+// Add registers a component. They are started in the order they were added and stopped in reverse,
+// so the ones that others depend on (like a database) should be added first.
+// stopTimeout is how long Run waits for it to return after its context was canceled.
+func (sv *Supervisor) Add(name string, run RunFunc, stopTimeout time.Duration) {
+	if stopTimeout <= 0 {
+		stopTimeout = DefaultStopTimeout
+	}
+	sv.comps = append(sv.comps, &component{name: name, run: run, timeout: stopTimeout})
+}
+
+// OnReload registers a hook for Reload. Hooks are called in the order they were added.
+func (sv *Supervisor) OnReload(name string, fn ReloadFunc) {
+	sv.reloadMu.Lock()
+	sv.reloaders = append(sv.reloaders, reloader{name: name, fn: fn})
+	sv.reloadMu.Unlock()
+}
+
+// Reload calls all reload hooks, even if one of them fails, and returns the ReloadError of the first failure.
+// A failed reload doesn't stop anything; the hooks should keep their current state in that case.
+func (sv *Supervisor) Reload(ctx context.Context) error {
+	sv.reloadMu.Lock()
+	defer sv.reloadMu.Unlock()
+
+	var firstErr error
+	for _, r := range sv.reloaders {
+		if err := r.fn(ctx); err != nil {
+			sv.log.Log("event", "error", "msg", "reload failed", "hook", r.name, "err", err)
+			if firstErr == nil {
+				firstErr = ReloadError{Name: r.name, Err: err}
+			}
+			continue
+		}
+		sv.log.Log("event", "reloaded", "hook", r.name)
+	}
+	return firstErr
+}
+
+// Run starts all components and blocks until ctx is done, a signal arrives or one of them fails.
+// Components that return nil before that are considered finished and don't stop the others.
+// It returns the ComponentError of the first failure, or else the first StopTimeoutError.
+func (sv *Supervisor) Run(ctx context.Context) error {
+	log := sv.log
+	if len(sv.signals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, sv.signals...)
+		defer stop()
+	}
+
+	var reload chan os.Signal
+	if len(sv.reloadSignals) > 0 {
+		reload = make(chan os.Signal, 1)
+		signal.Notify(reload, sv.reloadSignals...)
+		defer signal.Stop(reload)
+	}
+
+	failed := make(chan *component, len(sv.comps))
+	for _, c := range sv.comps {
+		// not derived from ctx, they are canceled one by one on shutdown
+		var cctx context.Context
+		cctx, c.cancel = context.WithCancel(context.Background())
+		c.done = make(chan error, 1)
+
+		go func(c *component) {
+			err := c.run(cctx)
+			if err != nil {
+				failed <- c
+			}
+			c.done <- err
+		}(c)
+		log.Log("event", "started", "component", c.name)
+	}
+
+	var firstErr error
+wait:
+	for {
+		select {
+		case sig := <-reload:
+			log.Log("event", "reload", "signal", sig)
+			sv.Reload(ctx) // failures are logged and shouldn't take the service down
+		case <-ctx.Done():
+			log.Log("event", "shutdown")
+			break wait
+		case c := <-failed:
+			err := <-c.done
+			firstErr = ComponentError{Name: c.name, Err: err}
+			c.done <- err // for the shutdown below
+			log.Log("event", "shutdown", "failed", c.name, "err", err)
+			break wait
+		}
+	}
+
+	for i := len(sv.comps) - 1; i >= 0; i-- {
+		c := sv.comps[i]
+		c.cancel()
+
+		timer := time.NewTimer(c.timeout)
+		select {
+		case err := <-c.done:
+			if err != nil && !errors.Is(err, context.Canceled) && firstErr == nil {
+				firstErr = ComponentError{Name: c.name, Err: err}
+			}
+			log.Log("event", "stopped", "component", c.name)
+		case <-timer.C:
+			log.Log("event", "error", "msg", "component didn't stop in time", "component", c.name, "timeout", c.timeout)
+			if firstErr == nil {
+				firstErr = StopTimeoutError{Name: c.name, Timeout: c.timeout}
+			}
+		}
+		timer.Stop()
+	}
+	return firstErr
+}
@@ -0,0 +1,121 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	kitlog "go.mindeco.de/log"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(e string) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+func (r *recorder) component(name string) RunFunc {
+	return func(ctx context.Context) error {
+		r.add("start " + name)
+		<-ctx.Done()
+		r.add("stop " + name)
+		return nil
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	a := assert.New(t)
+
+	var rec recorder
+	sv := New(SetLogger(kitlog.NewNopLogger()))
+	sv.Add("db", rec.component("db"), time.Second)
+	sv.Add("http", rec.component("http"), time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error)
+	go func() { ran <- sv.Run(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	a.NoError(<-ran)
+	a.Equal([]string{"stop http", "stop db"}, rec.events[2:], "stopped in reverse order")
+}
+
+func TestFailure(t *testing.T) {
+	a := assert.New(t)
+
+	var rec recorder
+	boom := errors.New("boom")
+	sv := New(SetLogger(kitlog.NewNopLogger()))
+	sv.Add("db", rec.component("db"), time.Second)
+	sv.Add("worker", func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return boom
+	}, time.Second)
+	sv.Add("done", func(ctx context.Context) error { return nil }, time.Second)
+
+	err := sv.Run(context.Background())
+	var cerr ComponentError
+	a.True(errors.As(err, &cerr))
+	a.Equal("worker", cerr.Name)
+	a.True(errors.Is(err, boom))
+	a.Contains(rec.events, "stop db", "the others are stopped")
+}
+
+func TestStopTimeout(t *testing.T) {
+	a := assert.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	sv := New(SetLogger(kitlog.NewNopLogger()))
+	sv.Add("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	}, 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := sv.Run(ctx)
+	a.Equal(StopTimeoutError{Name: "stuck", Timeout: 20 * time.Millisecond}, err)
+}
+
+func TestReload(t *testing.T) {
+	a := assert.New(t)
+
+	var rec recorder
+	boom := errors.New("boom")
+	sv := New(SetLogger(kitlog.NewNopLogger()))
+	sv.Add("http", rec.component("http"), time.Second)
+	sv.OnReload("broken", func(context.Context) error { return boom })
+	sv.OnReload("templates", func(context.Context) error {
+		rec.add("reload templates")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error)
+	go func() { ran <- sv.Run(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+
+	a.NoError(syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	time.Sleep(10 * time.Millisecond)
+
+	err := sv.Reload(ctx)
+	a.Equal(ReloadError{Name: "broken", Err: boom}, err)
+	a.True(errors.Is(err, boom))
+
+	cancel()
+	a.NoError(<-ran, "failed reloads don't stop anything")
+	a.Equal([]string{"start http", "reload templates", "reload templates", "stop http"}, rec.events)
+}
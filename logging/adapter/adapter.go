@@ -0,0 +1,61 @@
+// Package adapter is the single logging interface of this module and converts between it and log/slog.
+//
+// Logger is the interface of go-kit/log (and go.mindeco.de/log, its fork), so loggers of those can be passed as they are.
+// Applications that use slog can wrap theirs with FromSlog instead of setting up a second logging stack:
+//
+//	r, err := render.New(fs, render.SetLogger(adapter.FromSlog(slog.Default())))
+//
+// The other way around, NewSlogHandler lets code that wants a *slog.Logger write to a Logger.
+package adapter
+
+import (
+	"fmt"
+
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/log/level"
+)
+
+// Logger logs alternating keys and values, see go.mindeco.de/log
+type Logger = kitlog.Logger
+
+// Nop returns a Logger that discards everything
+func Nop() Logger {
+	return kitlog.NewNopLogger()
+}
+
+// the keys that are used as the message of the slog record, in this order
+var messageKeys = []string{"msg", "event"}
+
+// split separates the level and message from the other keyvals
+func split(keyvals []interface{}) (lvl level.Value, msg string, rest []interface{}) {
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, kitlog.ErrMissingValue)
+	}
+
+	msgIdx := -1
+	for _, mk := range messageKeys {
+		for i := 0; i < len(keyvals); i += 2 {
+			if k, ok := keyvals[i].(string); ok && k == mk {
+				msgIdx = i
+				break
+			}
+		}
+		if msgIdx != -1 {
+			break
+		}
+	}
+
+	rest = make([]interface{}, 0, len(keyvals))
+	for i := 0; i < len(keyvals); i += 2 {
+		if v, ok := keyvals[i+1].(level.Value); ok && keyvals[i] == level.Key() {
+			lvl = v
+			continue
+		}
+		if i == msgIdx {
+			msg = fmt.Sprint(keyvals[i+1])
+			continue
+		}
+		rest = append(rest, keyvals[i], keyvals[i+1])
+	}
+	return lvl, msg, rest
+}
@@ -0,0 +1,116 @@
+//go:build go1.21
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.mindeco.de/log/level"
+)
+
+// FromSlog returns a Logger that writes to l. The level (of go.mindeco.de/log/level) becomes the level of the record,
+// Info if there is none, and the value of msg (or else event) its message.
+func FromSlog(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (sl slogLogger) Log(keyvals ...interface{}) error {
+	lvl, msg, rest := split(keyvals)
+
+	attrs := make([]slog.Attr, 0, len(rest)/2)
+	for i := 0; i < len(rest); i += 2 {
+		attrs = append(attrs, slog.Any(fmt.Sprint(rest[i]), rest[i+1]))
+	}
+	sl.l.LogAttrs(context.Background(), toSlogLevel(lvl), msg, attrs...)
+	return nil
+}
+
+func toSlogLevel(lvl level.Value) slog.Level {
+	switch lvl {
+	case level.ErrorValue():
+		return slog.LevelError
+	case level.WarnValue():
+		return slog.LevelWarn
+	case level.DebugValue():
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+func fromSlogLevel(l slog.Level) level.Value {
+	switch {
+	case l >= slog.LevelError:
+		return level.ErrorValue()
+	case l >= slog.LevelWarn:
+		return level.WarnValue()
+	case l >= slog.LevelInfo:
+		return level.InfoValue()
+	}
+	return level.DebugValue()
+}
+
+// NewSlogHandler returns a slog.Handler that writes the records to l, with the level first and the message as msg.
+// Groups are flattened into the keys, like group.key. Filtering by level is left to l, see level.NewFilter.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+type slogHandler struct {
+	l      Logger
+	attrs  []interface{} // from WithAttrs
+	prefix string        // from WithGroup
+}
+
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	kv := make([]interface{}, 0, 4+len(h.attrs)+2*r.NumAttrs())
+	kv = append(kv, level.Key(), fromSlogLevel(r.Level), "msg", r.Message)
+	kv = append(kv, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		kv = appendAttr(kv, h.prefix, a)
+		return true
+	})
+	return h.l.Log(kv...)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]interface{}(nil), h.attrs...)
+	for _, a := range attrs {
+		h2.attrs = appendAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+func appendAttr(kv []interface{}, prefix string, a slog.Attr) []interface{} {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			kv = appendAttr(kv, prefix, ga)
+		}
+		return kv
+	}
+	if a.Key == "" {
+		return kv
+	}
+	return append(kv, prefix+a.Key, v.Any())
+}
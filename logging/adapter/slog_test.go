@@ -0,0 +1,54 @@
+//go:build go1.21
+
+package adapter
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	kitlog "go.mindeco.de/log"
+	"go.mindeco.de/log/level"
+)
+
+func TestFromSlog(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	sl := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	l := FromSlog(sl)
+	level.Error(l).Log("event", "render failed", "tpl", "/index.tmpl", "err", errors.New("boom"))
+	a.Equal(`level=ERROR msg="render failed" tpl=/index.tmpl err=boom`+"\n", buf.String())
+
+	buf.Reset()
+	kitlog.With(l, "module", "auth").Log("msg", "hello", "odd")
+	a.Equal(`level=INFO msg=hello module=auth odd=(MISSING)`+"\n", buf.String())
+}
+
+func TestSlogHandler(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	sl := slog.New(NewSlogHandler(kitlog.NewLogfmtLogger(&buf)))
+
+	sl.Warn("slow query", "took", 3, slog.Group("db", "table", "users"))
+	a.Equal("level=warn msg=\"slow query\" took=3 db.table=users\n", buf.String())
+
+	buf.Reset()
+	sl.With("module", "jobs").WithGroup("job").Debug("done", "id", 23)
+	a.Equal("level=debug msg=done module=jobs job.id=23\n", buf.String())
+
+	a.False(strings.Contains(buf.String(), "time="), "left to the Logger")
+}
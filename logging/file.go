@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// File is a log file that can be reopened after logrotate moved it, without losing lines in between.
+// Pass it to SetupLogging and call Reopen from a lifecycle reload hook (or the postrotate script's SIGHUP).
+type File struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenFile opens (or creates) path for appending
+func OpenFile(path string) (*File, error) {
+	f, err := openAppend(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{path: path, f: f}, nil
+}
+
+func openAppend(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("logging: failed to open log file: %w", err)
+	}
+	return f, nil
+}
+
+func (lf *File) Write(p []byte) (int, error) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	return lf.f.Write(p)
+}
+
+// Reopen opens the path again and closes the old file. If that fails, it keeps writing to the old one.
+func (lf *File) Reopen() error {
+	f, err := openAppend(lf.path)
+	if err != nil {
+		return err
+	}
+
+	lf.mu.Lock()
+	old := lf.f
+	lf.f = f
+	lf.mu.Unlock()
+	return old.Close()
+}
+
+// Close closes the current file
+func (lf *File) Close() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	return lf.f.Close()
+}